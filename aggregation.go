@@ -0,0 +1,223 @@
+package cloudcarbonexporter
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// OtherLabelValue is the value given to the labels of impacts folded out of the top N
+const OtherLabelValue = "other"
+
+// AggregationRule sums impacts sharing the same values for the By labels into a single
+// impact. The explorer label is always kept, and so is the kind label of rules restricted
+// to a kind, so that groups of different rules never share the same labels.
+type AggregationRule struct {
+	// Kind restricts the rule to impacts of this kind. The rule applies to all impacts when empty.
+	Kind string
+	// By lists the labels kept on aggregated impacts
+	By []string
+	// TopN keeps the N groups with the highest emissions and folds the other ones into a
+	// single group where all By labels are set to "other". Zero keeps all groups.
+	TopN int
+}
+
+// ParseAggregationRule parses a rule formatted as "kind=ec2/instance;by=location,tag_team;top=10".
+// The kind and top parameters are optional.
+func ParseAggregationRule(s string) (rule AggregationRule, err error) {
+	for _, param := range strings.Split(s, ";") {
+		param = strings.TrimSpace(param)
+		if param == "" {
+			continue
+		}
+
+		key, value, found := strings.Cut(param, "=")
+		if !found {
+			return rule, fmt.Errorf("invalid aggregation parameter %q: expected key=value", param)
+		}
+
+		switch strings.TrimSpace(key) {
+		case "kind":
+			rule.Kind = strings.TrimSpace(value)
+		case "by":
+			for _, label := range strings.Split(value, ",") {
+				if label = strings.TrimSpace(label); label != "" {
+					rule.By = append(rule.By, label)
+				}
+			}
+		case "top":
+			rule.TopN, err = strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || rule.TopN < 0 {
+				return rule, fmt.Errorf("invalid aggregation top %q: must be a positive integer", value)
+			}
+		default:
+			return rule, fmt.Errorf("unknown aggregation parameter %q", key)
+		}
+	}
+
+	if len(rule.By) == 0 {
+		return rule, fmt.Errorf("aggregation rule %q must set at least one label in by", s)
+	}
+
+	return rule, nil
+}
+
+func (rule AggregationRule) matches(impact *Impact) bool {
+	return rule.Kind == "" || impact.Labels["kind"] == rule.Kind
+}
+
+// AggregateImpacts applies the rules to the impacts. Each impact is aggregated by the first
+// rule matching it, impacts not matching any rule are returned unchanged. The result does
+// not depend on the impacts order: groups are returned sorted by labels and their members
+// are summed in a stable order.
+func AggregateImpacts(rules []AggregationRule, impacts []*Impact) []*Impact {
+	if len(rules) == 0 {
+		return impacts
+	}
+
+	matched := make([][]*Impact, len(rules))
+	result := make([]*Impact, 0, len(impacts))
+
+impactsLoop:
+	for _, impact := range impacts {
+		for i, rule := range rules {
+			if rule.matches(impact) {
+				matched[i] = append(matched[i], impact)
+				continue impactsLoop
+			}
+		}
+		result = append(result, impact)
+	}
+
+	for i, rule := range rules {
+		result = append(result, rule.aggregate(matched[i])...)
+	}
+
+	return result
+}
+
+// aggregate sums impacts into groups
+func (rule AggregationRule) aggregate(impacts []*Impact) []*Impact {
+	groups := make(map[string][]*Impact)
+	for _, impact := range impacts {
+		labels := rule.groupLabels(impact.Labels, false)
+		fingerprint := labelsFingerprint(labels)
+		groups[fingerprint] = append(groups[fingerprint], impact)
+	}
+
+	aggregated := make([]*Impact, 0, len(groups))
+	for _, members := range groups {
+		sortImpacts(members)
+		aggregated = append(aggregated, sumImpacts(rule.groupLabels(members[0].Labels, false), members))
+	}
+	sortImpacts(aggregated)
+
+	if rule.TopN == 0 || len(aggregated) <= rule.TopN {
+		return aggregated
+	}
+
+	slices.SortStableFunc(aggregated, func(a, b *Impact) int {
+		return cmp.Compare(totalEmissions(b), totalEmissions(a))
+	})
+
+	top := aggregated[:rule.TopN]
+	others := aggregated[rule.TopN:]
+	sortImpacts(others)
+
+	// others may span several explorers, they are folded by explorer
+	byExplorer := make(map[string][]*Impact)
+	for _, impact := range others {
+		byExplorer[impact.Labels["explorer"]] = append(byExplorer[impact.Labels["explorer"]], impact)
+	}
+	for _, members := range byExplorer {
+		top = append(top, sumImpacts(rule.groupLabels(members[0].Labels, true), members))
+	}
+	sortImpacts(top)
+
+	return top
+}
+
+// groupLabels returns the labels of the group an impact belongs to
+func (rule AggregationRule) groupLabels(labels map[string]string, other bool) map[string]string {
+	group := make(map[string]string, len(rule.By)+2)
+	for _, label := range rule.By {
+		switch {
+		case other:
+			group[label] = OtherLabelValue
+		case labels[label] != "":
+			group[label] = labels[label]
+		}
+	}
+	if explorer, found := labels["explorer"]; found {
+		group["explorer"] = explorer
+	}
+	if rule.Kind != "" {
+		group["kind"] = rule.Kind
+	}
+	return group
+}
+
+// sumImpacts returns a single impact with the sum of energy, emissions and components of
+// the impacts. Impacts must be sorted to get a deterministic result.
+func sumImpacts(labels map[string]string, impacts []*Impact) *Impact {
+	sum := &Impact{Labels: labels}
+	for _, impact := range impacts {
+		sum.Energy += impact.Energy
+		sum.OverheadEnergy += impact.OverheadEnergy
+		sum.EnergyEmissions = addEmissionsOverTime(sum.EnergyEmissions, impact.EnergyEmissions)
+		sum.MarketEmissions = addEmissionsOverTime(sum.MarketEmissions, impact.MarketEmissions)
+		sum.EmbodiedEmissions = addEmissionsOverTime(sum.EmbodiedEmissions, impact.EmbodiedEmissions)
+		sum.OnSiteWater += impact.OnSiteWater
+		sum.OffSiteWater += impact.OffSiteWater
+
+		for _, component := range sortedComponents(impact.Components) {
+			componentImpact := impact.Components[component]
+			if sum.Components == nil {
+				sum.Components = make(map[Component]*ComponentImpact)
+			}
+			summed, found := sum.Components[component]
+			if !found {
+				summed = new(ComponentImpact)
+				sum.Components[component] = summed
+			}
+			summed.Energy += componentImpact.Energy
+			summed.EnergyEmissions = addEmissionsOverTime(summed.EnergyEmissions, componentImpact.EnergyEmissions)
+			summed.EmbodiedEmissions = addEmissionsOverTime(summed.EmbodiedEmissions, componentImpact.EmbodiedEmissions)
+		}
+	}
+	return sum
+}
+
+func sortedComponents(components map[Component]*ComponentImpact) []Component {
+	sorted := make([]Component, 0, len(components))
+	for component := range components {
+		sorted = append(sorted, component)
+	}
+	slices.Sort(sorted)
+	return sorted
+}
+
+// sortImpacts sorts impacts by labels, then by value for impacts sharing the same labels
+func sortImpacts(impacts []*Impact) {
+	slices.SortFunc(impacts, func(a, b *Impact) int {
+		return cmp.Or(
+			strings.Compare(labelsFingerprint(a.Labels), labelsFingerprint(b.Labels)),
+			cmp.Compare(a.Energy, b.Energy),
+			cmp.Compare(totalEmissions(a), totalEmissions(b)),
+		)
+	})
+}
+
+// totalEmissions returns the usage and embodied emissions of an impact in kgCO2eq/day
+func totalEmissions(impact *Impact) float64 {
+	total := 0.0
+	if impact.EnergyEmissions.During != 0 {
+		total += impact.EnergyEmissions.KgCO2eq_day()
+	}
+	if impact.EmbodiedEmissions.During != 0 {
+		total += impact.EmbodiedEmissions.KgCO2eq_day()
+	}
+	return total
+}
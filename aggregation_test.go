@@ -0,0 +1,93 @@
+package cloudcarbonexporter
+
+import (
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseAggregationRule(t *testing.T) {
+	rule, err := ParseAggregationRule("kind=ec2/instance; by=location,tag_team ;top=10")
+	assert.NoError(t, err)
+	assert.Equal(t, AggregationRule{Kind: "ec2/instance", By: []string{"location", "tag_team"}, TopN: 10}, rule)
+
+	_, err = ParseAggregationRule("kind=ec2/instance")
+	assert.Error(t, err)
+	_, err = ParseAggregationRule("by=location;top=-1")
+	assert.Error(t, err)
+	_, err = ParseAggregationRule("by=location;sort=asc")
+	assert.Error(t, err)
+}
+
+func newAggregationImpact(kind, location, team string, energy Energy) *Impact {
+	impact := &Impact{
+		Labels: MergeLabels(map[string]string{"explorer": "aws", "kind": kind, "location": location, "tag_team": team}),
+	}
+	impact.AddComponent(ComponentCPU, energy, EmissionsOverTime{Emissions: Emissions(energy) * 100, During: 24 * time.Hour})
+	impact.ApplyCarbonIntensity(100)
+	return impact
+}
+
+func TestAggregateImpacts(t *testing.T) {
+	impacts := []*Impact{
+		newAggregationImpact("ec2/instance", "eu-west-3", "data", 1.1),
+		newAggregationImpact("ec2/instance", "us-east-1", "data", 2.2),
+		newAggregationImpact("ec2/instance", "us-east-1", "web", 40),
+		newAggregationImpact("ec2/instance", "eu-west-1", "ops", 3),
+		newAggregationImpact("ec2/instance", "eu-west-1", "", 0.7),
+		newAggregationImpact("s3/bucket", "eu-west-1", "data", 5),
+	}
+
+	rules := []AggregationRule{{Kind: "ec2/instance", By: []string{"tag_team"}, TopN: 2}}
+	aggregated := AggregateImpacts(rules, impacts)
+
+	// the bucket is not matched by the rule
+	assert.Len(t, aggregated, 4)
+	assert.Equal(t, "s3/bucket", aggregated[0].Labels["kind"])
+
+	byTeam := make(map[string]*Impact)
+	for _, impact := range aggregated[1:] {
+		assert.Equal(t, "aws", impact.Labels["explorer"])
+		assert.NotContains(t, impact.Labels, "location")
+		byTeam[impact.Labels["tag_team"]] = impact
+	}
+
+	assert.InDelta(t, 40, float64(byTeam["web"].Energy), 0.0001)
+	assert.InDelta(t, 3.3, float64(byTeam["data"].Energy), 0.0001)
+	assert.InDelta(t, 3.7, float64(byTeam[OtherLabelValue].Energy), 0.0001)
+	assert.InDelta(t, 3.7, float64(byTeam[OtherLabelValue].Components[ComponentCPU].Energy), 0.0001)
+
+	// the result does not depend on the order of the impacts
+	for range 10 {
+		shuffled := make([]*Impact, len(impacts))
+		copy(shuffled, impacts)
+		rand.Shuffle(len(shuffled), func(i, j int) { shuffled[i], shuffled[j] = shuffled[j], shuffled[i] })
+		assert.Equal(t, aggregated, AggregateImpacts(rules, shuffled))
+	}
+
+	assert.Equal(t, impacts, AggregateImpacts(nil, impacts))
+}
+
+func TestAggregateImpactsKinds(t *testing.T) {
+	impacts := []*Impact{
+		newAggregationImpact("ec2/instance", "eu-west-3", "data", 1),
+		newAggregationImpact("ec2/instance", "eu-west-3", "web", 2),
+		newAggregationImpact("ec2/volume", "eu-west-3", "data", 4),
+	}
+
+	rules := []AggregationRule{
+		{Kind: "ec2/instance", By: []string{"location"}},
+		{Kind: "ec2/volume", By: []string{"location"}, TopN: 1},
+	}
+	aggregated := AggregateImpacts(rules, impacts)
+
+	// groups of different kinds keep distinct labels so their series do not collide
+	assert.Len(t, aggregated, 2)
+	assert.Equal(t, map[string]string{"explorer": "aws", "kind": "ec2/instance", "location": "eu-west-3"}, aggregated[0].Labels)
+	assert.InDelta(t, 3, float64(aggregated[0].Energy), 0.0001)
+	assert.Equal(t, map[string]string{"explorer": "aws", "kind": "ec2/volume", "location": "eu-west-3"}, aggregated[1].Labels)
+	assert.InDelta(t, 4, float64(aggregated[1].Energy), 0.0001)
+	assert.NotEqual(t, labelsFingerprint(aggregated[0].Labels), labelsFingerprint(aggregated[1].Labels))
+}
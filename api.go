@@ -0,0 +1,236 @@
+package cloudcarbonexporter
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultPageSize = 100
+	maxPageSize     = 1000
+)
+
+// ImpactsResponse is the body returned by the impacts api
+type ImpactsResponse struct {
+	CollectedAt time.Time         `json:"collected_at"`
+	TotalSize   int               `json:"total_size"`
+	Impacts     []*ImpactResource `json:"impacts"`
+	// NextPageToken is empty on the last page
+	NextPageToken string `json:"next_page_token,omitempty"`
+}
+
+// ImpactResource is the json representation of an impact
+type ImpactResource struct {
+	Labels      map[string]string `json:"labels"`
+	EnergyWatts float64           `json:"energy_watts"`
+	// OverheadWatts is the share of EnergyWatts drawn by the datacenter overhead
+	OverheadWatts            float64 `json:"overhead_watts"`
+	UsageEmissionsKgCO2eqDay float64 `json:"usage_emissions_kgco2eq_day"`
+	// MarketUsageEmissionsKgCO2eqDay are the market-based usage emissions, if known
+	MarketUsageEmissionsKgCO2eqDay float64                          `json:"market_usage_emissions_kgco2eq_day,omitempty"`
+	EmbodiedEmissionsKgCO2eqDay    float64                          `json:"embodied_emissions_kgco2eq_day"`
+	OnSiteWaterLitresDay           float64                          `json:"onsite_water_litres_day"`
+	OffSiteWaterLitresDay          float64                          `json:"offsite_water_litres_day"`
+	Components                     map[Component]*ComponentResource `json:"components,omitempty"`
+	Inputs                         ImpactInputs                     `json:"inputs"`
+}
+
+// ComponentResource is the json representation of an impact component
+type ComponentResource struct {
+	EnergyWatts                 float64 `json:"energy_watts"`
+	UsageEmissionsKgCO2eqDay    float64 `json:"usage_emissions_kgco2eq_day"`
+	EmbodiedEmissionsKgCO2eqDay float64 `json:"embodied_emissions_kgco2eq_day"`
+}
+
+// NewImpactResource returns the json representation of an impact
+func NewImpactResource(impact *Impact) *ImpactResource {
+	resource := &ImpactResource{
+		Labels:                      impact.Labels,
+		EnergyWatts:                 float64(impact.Energy),
+		OverheadWatts:               float64(impact.OverheadEnergy),
+		UsageEmissionsKgCO2eqDay:    impact.EnergyEmissions.KgCO2eq_day(),
+		EmbodiedEmissionsKgCO2eqDay: impact.EmbodiedEmissions.KgCO2eq_day(),
+		OnSiteWaterLitresDay:        float64(impact.OnSiteWater),
+		OffSiteWaterLitresDay:       float64(impact.OffSiteWater),
+		Inputs:                      impact.Inputs,
+	}
+	if impact.MarketEmissions.During != 0 {
+		resource.MarketUsageEmissionsKgCO2eqDay = impact.MarketEmissions.KgCO2eq_day()
+	}
+
+	if len(impact.Components) > 0 {
+		resource.Components = make(map[Component]*ComponentResource, len(impact.Components))
+		for component, componentImpact := range impact.Components {
+			resource.Components[component] = &ComponentResource{
+				EnergyWatts:                 float64(componentImpact.Energy),
+				UsageEmissionsKgCO2eqDay:    componentImpact.EnergyEmissions.KgCO2eq_day(),
+				EmbodiedEmissionsKgCO2eqDay: componentImpact.EmbodiedEmissions.KgCO2eq_day(),
+			}
+		}
+	}
+
+	return resource
+}
+
+// ImpactsHandler serves the impacts of the source last snapshot as json. Impacts can
+// be filtered with the kind, location and label.<name> query parameters and are paginated
+// with the page_size and page_token parameters.
+type ImpactsHandler struct {
+	source SnapshotSource
+}
+
+// NewImpactsHandler creates a new ImpactsHandler serving the source last snapshot
+func NewImpactsHandler(source SnapshotSource) *ImpactsHandler {
+	return &ImpactsHandler{
+		source: source,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (handler *ImpactsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	snapshot := handler.source.Snapshot()
+	if snapshot == nil {
+		http.Error(w, "no collection completed yet", http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+
+	pageSize := defaultPageSize
+	if s := query.Get("page_size"); s != "" {
+		size, err := strconv.Atoi(s)
+		if err != nil || size <= 0 {
+			http.Error(w, "page_size must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		pageSize = min(size, maxPageSize)
+	}
+
+	var after *pagePosition
+	if token := query.Get("page_token"); token != "" {
+		position, err := parsePageToken(token)
+		if err != nil {
+			http.Error(w, "invalid page_token", http.StatusBadRequest)
+			return
+		}
+		after = position
+	}
+
+	filters := impactFilters(query)
+
+	// impacts are sorted by labels so that pages stay stable between two snapshots. Impacts
+	// with the same labels are told apart by their rank among them.
+	type entry struct {
+		position pagePosition
+		impact   *Impact
+	}
+	entries := make([]entry, 0, len(snapshot.Impacts))
+	for _, impact := range snapshot.Impacts {
+		if !matchLabels(impact.Labels, filters) {
+			continue
+		}
+		entries = append(entries, entry{position: pagePosition{fingerprint: labelsFingerprint(impact.Labels)}, impact: impact})
+	}
+	slices.SortStableFunc(entries, func(a, b entry) int {
+		return strings.Compare(a.position.fingerprint, b.position.fingerprint)
+	})
+	for i := 1; i < len(entries); i++ {
+		if entries[i].position.fingerprint == entries[i-1].position.fingerprint {
+			entries[i].position.rank = entries[i-1].position.rank + 1
+		}
+	}
+
+	start := 0
+	if after != nil {
+		start = sort.Search(len(entries), func(i int) bool {
+			return entries[i].position.after(*after)
+		})
+	}
+	end := min(start+pageSize, len(entries))
+
+	response := &ImpactsResponse{
+		CollectedAt: snapshot.CollectedAt,
+		TotalSize:   len(entries),
+		Impacts:     make([]*ImpactResource, 0, end-start),
+	}
+	for _, e := range entries[start:end] {
+		response.Impacts = append(response.Impacts, NewImpactResource(e.impact))
+	}
+	if end < len(entries) {
+		response.NextPageToken = entries[end-1].position.token()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("failed to write impacts", "err", err.Error())
+	}
+}
+
+// pagePosition is the position of an impact in the pages: its labels fingerprint and its
+// rank among the impacts with the same labels
+type pagePosition struct {
+	fingerprint string
+	rank        int
+}
+
+// after returns true if the position comes after the other one
+func (position pagePosition) after(other pagePosition) bool {
+	if c := strings.Compare(position.fingerprint, other.fingerprint); c != 0 {
+		return c > 0
+	}
+	return position.rank > other.rank
+}
+
+// token returns the page token of the page starting after the position
+func (position pagePosition) token() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(position.rank) + ":" + position.fingerprint))
+}
+
+// parsePageToken returns the position encoded in a page token
+func parsePageToken(token string) (*pagePosition, error) {
+	decoded, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	rank, fingerprint, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return nil, fmt.Errorf("page token has no rank")
+	}
+	position := &pagePosition{fingerprint: fingerprint}
+	if position.rank, err = strconv.Atoi(rank); err != nil {
+		return nil, err
+	}
+	return position, nil
+}
+
+// impactFilters returns the labels an impact must match from the query parameters
+func impactFilters(query url.Values) map[string]string {
+	filters := make(map[string]string)
+	for param, values := range query {
+		switch {
+		case param == "kind" || param == "location":
+			filters[param] = values[0]
+		case strings.HasPrefix(param, "label."):
+			filters[strings.TrimPrefix(param, "label.")] = values[0]
+		}
+	}
+	return filters
+}
+
+func matchLabels(labels map[string]string, filters map[string]string) bool {
+	for name, value := range filters {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
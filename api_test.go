@@ -0,0 +1,106 @@
+package cloudcarbonexporter
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func getImpacts(t *testing.T, handler http.Handler, query url.Values) *ImpactsResponse {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/impacts?"+query.Encode(), nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	response := new(ImpactsResponse)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(response))
+	return response
+}
+
+func TestImpactsHandler(t *testing.T) {
+	explorer := newFakeExplorer()
+	explorer.impacts = append(explorer.impacts, &Impact{
+		Labels: map[string]string{"kind": "fake/instance", "location": "eu-west-3", "team": "data"},
+		Energy: 30,
+		Inputs: ImpactInputs{
+			InstanceType: "n2-standard-2",
+			VCPU:         2,
+			Processor:    &ProcessorInputs{Name: "Intel Xeon", Threads: 2},
+			CPUAverage:   12,
+		},
+	})
+	collector := NewCollector("fake", explorer)
+	handler := NewImpactsHandler(collector)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/impacts", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	collector.Collect(t.Context())
+
+	all := getImpacts(t, handler, url.Values{})
+	assert.Equal(t, 3, all.TotalSize)
+	assert.Len(t, all.Impacts, 3)
+	assert.Empty(t, all.NextPageToken)
+
+	filtered := getImpacts(t, handler, url.Values{"location": {"eu-west-3"}, "label.team": {"data"}})
+	assert.Equal(t, 1, filtered.TotalSize)
+	assert.Equal(t, 30.0, filtered.Impacts[0].EnergyWatts)
+	assert.Equal(t, "n2-standard-2", filtered.Impacts[0].Inputs.InstanceType)
+	assert.Equal(t, "Intel Xeon", filtered.Impacts[0].Inputs.Processor.Name)
+
+	// paging through all impacts returns each of them once, in the same order
+	paged := make([]*ImpactResource, 0)
+	token := ""
+	for {
+		page := getImpacts(t, handler, url.Values{"page_size": {"2"}, "page_token": {token}})
+		assert.LessOrEqual(t, len(page.Impacts), 2)
+		paged = append(paged, page.Impacts...)
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+	assert.Equal(t, all.Impacts, paged)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/impacts?page_size=-1", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/v1/impacts?page_token=bm90YXRva2Vu", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestImpactsHandlerIdenticalLabels(t *testing.T) {
+	explorer := newFakeExplorer()
+	explorer.impacts = nil
+	for energy := range 5 {
+		explorer.impacts = append(explorer.impacts, &Impact{
+			Labels: map[string]string{"kind": "fake/instance", "location": "eu-west-3"},
+			Energy: Energy(energy),
+		})
+	}
+	collector := NewCollector("fake", explorer)
+	collector.Collect(t.Context())
+	handler := NewImpactsHandler(collector)
+
+	// impacts with the same labels across a page boundary are all returned once
+	energies := make([]float64, 0)
+	token := ""
+	for {
+		page := getImpacts(t, handler, url.Values{"page_size": {"2"}, "page_token": {token}})
+		for _, impact := range page.Impacts {
+			energies = append(energies, impact.EnergyWatts)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+	assert.Equal(t, []float64{0, 1, 2, 3, 4}, energies)
+}
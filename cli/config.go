@@ -0,0 +1,390 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/config"
+	"github.com/superdango/cloud-carbon-exporter/internal/replay"
+)
+
+// envPrefix prefixes the environment variables overriding the flags
+const envPrefix = "CLOUD_CARBON_EXPORTER_"
+
+// flags holds the command line flags. A flag overrides the configuration file when it is
+// set on the command line or through its environment variable.
+type flags struct {
+	config                 string
+	cloudProvider          string
+	providerValues         map[string]*string
+	listen                 string
+	logLevel               string
+	logFormat              string
+	printSupportedServices string
+	collectInterval        time.Duration
+	collectTimeout         time.Duration
+	otlpEndpoint           string
+	otlpProtocol           string
+	otlpHeaders            string
+	otlpInterval           time.Duration
+	otlpInsecure           bool
+	remoteWriteURL         string
+	remoteWriteHeaders     string
+	remoteWriteInterval    time.Duration
+	mode                   string
+	aggregations           stringsFlag
+	labelsTagPrefix        string
+	labelsAllow            stringsFlag
+	labelsDeny             stringsFlag
+	labelsRenames          stringsFlag
+	explorers              stringsFlag
+	reloadToken            string
+	recordDir              string
+	replayDir              string
+	electricityMaps        bool
+	factorFiles            stringsFlag
+}
+
+// providerFlag is a flag overriding a field of the built-in providers configuration
+type providerFlag struct {
+	name         string
+	provider     string
+	field        string
+	defaultValue string
+	usage        string
+}
+
+// providerFlags are the flags of the built-in providers. Other provider fields are set in
+// the configuration file or with -explorer cloud.<provider>.<field>=value.
+var providerFlags = []providerFlag{
+	{"cloud.gcp.projectid", "gcp", "project_id", "", "gcp project to explore resources from"},
+	{"cloud.gcp.scope", "gcp", "scope", "", "gcp organization or folder to explore all projects from (organizations/<id>, folders/<id>). explores cloud.gcp.projectid if empty"},
+	{"cloud.aws.rolearn", "aws", "role_arn", "", "aws role arn to assume"},
+	{"cloud.aws.defaultregion", "aws", "default_region", "us-east-1", "aws default region"},
+	{"cloud.aws.organization.role", "aws", "organization_role", "", "role template assumed in each aws organization member account (arn:aws:iam::{account}:role/carbon-reader). explores a single account if empty"},
+}
+
+// providerField returns the provider and the configuration field set by an -explorer
+// parameter, either a provider flag (cloud.aws.rolearn) or cloud.<provider>.<field>
+func providerField(key string) (provider string, field string, found bool) {
+	for _, providerFlag := range providerFlags {
+		if providerFlag.name == key {
+			return providerFlag.provider, providerFlag.field, true
+		}
+	}
+
+	key, found = strings.CutPrefix(key, "cloud.")
+	if !found {
+		return "", "", false
+	}
+	provider, field, found = strings.Cut(key, ".")
+	return provider, field, found && field != ""
+}
+
+// register defines the flags on the flag set with the configuration defaults
+func (f *flags) register(fs *flag.FlagSet, defaults *config.Config) {
+	fs.StringVar(&f.config, "config", "", "yaml configuration file. flags and environment variables override its settings")
+	fs.Var(&f.explorers, "explorer", "explorer to run, can be repeated (name=aws-prod,cloud.provider=aws,cloud.aws.rolearn=...,collect.timeout=1m). overrides the configured explorers")
+	fs.StringVar(&f.cloudProvider, "cloud.provider", "", "cloud provider type (gcp, aws, scw, demo). overrides the configured explorers")
+	f.providerValues = make(map[string]*string, len(providerFlags))
+	for _, providerFlag := range providerFlags {
+		f.providerValues[providerFlag.name] = fs.String(providerFlag.name, providerFlag.defaultValue, providerFlag.usage)
+	}
+	fs.StringVar(&f.mode, "mode", defaults.Mode, "run mode: serve metrics continuously (serve) or collect once, push to the configured sinks and exit (push)")
+	fs.StringVar(&f.listen, "listen", defaults.Listen, "addr to listen to")
+	fs.StringVar(&f.logLevel, "log.level", defaults.Log.Level, "log severity (debug, info, warn, error)")
+	fs.StringVar(&f.logFormat, "log.format", defaults.Log.Format, "log format (text, json)")
+	fs.DurationVar(&f.collectInterval, "collect.interval", defaults.Collect.Interval, "interval between two background collections")
+	fs.DurationVar(&f.collectTimeout, "collect.timeout", defaults.Collect.Timeout, "maximum duration of a collection")
+	fs.StringVar(&f.otlpEndpoint, "otlp.endpoint", "", "otlp receiver endpoint to push metrics to (host:port for grpc, url for http/protobuf). disabled if empty")
+	fs.StringVar(&f.otlpProtocol, "otlp.protocol", defaults.Sinks.OTLP.Protocol, "otlp protocol (grpc, http/protobuf)")
+	fs.StringVar(&f.otlpHeaders, "otlp.headers", "", "otlp headers sent with each push (key1=value1,key2=value2)")
+	fs.DurationVar(&f.otlpInterval, "otlp.interval", defaults.Sinks.OTLP.Interval, "interval between two otlp pushes")
+	fs.BoolVar(&f.otlpInsecure, "otlp.insecure", false, "disable tls for otlp grpc connections")
+	fs.StringVar(&f.remoteWriteURL, "remotewrite.url", "", "prometheus remote write url to push metrics to. disabled if empty")
+	fs.StringVar(&f.remoteWriteHeaders, "remotewrite.headers", "", "headers sent with each remote write request (key1=value1,key2=value2)")
+	fs.DurationVar(&f.remoteWriteInterval, "remotewrite.interval", defaults.Sinks.RemoteWrite.Interval, "interval between two remote write pushes")
+	fs.Var(&f.aggregations, "aggregate", "aggregation rule applied to impacts before they are exposed, can be repeated (kind=ec2/instance;by=location,tag_team;top=10)")
+	fs.StringVar(&f.labelsTagPrefix, "labels.tagprefix", defaults.Labels.TagPrefix, "prefix of the labels created from cloud tags")
+	fs.Var(&f.labelsAllow, "labels.allow", "regex of the cloud tag keys exposed as labels, can be repeated. all tags are exposed if not set")
+	fs.Var(&f.labelsDeny, "labels.deny", "regex of the cloud tag keys never exposed as labels, can be repeated")
+	fs.Var(&f.labelsRenames, "labels.rename", "label to rename (old=new), can be repeated")
+	fs.StringVar(&f.reloadToken, "reload.token", "", "bearer token required by the /-/reload endpoint, prefer its environment variable. endpoint is disabled if empty")
+	fs.StringVar(&f.recordDir, "record.dir", "", "directory where the cloud api exchanges of each explorer are appended to redacted fixture files (<explorer>.jsonl)")
+	fs.StringVar(&f.replayDir, "replay.dir", "", "directory of the fixture files replayed instead of calling the cloud apis. credentials are not loaded")
+	fs.BoolVar(&f.electricityMaps, "intensity.electricitymaps", false, "use the live grid carbon intensity of electricity maps, with the api key of ELECTRICITYMAPS_API_KEY. static intensities are used if it fails")
+	fs.Var(&f.factorFiles, "factors.file", "emission factors file (yaml, csv) overriding the built-in carbon intensity and pue by provider and location, can be repeated. read again on reload")
+	fs.StringVar(&f.printSupportedServices, "print-supported-services", "", "print on stdout the supported services list (markdown)")
+}
+
+// setFixtures sets the transport recording or replaying the cloud api exchanges of the
+// explorer in its fixture file
+func (f *flags) setFixtures(settings *cloudcarbonexporter.ExplorerSettings, explorer string) error {
+	switch {
+	case f.replayDir != "":
+		path := filepath.Join(f.replayDir, replay.FixtureName(explorer))
+		player, err := replay.Load(path)
+		if err != nil {
+			return err
+		}
+		settings.Transport, settings.Offline = player, true
+		slog.Info("replaying cloud api exchanges", "explorer", explorer, "fixture", path)
+	case f.recordDir != "":
+		if err := os.MkdirAll(f.recordDir, 0o700); err != nil {
+			return fmt.Errorf("failed to create record directory: %w", err)
+		}
+		path := filepath.Join(f.recordDir, replay.FixtureName(explorer))
+		settings.Transport = replay.NewRecorder(path, nil)
+		slog.Info("recording cloud api exchanges", "explorer", explorer, "fixture", path)
+	}
+	return nil
+}
+
+// envName returns the environment variable overriding the flag, e.g.
+// CLOUD_CARBON_EXPORTER_COLLECT_INTERVAL for collect.interval
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(flagName))
+}
+
+// setFromEnv sets the flags not set on the command line from their environment variable
+// and returns the names of all set flags
+func setFromEnv(fs *flag.FlagSet) (map[string]bool, error) {
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		value, found := os.LookupEnv(envName(f.Name))
+		if set[f.Name] || !found || err != nil {
+			return
+		}
+		if err = fs.Set(f.Name, value); err != nil {
+			err = fmt.Errorf("invalid environment variable %s: %w", envName(f.Name), err)
+			return
+		}
+		set[f.Name] = true
+	})
+
+	return set, err
+}
+
+// loadConfig loads the configuration file, if any, and overrides it with the set flags
+func (f *flags) loadConfig(set map[string]bool) (cfg *config.Config, err error) {
+	if f.recordDir != "" && f.replayDir != "" {
+		return nil, fmt.Errorf("record.dir and replay.dir cannot be set together")
+	}
+
+	cfg = config.Default()
+	if f.config != "" {
+		if cfg, err = config.Load(f.config); err != nil {
+			return nil, err
+		}
+	}
+
+	if set["listen"] {
+		cfg.Listen = f.listen
+	}
+	if set["mode"] {
+		cfg.Mode = f.mode
+	}
+	if set["log.level"] {
+		cfg.Log.Level = f.logLevel
+	}
+	if set["log.format"] {
+		cfg.Log.Format = f.logFormat
+	}
+	if set["collect.interval"] {
+		cfg.Collect.Interval = f.collectInterval
+	}
+	if set["collect.timeout"] {
+		cfg.Collect.Timeout = f.collectTimeout
+	}
+
+	if err := f.overrideExplorers(cfg, set); err != nil {
+		return nil, err
+	}
+
+	if set["labels.tagprefix"] {
+		cfg.Labels.TagPrefix = f.labelsTagPrefix
+	}
+	if set["labels.allow"] {
+		cfg.Labels.Allow = f.labelsAllow
+	}
+	if set["labels.deny"] {
+		cfg.Labels.Deny = f.labelsDeny
+	}
+	if set["labels.rename"] {
+		if cfg.Labels.Rename, err = cloudcarbonexporter.ParseRenames(f.labelsRenames...); err != nil {
+			return nil, err
+		}
+	}
+	if set["aggregate"] {
+		cfg.Aggregations = make([]config.Aggregation, 0, len(f.aggregations))
+		for _, s := range f.aggregations {
+			rule, err := cloudcarbonexporter.ParseAggregationRule(s)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Aggregations = append(cfg.Aggregations, config.Aggregation{Kind: rule.Kind, By: rule.By, Top: rule.TopN})
+		}
+	}
+
+	if set["otlp.endpoint"] {
+		cfg.Sinks.OTLP.Endpoint = f.otlpEndpoint
+	}
+	if set["otlp.protocol"] {
+		cfg.Sinks.OTLP.Protocol = f.otlpProtocol
+	}
+	if set["otlp.headers"] {
+		cfg.Sinks.OTLP.Headers = parseKeyValues(f.otlpHeaders)
+	}
+	if set["otlp.interval"] {
+		cfg.Sinks.OTLP.Interval = f.otlpInterval
+	}
+	if set["otlp.insecure"] {
+		cfg.Sinks.OTLP.Insecure = f.otlpInsecure
+	}
+	if set["remotewrite.url"] {
+		cfg.Sinks.RemoteWrite.URL = f.remoteWriteURL
+	}
+	if set["remotewrite.headers"] {
+		cfg.Sinks.RemoteWrite.Headers = parseKeyValues(f.remoteWriteHeaders)
+	}
+	if set["remotewrite.interval"] {
+		cfg.Sinks.RemoteWrite.Interval = f.remoteWriteInterval
+	}
+	if set["factors.file"] {
+		cfg.Factors.Files = f.factorFiles
+	}
+	if set["intensity.electricitymaps"] {
+		cfg.Intensity.ElectricityMaps.Enabled = f.electricityMaps
+	}
+
+	return cfg, nil
+}
+
+// overrideExplorers replaces the configured explorers by the -explorer or -cloud.provider
+// flags. Other cloud flags override the explorers of their provider.
+func (f *flags) overrideExplorers(cfg *config.Config, set map[string]bool) error {
+	switch {
+	case set["explorer"]:
+		cfg.Explorers = make([]config.Explorer, 0, len(f.explorers))
+		for _, spec := range f.explorers {
+			explorer, err := parseExplorerSpec(spec)
+			if err != nil {
+				return err
+			}
+			cfg.Explorers = append(cfg.Explorers, explorer)
+		}
+		return nil
+	case set["cloud.provider"]:
+		explorer := config.Explorer{}
+		explorer.SetProvider(f.cloudProvider)
+		cfg.Explorers = []config.Explorer{explorer}
+	}
+
+	for i := range cfg.Explorers {
+		explorer := &cfg.Explorers[i]
+		for _, providerFlag := range providerFlags {
+			if !set[providerFlag.name] || explorer.Provider != providerFlag.provider {
+				continue
+			}
+			if err := explorer.SetProviderField(providerFlag.field, *f.providerValues[providerFlag.name]); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// parseExplorerSpec parses an -explorer flag
+func parseExplorerSpec(spec string) (explorer config.Explorer, err error) {
+	fields := make(map[string]string)
+	for key, value := range parseKeyValues(spec) {
+		switch key {
+		case "name":
+			explorer.Name = value
+		case "cloud.provider":
+			explorer.Provider = value
+		case "collect.interval":
+			if explorer.Collect.Interval, err = time.ParseDuration(value); err != nil {
+				return explorer, fmt.Errorf("explorer %q: invalid collect.interval: %w", spec, err)
+			}
+		case "collect.timeout":
+			if explorer.Collect.Timeout, err = time.ParseDuration(value); err != nil {
+				return explorer, fmt.Errorf("explorer %q: invalid collect.timeout: %w", spec, err)
+			}
+		default:
+			if _, _, found := providerField(key); !found {
+				return explorer, fmt.Errorf("explorer %q: unknown parameter %s", spec, key)
+			}
+			fields[key] = value
+		}
+	}
+
+	// provider fields are set once the provider is known
+	explorer.SetProvider(explorer.Provider)
+	for key, value := range fields {
+		provider, field, _ := providerField(key)
+		if provider != explorer.Provider {
+			return explorer, fmt.Errorf("explorer %q: %s is not supported by %s explorers", spec, key, explorer.Provider)
+		}
+		if err := explorer.SetProviderField(field, value); err != nil {
+			return explorer, fmt.Errorf("explorer %q: %w", spec, err)
+		}
+	}
+
+	return explorer, nil
+}
+
+// validateConfig implements the validate-config subcommand. It reports all errors of the
+// configuration file with their line and returns the process exit code.
+func validateConfig(args []string) int {
+	if len(args) != 1 {
+		fmt.Fprintf(os.Stderr, "Usage of %s validate-config:\n  %s validate-config <config.yaml>\n", os.Args[0], os.Args[0])
+		return 2
+	}
+	path := args[0]
+
+	cfg, err := config.Load(path)
+	if err == nil {
+		err = cfg.Validate()
+	}
+
+	if errs, ok := err.(config.Errors); ok {
+		for _, err := range errs {
+			fmt.Fprintln(os.Stderr, formatConfigError(path, err))
+		}
+		return 1
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %s\n", path, err)
+		return 1
+	}
+
+	fmt.Printf("%s: configuration is valid\n", path)
+	return 0
+}
+
+// formatConfigError formats an error of the configuration file as file:line: message
+func formatConfigError(path string, err *config.Error) string {
+	if path == "" {
+		return err.Error()
+	}
+
+	msg := err.Msg
+	if err.Path != "" {
+		msg = err.Path + ": " + msg
+	}
+	if err.Line > 0 {
+		return fmt.Sprintf("%s:%d: %s", path, err.Line, msg)
+	}
+	return fmt.Sprintf("%s: %s", path, msg)
+}
@@ -0,0 +1,323 @@
+// Package cli implements the cloud carbon exporter command. Custom binaries run Main after
+// importing the packages of their out-of-tree explorers, which register their provider in
+// their init function.
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"strings"
+	"syscall"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+
+	"github.com/superdango/cloud-carbon-exporter/internal/config"
+	"github.com/superdango/cloud-carbon-exporter/internal/electricitymaps"
+	"github.com/superdango/cloud-carbon-exporter/internal/otlp"
+	"github.com/superdango/cloud-carbon-exporter/internal/remotewrite"
+
+	// built-in explorer providers
+	_ "github.com/superdango/cloud-carbon-exporter/internal/aws"
+	_ "github.com/superdango/cloud-carbon-exporter/internal/demo"
+	_ "github.com/superdango/cloud-carbon-exporter/internal/gcp"
+	_ "github.com/superdango/cloud-carbon-exporter/internal/scw"
+
+	"github.com/lmittmann/tint"
+	"github.com/mattn/go-isatty"
+)
+
+// Main runs the exporter command with the os arguments and the registered explorer
+// providers
+func Main() {
+	ctx := context.Background()
+
+	if len(os.Args) > 1 && os.Args[1] == "validate-config" {
+		os.Exit(validateConfig(os.Args[2:]))
+	}
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage of %s:\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  %s [flags]\n  %s validate-config <config.yaml>\n\nFlags:\n", os.Args[0], os.Args[0])
+
+		flag.PrintDefaults()
+
+		fmt.Fprint(os.Stderr, "\nEnvironment Variables:\n")
+		fmt.Fprint(os.Stderr, "  "+envPrefix+"<FLAG>\n")
+		fmt.Fprint(os.Stderr, "        overrides a flag, e.g. "+envName("collect.interval")+" for -collect.interval\n")
+		fmt.Fprint(os.Stderr, "  SCW_ACCESS_KEY\n")
+		fmt.Fprint(os.Stderr, "        scaleway access key\n")
+		fmt.Fprint(os.Stderr, "  SCW_SECRET_KEY\n")
+		fmt.Fprint(os.Stderr, "        scaleway secret key\n")
+		fmt.Fprint(os.Stderr, "  ELECTRICITYMAPS_API_KEY\n")
+		fmt.Fprint(os.Stderr, "        electricity maps api key\n")
+	}
+
+	flags := new(flags)
+	flags.register(flag.CommandLine, config.Default())
+	flag.Parse()
+
+	set, err := setFromEnv(flag.CommandLine)
+	if err != nil {
+		initLogging(flags.logLevel, flags.logFormat)
+		slog.Error("invalid configuration", "err", err.Error())
+		os.Exit(1)
+	}
+
+	cfg, err := flags.loadConfig(set)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if cfg != nil {
+		initLogging(cfg.Log.Level, cfg.Log.Format)
+	} else {
+		initLogging(flags.logLevel, flags.logFormat)
+	}
+
+	if flags.printSupportedServices == "markdown" {
+		printMarkdownSupportedServices(cloudcarbonexporter.ExplorerProviders())
+		os.Exit(0)
+	}
+
+	if err != nil {
+		if errs, ok := err.(config.Errors); ok {
+			for _, err := range errs {
+				slog.Error("invalid configuration", "err", formatConfigError(flags.config, err))
+			}
+		} else {
+			slog.Error("invalid configuration", "err", err.Error())
+		}
+		os.Exit(1)
+	}
+
+	intensityProviders, err := initIntensityProviders(ctx, cfg.Intensity)
+	if err != nil {
+		slog.Error("failed to init carbon intensity sources", "err", err.Error())
+		os.Exit(1)
+	}
+
+	exporter := newExporter(flags, set, intensityProviders...)
+	if err := exporter.apply(ctx, cfg, false); err != nil {
+		slog.Error("failed to apply configuration", "err", err.Error())
+		os.Exit(1)
+	}
+	defer exporter.close()
+	collector := exporter.group
+
+	sinks := make(map[string]cloudcarbonexporter.Sink)
+	intervals := make(map[string]time.Duration)
+
+	if otlpConfig := cfg.Sinks.OTLP; otlpConfig.Endpoint != "" {
+		otlpExporter := otlp.NewExporter().Configure(
+			otlp.WithEndpoint(otlpConfig.Endpoint),
+			otlp.WithProtocol(otlp.Protocol(otlpConfig.Protocol)),
+			otlp.WithHeaders(otlpConfig.Headers),
+			otlp.WithInsecure(otlpConfig.Insecure),
+		)
+		if err := otlpExporter.Init(ctx); err != nil {
+			slog.Error("failed to init otlp exporter", "err", err.Error())
+			os.Exit(1)
+		}
+		defer otlpExporter.Close()
+
+		slog.Info("pushing metrics to otlp receiver", "endpoint", otlpConfig.Endpoint, "protocol", otlpConfig.Protocol)
+		sinks["otlp"] = otlpExporter
+		intervals["otlp"] = otlpConfig.Interval
+	}
+
+	if remoteWriteConfig := cfg.Sinks.RemoteWrite; remoteWriteConfig.URL != "" {
+		slog.Info("pushing metrics to prometheus remote write", "url", remoteWriteConfig.URL)
+		sinks["remotewrite"] = remotewrite.NewWriter().Configure(
+			remotewrite.WithURL(remoteWriteConfig.URL),
+			remotewrite.WithHeaders(remoteWriteConfig.Headers),
+		)
+		intervals["remotewrite"] = remoteWriteConfig.Interval
+	}
+
+	if cfg.Mode == "push" {
+		if err := pushOnce(ctx, collector, sinks); err != nil {
+			slog.Error("failed to push metrics", "err", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
+	reloader := cloudcarbonexporter.NewReloader(exporter.reload)
+	collector.AddMetricsSources(reloader)
+	go reloader.WatchSignals(ctx, syscall.SIGHUP)
+
+	go collector.Run(ctx)
+	for sinkName, sink := range sinks {
+		go cloudcarbonexporter.RunSink(ctx, sinkName, sink, collector, intervals[sinkName])
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "<a href=\"/metrics\">go to /metrics</a>")
+	})
+	mux.Handle("/metrics", cloudcarbonexporter.NewOpenMetricsHandler(collector))
+	mux.Handle("/api/v1/impacts", cloudcarbonexporter.NewImpactsHandler(collector))
+	mux.Handle("/healthz", cloudcarbonexporter.NewHealthHandler())
+	mux.Handle("/readyz", cloudcarbonexporter.NewReadinessHandler(collector))
+	mux.Handle("/-/reload", cloudcarbonexporter.NewReloadHandler(reloader, flags.reloadToken))
+
+	slog.Info("starting cloud carbon exporter", "listen", "http://"+cfg.Listen, "explorers", len(collector.Collectors()))
+	if err := http.ListenAndServe(cfg.Listen, mux); err != nil {
+		slog.Error("failed to start cloud carbon exporter", "err", err)
+		os.Exit(1)
+	}
+}
+
+func initLogging(logLevel string, logFormat string) {
+	switch logFormat {
+	case "text":
+		slog.SetDefault(slog.New(tint.NewHandler(os.Stdout, &tint.Options{
+			Level:   slogLevel(logLevel),
+			NoColor: !isatty.IsTerminal(os.Stdout.Fd()),
+		})))
+	case "json":
+		slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+			Level: slogLevel(logLevel),
+			ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+				switch a.Key {
+				case slog.LevelKey:
+					a.Key = "severity"
+					return a
+				case slog.MessageKey:
+					a.Key = "message"
+					return a
+				default:
+					return a
+				}
+			},
+		})))
+	}
+}
+
+// initIntensityProviders returns the live carbon intensity providers of the configuration.
+// They are shared by all explorers, along with their cache.
+func initIntensityProviders(ctx context.Context, intensity config.Intensity) ([]cloudcarbonexporter.IntensityProvider, error) {
+	providers := make([]cloudcarbonexporter.IntensityProvider, 0)
+
+	if electricityMaps := intensity.ElectricityMaps; electricityMaps.Enabled {
+		provider := electricitymaps.NewIntensityProvider().Configure(
+			electricitymaps.WithURL(electricityMaps.URL),
+			electricitymaps.WithAPIKey(os.Getenv(electricityMaps.APIKeyEnv)),
+			electricitymaps.WithCacheTTL(electricityMaps.CacheTTL),
+			electricitymaps.WithZones(electricityMaps.Zones),
+		)
+		if err := provider.Init(ctx); err != nil {
+			return nil, fmt.Errorf("failed to init electricity maps (api key from %s): %w", electricityMaps.APIKeyEnv, err)
+		}
+		slog.Info("using live carbon intensity from electricity maps", "url", electricityMaps.URL, "cache_ttl", electricityMaps.CacheTTL)
+		providers = append(providers, provider)
+	}
+
+	return providers, nil
+}
+
+// explorerDurations returns the collect interval and timeout of an explorer, defaulting to
+// the global ones
+func explorerDurations(explorerConfig *config.Explorer, collect config.Collect) (time.Duration, time.Duration) {
+	interval, timeout := collect.Interval, collect.Timeout
+	if explorerConfig.Collect.Interval != 0 {
+		interval = explorerConfig.Collect.Interval
+	}
+	if explorerConfig.Collect.Timeout != 0 {
+		timeout = explorerConfig.Collect.Timeout
+	}
+	return interval, timeout
+}
+
+// pushOnce runs a single collection and pushes its metrics to all sinks. It fails if the
+// collection timed out or if any sink failed.
+func pushOnce(ctx context.Context, collector *cloudcarbonexporter.CollectorGroup, sinks map[string]cloudcarbonexporter.Sink) error {
+	if len(sinks) == 0 {
+		return fmt.Errorf("push mode requires at least one sink (-otlp.endpoint, -remotewrite.url)")
+	}
+
+	if snapshot := collector.Collect(ctx); snapshot == nil {
+		return fmt.Errorf("metrics collection did not complete")
+	}
+
+	metrics := collector.Metrics()
+	failed := 0
+	for sinkName, sink := range sinks {
+		if err := sink.Push(ctx, metrics); err != nil {
+			slog.Error("failed to push metrics", "sink", sinkName, "err", err.Error())
+			failed++
+			continue
+		}
+		slog.Info("metrics pushed", "sink", sinkName, "metrics", len(metrics))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d sinks failed", failed, len(sinks))
+	}
+
+	// metrics of the other explorers have been pushed but the run must be reported as failed
+	if failedExplorers := collector.Failed(); len(failedExplorers) > 0 {
+		return fmt.Errorf("metrics collection did not complete for explorers: %s", strings.Join(failedExplorers, ", "))
+	}
+
+	return nil
+}
+
+// stringsFlag is a flag that can be repeated
+type stringsFlag []string
+
+func (f *stringsFlag) String() string { return strings.Join(*f, " ") }
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// parseKeyValues parses a comma separated list of key=value pairs
+func parseKeyValues(s string) map[string]string {
+	m := make(map[string]string)
+	for _, pair := range strings.Split(s, ",") {
+		key, value, found := strings.Cut(pair, "=")
+		if !found {
+			continue
+		}
+		m[strings.TrimSpace(key)] = strings.TrimSpace(value)
+	}
+	return m
+}
+
+func slogLevel(level string) slog.Level {
+	switch strings.ToLower(level) {
+	case "debug":
+		return slog.LevelDebug
+	case "info":
+		return slog.LevelInfo
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+
+	return slog.LevelInfo
+}
+
+func printMarkdownSupportedServices(providers []cloudcarbonexporter.ExplorerProvider) {
+	str := ""
+	for _, provider := range providers {
+		str += "## " + strings.ToUpper(provider.Name) + "\n\n"
+		for _, service := range provider.SupportedServices {
+			str += "* `" + service + "`\n"
+		}
+		if len(provider.SupportedServices) == 0 {
+			str += "* `none` \n"
+		}
+		str += "\n"
+	}
+	str += fmt.Sprintf("_automatically generated on %s_", time.Now().UTC().Format(time.RFC850))
+
+	fmt.Println(str)
+}
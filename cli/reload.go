@@ -0,0 +1,391 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"sync"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/config"
+	"github.com/superdango/cloud-carbon-exporter/internal/factors"
+)
+
+// runningExplorer is an explorer collected by the exporter along with the configuration it
+// was initialized with
+type runningExplorer struct {
+	config config.Explorer
+	// factors are the emission factors of the explorer provider
+	factors   *factors.ProviderFactors
+	explorer  cloudcarbonexporter.Explorer
+	collector *cloudcarbonexporter.Collector
+	// cancel stops the background tasks of the explorer, like its cache expiration
+	cancel context.CancelFunc
+}
+
+// close stops the explorer
+func (running *runningExplorer) close() {
+	running.cancel()
+	if err := running.explorer.Close(); err != nil {
+		slog.Warn("failed to close explorer", "err", err.Error())
+	}
+}
+
+// closeAfter stops the explorer in the background once wait returns, so that the collection
+// in progress with the explorer completes with its clients
+func (running *runningExplorer) closeAfter(wait func()) {
+	go func() {
+		wait()
+		running.close()
+	}()
+}
+
+// keeps returns true if the explorer can be kept for the configuration and emission factors
+// of its provider, along with its cache. Collect settings are applied by its collector, and
+// emission factors by the explorer itself if it is a FactorsUpdater. Other changes, like
+// credentials or scope, require a new explorer.
+func (running *runningExplorer) keeps(explorerConfig config.Explorer, providerFactors *factors.ProviderFactors) bool {
+	current := running.config
+	current.Collect, explorerConfig.Collect = config.Collect{}, config.Collect{}
+	if _, ok := running.explorer.(cloudcarbonexporter.FactorsUpdater); !ok {
+		return reflect.DeepEqual(current, explorerConfig) && reflect.DeepEqual(running.factors, providerFactors)
+	}
+	current.PUE, current.Intensity, current.CarbonFreeEnergy = 0, nil, nil
+	explorerConfig.PUE, explorerConfig.Intensity, explorerConfig.CarbonFreeEnergy = 0, nil, nil
+	return reflect.DeepEqual(current, explorerConfig)
+}
+
+// update sets the configuration and emission factors of the kept explorer, which applies
+// them if they changed
+func (running *runningExplorer) update(explorerConfig config.Explorer, settings *cloudcarbonexporter.ExplorerSettings, providerFactors *factors.ProviderFactors) {
+	updater, ok := running.explorer.(cloudcarbonexporter.FactorsUpdater)
+	factorsChanged := running.config.PUE != explorerConfig.PUE ||
+		!reflect.DeepEqual(running.config.Intensity, explorerConfig.Intensity) ||
+		!reflect.DeepEqual(running.config.CarbonFreeEnergy, explorerConfig.CarbonFreeEnergy) ||
+		!reflect.DeepEqual(running.factors, providerFactors)
+	if ok && factorsChanged {
+		updater.UpdateFactors(settings)
+		slog.Info("explorer emission factors updated", "explorer", explorerConfig.DisplayName())
+	}
+	running.config, running.factors = explorerConfig, providerFactors
+}
+
+const (
+	// initRetryMinBackoff is the delay before the first initialization retry of an explorer
+	initRetryMinBackoff = 10 * time.Second
+	// initRetryMaxBackoff caps the delay between two initialization retries of an explorer
+	initRetryMaxBackoff = 5 * time.Minute
+)
+
+// pendingExplorer is an explorer that failed to initialize at startup. Its initialization is
+// retried in the background until it succeeds or a reload removes or changes it.
+type pendingExplorer struct {
+	config  config.Explorer
+	factors factors.Factors
+	// err is the error of the first initialization attempt
+	err error
+	// cancel stops the retries
+	cancel context.CancelFunc
+}
+
+// exporter applies configurations to the collector group. Explorers whose configuration
+// did not change are kept across reloads along with their cache.
+type exporter struct {
+	flags       *flags
+	set         map[string]bool
+	cfg         *config.Config
+	labelPolicy *cloudcarbonexporter.LabelPolicy
+	group       *cloudcarbonexporter.CollectorGroup
+	explorers   map[string]*runningExplorer
+	pending     map[string]*pendingExplorer
+	// intensityProviders are the live carbon intensity providers given to the explorers
+	intensityProviders []cloudcarbonexporter.IntensityProvider
+	mu                 *sync.Mutex
+}
+
+// newExporter returns an exporter without explorers
+func newExporter(flags *flags, set map[string]bool, intensityProviders ...cloudcarbonexporter.IntensityProvider) *exporter {
+	return &exporter{
+		flags:              flags,
+		set:                set,
+		group:              cloudcarbonexporter.NewCollectorGroup(),
+		explorers:          make(map[string]*runningExplorer),
+		pending:            make(map[string]*pendingExplorer),
+		intensityProviders: intensityProviders,
+		mu:                 new(sync.Mutex),
+	}
+}
+
+// reload loads and validates the configuration again, then applies it. The running
+// configuration is kept if any of these steps fails.
+func (exporter *exporter) reload(ctx context.Context) error {
+	cfg, err := exporter.flags.loadConfig(exporter.set)
+	if err == nil {
+		err = cfg.Validate()
+	}
+	if err != nil {
+		return fmt.Errorf("invalid configuration: %w", err)
+	}
+
+	if cfg.Listen != exporter.cfg.Listen {
+		slog.Warn("listen address change requires a restart", "listen", exporter.cfg.Listen)
+	}
+	if !reflect.DeepEqual(cfg.Sinks, exporter.cfg.Sinks) {
+		slog.Warn("sinks changes require a restart")
+	}
+	if !reflect.DeepEqual(cfg.Intensity, exporter.cfg.Intensity) {
+		slog.Warn("carbon intensity sources changes require a restart")
+	}
+
+	if err := exporter.apply(ctx, cfg, true); err != nil {
+		return err
+	}
+	initLogging(cfg.Log.Level, cfg.Log.Format)
+	return nil
+}
+
+// apply initializes the new and changed explorers of the configuration, then swaps them
+// with the running ones. Collectors of kept explorers only get the new collect, labels and
+// aggregations settings, and the explorers the new emission factors. Replaced explorers
+// are closed once their collection in progress completes. If strict, the configuration is not applied if an
+// explorer fails to initialize, otherwise this explorer is reported as not ready and its
+// initialization is retried in the background.
+func (exporter *exporter) apply(ctx context.Context, cfg *config.Config, strict bool) error {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	labelPolicy, err := cfg.Labels.Policy()
+	if err != nil {
+		return fmt.Errorf("invalid labels configuration: %w", err)
+	}
+	explorerFactors, err := factors.Load(cfg.Factors.Files...)
+	if err != nil {
+		return fmt.Errorf("invalid emission factors: %w", err)
+	}
+
+	initialized := make(map[string]*runningExplorer)
+	pending := make(map[string]*pendingExplorer)
+	abort := func(err error) error {
+		for _, running := range initialized {
+			running.close()
+		}
+		return err
+	}
+
+	for _, explorerConfig := range cfg.Explorers {
+		name := explorerConfig.DisplayName()
+		providerFactors := explorerFactors[explorerConfig.Provider]
+		if running, found := exporter.explorers[name]; found && running.keeps(explorerConfig, providerFactors) {
+			continue
+		}
+		if retried, found := exporter.pending[name]; found && reflect.DeepEqual(retried.config, explorerConfig) && reflect.DeepEqual(retried.factors[explorerConfig.Provider], providerFactors) {
+			pending[name] = retried
+			continue
+		}
+
+		// explorers outlive the reload request that initialized them
+		explorerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		explorer, err := exporter.initExplorer(explorerCtx, &explorerConfig, explorerFactors)
+		if err != nil {
+			cancel()
+			if strict {
+				return abort(fmt.Errorf("failed to init explorer %s: %w", name, err))
+			}
+			slog.Error("failed to init explorer, retrying in the background", "explorer", name, "err", err.Error())
+			pending[name] = &pendingExplorer{config: explorerConfig, factors: explorerFactors, err: err}
+			continue
+		}
+		initialized[name] = &runningExplorer{config: explorerConfig, factors: providerFactors, explorer: explorer, cancel: cancel}
+	}
+
+	collectors := make([]*cloudcarbonexporter.Collector, 0, len(cfg.Explorers))
+	explorers := make(map[string]*runningExplorer, len(cfg.Explorers))
+	// replaced holds the functions waiting for the collections in progress with the
+	// replaced explorers
+	replaced := make(map[string]func())
+	for _, explorerConfig := range cfg.Explorers {
+		name := explorerConfig.DisplayName()
+		opts := collectorOptions(cfg, labelPolicy, &explorerConfig)
+
+		running, found := exporter.explorers[name]
+		next, changed := initialized[name]
+		switch {
+		case changed && found:
+			next.collector = running.collector
+		case changed:
+			next.collector = cloudcarbonexporter.NewCollector(name, next.explorer, opts...)
+		case found:
+			next = running
+			next.update(explorerConfig, exporter.settings(&explorerConfig, explorerFactors), explorerFactors[explorerConfig.Provider])
+		default:
+			// explorer failed to initialize and is retried in the background
+			continue
+		}
+
+		explorers[name] = next
+		collectors = append(collectors, next.collector)
+		if _, wait := next.collector.Reconfigure(next.explorer, opts...); changed && found {
+			replaced[name] = wait
+		}
+	}
+
+	if len(collectors) == 0 && len(pending) == 0 {
+		return abort(fmt.Errorf("no explorer could be initialized"))
+	}
+
+	for name, retried := range exporter.pending {
+		if pending[name] != retried {
+			retried.cancel()
+			exporter.group.SetPending(name, nil)
+		}
+	}
+	for name, next := range pending {
+		if exporter.pending[name] != next {
+			// retries outlive the request that applied the configuration
+			retryCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+			next.cancel = cancel
+			exporter.group.SetPending(name, next.err)
+			go exporter.retryInit(retryCtx, name, next)
+		}
+	}
+	exporter.group.Replace(collectors...)
+	for name, running := range exporter.explorers {
+		if wait, found := replaced[name]; found {
+			running.closeAfter(wait)
+		} else if _, found := explorers[name]; !found {
+			running.closeAfter(running.collector.Wait)
+		}
+	}
+	exporter.explorers = explorers
+	exporter.pending = pending
+	exporter.cfg = cfg
+	exporter.labelPolicy = labelPolicy
+
+	slog.Info("configuration applied", "explorers", len(explorers), "initialized", len(initialized), "pending", len(pending))
+	return nil
+}
+
+// retryInit initializes the pending explorer again, doubling the delay between attempts,
+// until it succeeds or the context is done. The initialized explorer is then collected
+// along with the others.
+func (exporter *exporter) retryInit(ctx context.Context, name string, pending *pendingExplorer) {
+	backoff := initRetryMinBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		explorerCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		explorer, err := exporter.initExplorer(explorerCtx, &pending.config, pending.factors)
+		if err == nil {
+			running := &runningExplorer{config: pending.config, factors: pending.factors[pending.config.Provider], explorer: explorer, cancel: cancel}
+			if !exporter.addInitialized(name, pending, running) {
+				// a reload removed or changed the explorer in the meantime
+				running.close()
+			}
+			return
+		}
+		cancel()
+
+		backoff = min(2*backoff, initRetryMaxBackoff)
+		exporter.group.SetPending(name, err)
+		slog.Warn("failed to init explorer", "explorer", name, "retry_in", backoff, "err", err.Error())
+	}
+}
+
+// addInitialized replaces the pending explorer with the running one and starts collecting
+// it. It returns false if the explorer is not pending anymore.
+func (exporter *exporter) addInitialized(name string, pending *pendingExplorer, running *runningExplorer) bool {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	if exporter.pending[name] != pending {
+		return false
+	}
+	delete(exporter.pending, name)
+	running.collector = cloudcarbonexporter.NewCollector(name, running.explorer, collectorOptions(exporter.cfg, exporter.labelPolicy, &running.config)...)
+	exporter.explorers[name] = running
+
+	collectors := make([]*cloudcarbonexporter.Collector, 0, len(exporter.explorers))
+	for _, explorerConfig := range exporter.cfg.Explorers {
+		if running, found := exporter.explorers[explorerConfig.DisplayName()]; found {
+			collectors = append(collectors, running.collector)
+		}
+	}
+	exporter.group.SetPending(name, nil)
+	exporter.group.Replace(collectors...)
+
+	slog.Info("explorer initialized", "explorer", name)
+	return true
+}
+
+// collectorOptions returns the options of the explorer collector
+func collectorOptions(cfg *config.Config, labelPolicy *cloudcarbonexporter.LabelPolicy, explorerConfig *config.Explorer) []cloudcarbonexporter.CollectorOption {
+	interval, timeout := explorerDurations(explorerConfig, cfg.Collect)
+	return []cloudcarbonexporter.CollectorOption{
+		cloudcarbonexporter.WithCollectInterval(interval),
+		cloudcarbonexporter.WithCollectTimeout(timeout),
+		cloudcarbonexporter.WithAggregationRules(cfg.AggregationRules()...),
+		cloudcarbonexporter.WithLabelPolicy(labelPolicy),
+	}
+}
+
+// initExplorer creates the explorer of the configured provider and initializes it with the
+// emission factors and live carbon intensity providers. Its cloud api exchanges are
+// recorded or replayed if the flags say so.
+func (exporter *exporter) initExplorer(ctx context.Context, explorerConfig *config.Explorer, explorerFactors factors.Factors) (cloudcarbonexporter.Explorer, error) {
+	provider, found := cloudcarbonexporter.LookupExplorerProvider(explorerConfig.Provider)
+	if explorerConfig.Provider == "" {
+		return nil, fmt.Errorf("cloud provider is not set")
+	}
+	if !found {
+		return nil, fmt.Errorf("cloud provider %s is not supported", explorerConfig.Provider)
+	}
+
+	providerConfig := explorerConfig.Config
+	if providerConfig == nil {
+		providerConfig = provider.NewConfig()
+	}
+	settings := exporter.settings(explorerConfig, explorerFactors)
+	if err := exporter.flags.setFixtures(settings, explorerConfig.DisplayName()); err != nil {
+		return nil, err
+	}
+	explorer, err := provider.New(ctx, settings, providerConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := explorer.Init(ctx); err != nil {
+		if err := explorer.Close(); err != nil {
+			slog.Warn("failed to close explorer", "err", err.Error())
+		}
+		return nil, err
+	}
+	return explorer, nil
+}
+
+// settings returns the settings of the explorer with the emission factors of its provider
+// and the live carbon intensity providers
+func (exporter *exporter) settings(explorerConfig *config.Explorer, explorerFactors factors.Factors) *cloudcarbonexporter.ExplorerSettings {
+	settings := explorerConfig.Settings()
+	explorerFactors.Apply(explorerConfig.Provider, settings)
+	settings.IntensityProviders = exporter.intensityProviders
+	return settings
+}
+
+// close stops all explorers and their initialization retries
+func (exporter *exporter) close() {
+	exporter.mu.Lock()
+	defer exporter.mu.Unlock()
+
+	for _, running := range exporter.explorers {
+		running.close()
+	}
+	for _, retried := range exporter.pending {
+		retried.cancel()
+	}
+}
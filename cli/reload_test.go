@@ -0,0 +1,136 @@
+package cli
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/cache"
+	"github.com/superdango/cloud-carbon-exporter/internal/config"
+)
+
+type cachedProviderConfig struct{}
+
+func (config *cachedProviderConfig) Validate(settings *cloudcarbonexporter.ExplorerSettings) []*cloudcarbonexporter.FieldError {
+	return nil
+}
+
+// cachedExplorer is an explorer whose cache runs in the background, like the cloud ones
+type cachedExplorer struct {
+	cache *cache.Memory
+	// pue is the pue of the last emission factors update
+	pue float64
+	// collecting is closed when a collection starts, which then waits for release
+	collecting chan struct{}
+	release    chan struct{}
+}
+
+func (explorer *cachedExplorer) Init(ctx context.Context) error {
+	explorer.cache = cache.NewMemory(ctx, time.Minute)
+	return nil
+}
+
+func (explorer *cachedExplorer) CollectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact, errs chan error) {
+	if explorer.release != nil {
+		close(explorer.collecting)
+		<-explorer.release
+	}
+}
+
+func (explorer *cachedExplorer) UpdateFactors(settings *cloudcarbonexporter.ExplorerSettings) {
+	explorer.pue = settings.PUE
+}
+
+func (explorer *cachedExplorer) IsReady() bool { return true }
+
+func (explorer *cachedExplorer) SupportedServices() []string { return nil }
+
+func (explorer *cachedExplorer) Close() error { return nil }
+
+func init() {
+	cloudcarbonexporter.RegisterExplorerProvider(cloudcarbonexporter.ExplorerProvider{
+		Name:      "cached",
+		NewConfig: func() cloudcarbonexporter.ProviderConfig { return new(cachedProviderConfig) },
+		New: func(ctx context.Context, settings *cloudcarbonexporter.ExplorerSettings, config cloudcarbonexporter.ProviderConfig) (cloudcarbonexporter.Explorer, error) {
+			return new(cachedExplorer), nil
+		},
+	})
+}
+
+func TestExporterApplyStopsReplacedExplorers(t *testing.T) {
+	exporter := newExporter(new(flags), nil)
+	defer exporter.close()
+
+	cfg := config.Default()
+	cfg.Explorers = []config.Explorer{{Provider: "cached"}}
+	assert.NoError(t, exporter.apply(t.Context(), cfg, true))
+	previous := exporter.explorers["cached"].explorer.(*cachedExplorer)
+
+	// an unchanged explorer keeps its cache
+	assert.NoError(t, exporter.apply(t.Context(), cfg, true))
+	assert.Same(t, previous, exporter.explorers["cached"].explorer)
+
+	changed := config.Default()
+	changed.Explorers = []config.Explorer{{Provider: "cached", Regions: []string{"eu-west-3"}}}
+	assert.NoError(t, exporter.apply(t.Context(), changed, true))
+	assert.NotSame(t, previous, exporter.explorers["cached"].explorer)
+
+	select {
+	case <-previous.cache.Done():
+	case <-time.After(time.Second):
+		t.Fatal("cache of the replaced explorer is still running")
+	}
+	select {
+	case <-exporter.explorers["cached"].explorer.(*cachedExplorer).cache.Done():
+		t.Fatal("cache of the running explorer has been stopped")
+	default:
+	}
+}
+
+func TestExporterApplyUpdatesFactors(t *testing.T) {
+	exporter := newExporter(new(flags), nil)
+	defer exporter.close()
+
+	cfg := config.Default()
+	cfg.Explorers = []config.Explorer{{Provider: "cached"}}
+	assert.NoError(t, exporter.apply(t.Context(), cfg, true))
+	previous := exporter.explorers["cached"].explorer.(*cachedExplorer)
+
+	changed := config.Default()
+	changed.Explorers = []config.Explorer{{Provider: "cached", PUE: 1.3, Collect: config.Collect{Interval: time.Hour}}}
+	assert.NoError(t, exporter.apply(t.Context(), changed, true))
+	assert.Same(t, previous, exporter.explorers["cached"].explorer, "explorer keeps its cache")
+	assert.Equal(t, 1.3, previous.pue)
+	assert.Equal(t, changed.Explorers[0], exporter.explorers["cached"].config)
+}
+
+func TestExporterApplyClosesAfterCollection(t *testing.T) {
+	exporter := newExporter(new(flags), nil)
+	defer exporter.close()
+
+	cfg := config.Default()
+	cfg.Explorers = []config.Explorer{{Provider: "cached"}}
+	assert.NoError(t, exporter.apply(t.Context(), cfg, true))
+	previous := exporter.explorers["cached"].explorer.(*cachedExplorer)
+	previous.collecting, previous.release = make(chan struct{}), make(chan struct{})
+	go exporter.explorers["cached"].collector.Collect(t.Context())
+	<-previous.collecting
+
+	changed := config.Default()
+	changed.Explorers = []config.Explorer{{Provider: "cached", Regions: []string{"eu-west-3"}}}
+	assert.NoError(t, exporter.apply(t.Context(), changed, true))
+	select {
+	case <-previous.cache.Done():
+		t.Fatal("replaced explorer has been closed during its collection")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	close(previous.release)
+	select {
+	case <-previous.cache.Done():
+	case <-time.After(time.Second):
+		t.Fatal("replaced explorer has not been closed after its collection")
+	}
+}
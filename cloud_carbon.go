@@ -14,6 +14,20 @@ type Explorer interface {
 	io.Closer
 }
 
+// MetricsReporter is implemented by explorers exposing metrics besides the impacts, like a
+// business KPI relating emissions to the service usage. Metrics are read once the impacts
+// of a collection are gathered and are kept in its snapshot.
+type MetricsReporter interface {
+	ReportMetrics() []*Metric
+}
+
+// FactorsUpdater is implemented by explorers applying new emission factors without being
+// initialized again, so they keep their clients and cache. Only the PUE, carbon intensity and
+// carbon-free energy settings are read.
+type FactorsUpdater interface {
+	UpdateFactors(settings *ExplorerSettings)
+}
+
 type ExplorerErr struct {
 	Err       error
 	Operation string
@@ -0,0 +1,7 @@
+package main
+
+import "github.com/superdango/cloud-carbon-exporter/cli"
+
+func main() {
+	cli.Main()
+}
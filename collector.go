@@ -0,0 +1,384 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func init() {
+	RegisterMetricFamilies(
+		MetricFamily{
+			Name: "collect_success",
+			Help: "Whether the last resources collection completed before its timeout (1) or not (0).",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "snapshot_age_seconds",
+			Help: "Age of the impacts snapshot served by the exporter.",
+			Type: GaugeType,
+			Unit: "seconds",
+		},
+	)
+}
+
+// Snapshot holds the impacts gathered during a collection.
+type Snapshot struct {
+	Impacts []*Impact
+	// Aggregated holds the impacts exposed as metrics, after aggregation rules are applied
+	Aggregated []*Impact
+	// Cumulative holds impacts integrated over time since each resource discovery
+	Cumulative []*CumulativeImpact
+	// Metrics are reported by the explorer besides the impacts
+	Metrics     []*Metric
+	CollectedAt time.Time
+	Duration    time.Duration
+	Errors      int
+	APICalls    int
+}
+
+// CollectionStatus reports the outcome of a collection, even if its snapshot was discarded.
+type CollectionStatus struct {
+	Success  bool
+	Duration time.Duration
+	Errors   int
+	APICalls int
+	// OperationCalls counts the api calls by operation
+	OperationCalls map[string]int
+	// OperationErrors counts the errors by failing operation
+	OperationErrors map[string]int
+	SubExplorers    []SubExplorerStats
+}
+
+// MetricsSource provides the metrics exported by sinks
+type MetricsSource interface {
+	Metrics() []*Metric
+}
+
+// Collector periodically collects explorer impacts in the background and keeps the
+// last snapshot in memory so it can be served instantly.
+type Collector struct {
+	collectorSettings
+	explorerName string
+	snapshot     *atomic.Pointer[Snapshot]
+	status       *atomic.Pointer[CollectionStatus]
+	integrator   *impactIntegrator
+	mu           *sync.RWMutex
+}
+
+// collectorSettings are the collector settings that can be replaced while it runs
+type collectorSettings struct {
+	explorer     Explorer
+	interval     time.Duration
+	timeout      time.Duration
+	aggregations []AggregationRule
+	labelPolicy  *LabelPolicy
+	// collections is read locked by the collections in progress with these settings
+	collections *sync.RWMutex
+}
+
+type CollectorOption func(*Collector)
+
+// WithCollectInterval sets the interval between two collections.
+func WithCollectInterval(interval time.Duration) CollectorOption {
+	return func(c *Collector) {
+		c.interval = interval
+	}
+}
+
+// WithCollectTimeout sets the maximum duration of a collection.
+func WithCollectTimeout(timeout time.Duration) CollectorOption {
+	return func(c *Collector) {
+		c.timeout = timeout
+	}
+}
+
+// WithAggregationRules sets the rules used to aggregate impacts before they are exposed.
+func WithAggregationRules(rules ...AggregationRule) CollectorOption {
+	return func(c *Collector) {
+		c.aggregations = rules
+	}
+}
+
+// WithLabelPolicy sets the policy turning impacts tags into labels.
+func WithLabelPolicy(policy *LabelPolicy) CollectorOption {
+	return func(c *Collector) {
+		c.labelPolicy = policy
+	}
+}
+
+// NewCollector returns a new Collector of the explorer impacts
+func NewCollector(explorerName string, explorer Explorer, opts ...CollectorOption) *Collector {
+	collector := &Collector{
+		collectorSettings: collectorSettings{
+			explorer:    explorer,
+			interval:    time.Minute,
+			timeout:     3 * time.Minute,
+			labelPolicy: DefaultLabelPolicy(),
+			collections: new(sync.RWMutex),
+		},
+		explorerName: explorerName,
+		snapshot:     new(atomic.Pointer[Snapshot]),
+		status:       new(atomic.Pointer[CollectionStatus]),
+		integrator:   newImpactIntegrator(),
+		mu:           new(sync.RWMutex),
+	}
+
+	for _, opt := range opts {
+		if opt != nil {
+			opt(collector)
+		}
+	}
+
+	return collector
+}
+
+// Reconfigure replaces the explorer and the settings of the collector. Settings not set by
+// the options are reset to their default. The snapshot and the cumulative impacts are kept
+// and a collection in progress completes with the previous settings. It returns the previous
+// explorer and a function waiting for the collections in progress with it, so the caller can
+// close it once they complete if it is not used anymore.
+func (collector *Collector) Reconfigure(explorer Explorer, opts ...CollectorOption) (previous Explorer, wait func()) {
+	next := NewCollector(collector.explorerName, explorer, opts...)
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+
+	previous, wait = collector.explorer, waitCollections(collector.collections)
+	collector.collectorSettings = next.collectorSettings
+	return previous, wait
+}
+
+// Wait blocks until the collections in progress with the current settings complete
+func (collector *Collector) Wait() {
+	waitCollections(collector.settings().collections)()
+}
+
+// waitCollections returns a function waiting for the collections holding the lock
+func waitCollections(collections *sync.RWMutex) func() {
+	return func() {
+		collections.Lock()
+		defer collections.Unlock()
+	}
+}
+
+// Explorer returns the explorer of the collector
+func (collector *Collector) Explorer() Explorer {
+	return collector.settings().explorer
+}
+
+// settings returns a copy of the current collector settings
+func (collector *Collector) settings() collectorSettings {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+	return collector.collectorSettings
+}
+
+// acquireSettings returns a copy of the current collector settings, used by a collection in
+// progress until release is called
+func (collector *Collector) acquireSettings() (settings collectorSettings, release func()) {
+	collector.mu.RLock()
+	defer collector.mu.RUnlock()
+	collector.collections.RLock()
+	return collector.collectorSettings, collector.collections.RUnlock
+}
+
+// Run collects impacts immediately and then at each interval until the context is done. The
+// interval is read again after each collection so a reconfiguration is applied on the next tick.
+func (collector *Collector) Run(ctx context.Context) {
+	interval := collector.settings().interval
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		collector.Collect(ctx)
+		if current := collector.settings().interval; current != interval {
+			interval = current
+			ticker.Reset(interval)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Collect runs a single collection and swaps the current snapshot with its result. If the
+// collection times out, its partial result is discarded and the previous snapshot is kept.
+func (collector *Collector) Collect(ctx context.Context) *Snapshot {
+	settings, release := collector.acquireSettings()
+	defer release()
+	start := time.Now()
+	impacts := make(chan *Impact)
+	errs := make(chan error)
+	errCount := new(atomic.Int64)
+
+	baseLabels := map[string]string{
+		"explorer": collector.explorerName,
+	}
+
+	collectCtx, cancel := context.WithTimeout(ctx, settings.timeout)
+	defer cancel()
+	cctx := WrapCtx(collectCtx)
+
+	snapshot := &Snapshot{
+		Impacts: make([]*Impact, 0),
+	}
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+		for impact := range impacts {
+			impact.Labels = MergeLabels(settings.labelPolicy.Labels(impact), baseLabels)
+			snapshot.Impacts = append(snapshot.Impacts, impact)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+		for err := range errs {
+			if err == nil {
+				continue
+			}
+
+			errCount.Add(1)
+			cctx.Stats().IncrErrors(err)
+
+			experr := new(ExplorerErr)
+			if errors.As(err, &experr) {
+				slog.Warn("metrics collection failed", "err", experr, "op", experr.Operation)
+				continue
+			}
+			slog.Warn("metrics collection failed", "err", err.Error())
+		}
+	}()
+
+	settings.explorer.CollectImpacts(cctx, impacts, errs)
+	close(impacts)
+	close(errs)
+	wg.Wait()
+
+	for _, metric := range intensityMetrics(snapshot.Impacts) {
+		metric.Labels = MergeLabels(metric.Labels, baseLabels)
+		snapshot.Metrics = append(snapshot.Metrics, metric)
+	}
+	if reporter, ok := settings.explorer.(MetricsReporter); ok {
+		for _, metric := range reporter.ReportMetrics() {
+			metric.Labels = MergeLabels(metric.Labels, baseLabels)
+			snapshot.Metrics = append(snapshot.Metrics, metric)
+		}
+	}
+
+	snapshot.CollectedAt = time.Now()
+	snapshot.Duration = time.Since(start)
+	snapshot.Errors = int(errCount.Load())
+	snapshot.APICalls = cctx.Calls()
+
+	status := &CollectionStatus{
+		Success:  !errors.Is(collectCtx.Err(), context.DeadlineExceeded),
+		Duration: snapshot.Duration,
+		Errors:   snapshot.Errors,
+		APICalls: snapshot.APICalls,
+
+		OperationCalls:  cctx.Stats().Calls(),
+		OperationErrors: cctx.Stats().Errors(),
+		SubExplorers:    cctx.Stats().SubExplorers(),
+	}
+	collector.status.Store(status)
+
+	if !status.Success {
+		slog.Error("metrics collection timed out, keeping previous snapshot", "explorer", collector.explorerName, "timeout", settings.timeout)
+		return collector.Snapshot()
+	}
+
+	snapshot.Aggregated = AggregateImpacts(settings.aggregations, snapshot.Impacts)
+	snapshot.Cumulative = collector.integrator.integrate(snapshot.Aggregated, snapshot.CollectedAt)
+	collector.snapshot.Store(snapshot)
+	slog.Info("metrics have been successfully collected", "explorer", collector.explorerName, "impacts", len(snapshot.Impacts), "duration_ms", snapshot.Duration.Milliseconds())
+
+	return snapshot
+}
+
+// Snapshot returns the last collected snapshot or nil if no collection completed yet.
+func (collector *Collector) Snapshot() *Snapshot {
+	return collector.snapshot.Load()
+}
+
+// Metrics returns the metrics of the last snapshot and the collector self metrics. If no
+// collection completed yet, only the self metrics of the failed collections are returned.
+func (collector *Collector) Metrics() []*Metric {
+	snapshot := collector.Snapshot()
+	if snapshot == nil {
+		if collector.status.Load() == nil {
+			return nil
+		}
+		return collector.selfMetrics(nil)
+	}
+
+	metrics := make([]*Metric, 0, len(snapshot.Aggregated)*6)
+	for _, impact := range snapshot.Aggregated {
+		metrics = append(metrics, ImpactMetrics(impact)...)
+	}
+	for _, cumulative := range snapshot.Cumulative {
+		metrics = append(metrics, cumulative.Metrics()...)
+	}
+	metrics = append(metrics, snapshot.Metrics...)
+
+	return append(metrics, collector.selfMetrics(snapshot)...)
+}
+
+func (collector *Collector) selfMetrics(snapshot *Snapshot) []*Metric {
+	baseLabels := map[string]string{
+		"explorer": collector.explorerName,
+	}
+
+	status := collector.status.Load()
+	success := 0.0
+	if status.Success {
+		success = 1.0
+	}
+
+	metrics := []*Metric{
+		{Name: "collect_duration_ms", Labels: baseLabels, Value: float64(status.Duration.Milliseconds())},
+		{Name: "collect_success", Labels: baseLabels, Value: success},
+		{Name: "error_count", Labels: baseLabels, Value: float64(status.Errors)},
+		{Name: "api_calls", Labels: baseLabels, Value: float64(status.APICalls)},
+	}
+	if snapshot != nil {
+		metrics = append(metrics, &Metric{Name: "snapshot_age_seconds", Labels: baseLabels, Value: time.Since(snapshot.CollectedAt).Seconds()})
+	}
+	metrics = append(metrics, statsMetrics(baseLabels, status)...)
+
+	if reporter, ok := collector.Explorer().(CacheStatsReporter); ok {
+		metrics = append(metrics, cacheMetrics(baseLabels, reporter.CacheStats())...)
+	}
+
+	return metrics
+}
+
+// ImpactMetrics returns the metrics exposed for an impact. Usage emissions are labelled
+// with their accounting method: location-based, and market-based if the impact has some.
+// Water consumption is labelled with its scope: onsite for the datacenter cooling and
+// offsite for the electricity generation.
+func ImpactMetrics(impact *Impact) []*Metric {
+	metrics := []*Metric{
+		NewEnergyMetric(impact.Energy).SetLabels(impact.Labels),
+		NewOverheadEnergyMetric(impact.OverheadEnergy).SetLabels(impact.Labels),
+		NewEmissionsMetric(impact.EnergyEmissions).SetLabels(MergeLabels(impact.Labels, map[string]string{"method": "location"})),
+		NewEmbodiedEmissionsMetric(impact.EmbodiedEmissions).SetLabels(impact.Labels),
+		NewWaterMetric(impact.OnSiteWater).SetLabels(MergeLabels(impact.Labels, map[string]string{"scope": "onsite"})),
+		NewWaterMetric(impact.OffSiteWater).SetLabels(MergeLabels(impact.Labels, map[string]string{"scope": "offsite"})),
+	}
+	if impact.MarketEmissions.During != 0 {
+		metrics = append(metrics, NewEmissionsMetric(impact.MarketEmissions).SetLabels(MergeLabels(impact.Labels, map[string]string{"method": "market"})))
+	}
+
+	return append(metrics, ComponentMetrics(impact)...)
+}
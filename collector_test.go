@@ -0,0 +1,189 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeExplorer struct {
+	impacts []*Impact
+	errs    []error
+	block   bool
+}
+
+func (explorer *fakeExplorer) CollectImpacts(ctx Context, impacts chan *Impact, errs chan error) {
+	ctx.IncrCalls("fake:List")
+	for _, impact := range explorer.impacts {
+		copied := *impact
+		impacts <- &copied
+	}
+	for _, err := range explorer.errs {
+		errs <- err
+	}
+	if explorer.block {
+		<-ctx.Done()
+	}
+}
+
+func (explorer *fakeExplorer) Init(ctx context.Context) error { return nil }
+func (explorer *fakeExplorer) IsReady() bool                  { return true }
+func (explorer *fakeExplorer) SupportedServices() []string    { return []string{"fake/resource"} }
+func (explorer *fakeExplorer) Close() error                   { return nil }
+
+func newFakeExplorer() *fakeExplorer {
+	return &fakeExplorer{
+		impacts: []*Impact{
+			{Labels: map[string]string{"kind": "fake/resource", "location": "eu-west-3"}, Energy: 10, EnergyEmissions: ZeroEmissions, EmbodiedEmissions: ZeroEmissions},
+			{Labels: map[string]string{"kind": "fake/resource", "location": "us-east-1"}, Energy: 20, EnergyEmissions: ZeroEmissions, EmbodiedEmissions: ZeroEmissions},
+		},
+		errs: []error{nil, &ExplorerErr{Err: fmt.Errorf("denied"), Operation: "fake:List"}},
+	}
+}
+
+func TestCollectorCollect(t *testing.T) {
+	explorer := newFakeExplorer()
+	collector := NewCollector("fake", explorer)
+	assert.Nil(t, collector.Snapshot())
+	assert.Nil(t, collector.Metrics())
+
+	snapshot := collector.Collect(t.Context())
+	assert.Len(t, snapshot.Impacts, 2)
+	assert.Equal(t, 1, snapshot.Errors)
+	assert.Equal(t, 1, snapshot.APICalls)
+	for _, impact := range snapshot.Impacts {
+		assert.Equal(t, "fake", impact.Labels["explorer"])
+	}
+	assert.Same(t, snapshot, collector.Snapshot())
+
+	metrics := collector.Metrics()
+	// impacts, counters, self metrics then the api calls and errors of the fake:List operation
+	assert.Len(t, metrics, 2*6+2*3+5+2)
+	assert.Equal(t, map[string]int{"fake:List": 1}, collector.status.Load().OperationErrors)
+
+	// a timed out collection keeps the previous snapshot
+	explorer.block = true
+	collector.timeout = 10 * time.Millisecond
+	assert.Same(t, snapshot, collector.Collect(t.Context()))
+	assert.False(t, collector.status.Load().Success)
+}
+
+type fakeKPIExplorer struct {
+	*fakeExplorer
+}
+
+func (explorer *fakeKPIExplorer) ReportMetrics() []*Metric {
+	return []*Metric{{Name: "fake_users", Labels: map[string]string{"app": "fake"}, Value: 42}}
+}
+
+func TestCollectorReportedMetrics(t *testing.T) {
+	collector := NewCollector("fake", &fakeKPIExplorer{newFakeExplorer()})
+	snapshot := collector.Collect(t.Context())
+
+	assert.Equal(t, []*Metric{{Name: "fake_users", Labels: map[string]string{"app": "fake", "explorer": "fake"}, Value: 42}}, snapshot.Metrics)
+	assert.Contains(t, collector.Metrics(), snapshot.Metrics[0])
+}
+
+func TestCollectorReconfigure(t *testing.T) {
+	explorer := newFakeExplorer()
+	collector := NewCollector("fake", explorer)
+	snapshot := collector.Collect(t.Context())
+
+	replacement := newFakeExplorer()
+	replacement.impacts = replacement.impacts[:1]
+	previous, wait := collector.Reconfigure(replacement, WithCollectInterval(time.Hour))
+	assert.Same(t, explorer, previous)
+	wait()
+	assert.Same(t, replacement, collector.Explorer())
+	assert.Equal(t, time.Hour, collector.settings().interval)
+	assert.Equal(t, 3*time.Minute, collector.settings().timeout, "settings not set are reset to default")
+	assert.Same(t, snapshot, collector.Snapshot(), "snapshot is kept until the next collection")
+
+	assert.Len(t, collector.Collect(t.Context()).Impacts, 1)
+}
+
+func TestCollectorReconfigureWait(t *testing.T) {
+	explorer := newFakeExplorer()
+	explorer.block = true
+	collector := NewCollector("fake", explorer)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	go collector.Collect(ctx)
+	assert.Eventually(t, func() bool { return !waitReturns(collector.Wait, 10*time.Millisecond) }, time.Second, time.Millisecond)
+
+	_, wait := collector.Reconfigure(newFakeExplorer())
+	assert.False(t, waitReturns(wait, 10*time.Millisecond), "collection in progress uses the previous explorer")
+	assert.True(t, waitReturns(collector.Wait, time.Second), "no collection uses the replacement")
+
+	cancel()
+	assert.True(t, waitReturns(wait, time.Second))
+}
+
+// waitReturns returns true if wait returns before the timeout
+func waitReturns(wait func(), timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func TestCollectorIntensityMetrics(t *testing.T) {
+	explorer := &fakeExplorer{impacts: []*Impact{
+		{Labels: map[string]string{"kind": "fake/resource", "location": "eu-west-3"}, Energy: 10, EnergyEmissions: ZeroEmissions, EmbodiedEmissions: ZeroEmissions},
+		{Labels: map[string]string{"kind": "fake/resource", "location": "us-east-1"}, Energy: 20, EnergyEmissions: ZeroEmissions, EmbodiedEmissions: ZeroEmissions},
+	}}
+	measuredAt := time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC)
+	explorer.impacts[0].ApplyIntensity(Intensity{PerKWh: 50, Source: "live", Timestamp: measuredAt})
+	explorer.impacts[1].ApplyIntensity(Intensity{PerKWh: 400, Source: "ccf-aws"})
+
+	collector := NewCollector("fake", explorer)
+	snapshot := collector.Collect(t.Context())
+	assert.Equal(t, []*Metric{
+		{Name: "carbon_intensity_timestamp_seconds", Labels: map[string]string{"explorer": "fake", "location": "eu-west-3", "source": "live"}, Value: float64(measuredAt.Unix())},
+		{Name: "carbon_intensity_timestamp_seconds", Labels: map[string]string{"explorer": "fake", "location": "us-east-1", "source": "ccf-aws"}, Value: 0},
+	}, snapshot.Metrics)
+	since := snapshot.CollectedAt
+
+	// a new intensity measure does not reset the counters of the impacts
+	explorer.impacts[0].ApplyIntensity(Intensity{PerKWh: 60, Source: "live", Timestamp: measuredAt.Add(time.Hour)})
+	snapshot = collector.Collect(t.Context())
+	assert.Equal(t, float64(measuredAt.Add(time.Hour).Unix()), snapshot.Metrics[0].Value)
+	assert.Len(t, snapshot.Cumulative, 2)
+	for _, cumulative := range snapshot.Cumulative {
+		assert.Equal(t, since, cumulative.Since)
+	}
+}
+
+func TestOpenMetricsHandler(t *testing.T) {
+	collector := NewCollector("fake", newFakeExplorer())
+	handler := NewOpenMetricsHandler(collector)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	collector.Collect(t.Context())
+
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text;version=1.0.0")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, string(FormatOpenMetrics), rec.Header().Get("Content-Type"))
+	assert.Contains(t, rec.Body.String(), `estimated_watts{explorer="fake",kind="fake/resource",location="eu-west-3"} 10`)
+	assert.Contains(t, rec.Body.String(), `collect_success{explorer="fake"} 1`)
+	assert.True(t, strings.HasSuffix(rec.Body.String(), "# EOF\n"))
+}
@@ -0,0 +1,214 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// SnapshotSource provides the snapshot and metrics served by the http handlers
+type SnapshotSource interface {
+	MetricsSource
+	Snapshot() *Snapshot
+}
+
+// CollectorGroup runs several collectors side by side and merges their snapshots. Each
+// collector keeps its own interval, timeout and snapshot so a failing or slow explorer
+// does not affect the others. Collectors can be replaced while the group runs.
+type CollectorGroup struct {
+	collectors []*Collector
+	sources    []MetricsSource
+	// pending holds the error of the last initialization attempt of the explorers that
+	// have no collector yet, by name
+	pending map[string]error
+	runCtx  context.Context
+	cancels map[*Collector]context.CancelFunc
+	wg      *sync.WaitGroup
+	mu      *sync.RWMutex
+}
+
+// NewCollectorGroup returns a group of the collectors
+func NewCollectorGroup(collectors ...*Collector) *CollectorGroup {
+	return &CollectorGroup{
+		collectors: collectors,
+		sources:    make([]MetricsSource, 0),
+		pending:    make(map[string]error),
+		cancels:    make(map[*Collector]context.CancelFunc),
+		wg:         new(sync.WaitGroup),
+		mu:         new(sync.RWMutex),
+	}
+}
+
+// AddMetricsSources adds sources whose metrics are exported along with the collectors ones
+func (group *CollectorGroup) AddMetricsSources(sources ...MetricsSource) *CollectorGroup {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	group.sources = append(group.sources, sources...)
+	return group
+}
+
+// Collectors returns the collectors of the group
+func (group *CollectorGroup) Collectors() []*Collector {
+	group.mu.RLock()
+	defer group.mu.RUnlock()
+	return slices.Clone(group.collectors)
+}
+
+// SetPending records that the explorer could not be initialized yet, along with the error
+// of its last attempt. The explorer is reported as not ready until it is cleared with a nil
+// error.
+func (group *CollectorGroup) SetPending(explorerName string, err error) {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+	if err == nil {
+		delete(group.pending, explorerName)
+		return
+	}
+	group.pending[explorerName] = err
+}
+
+// Pending returns the explorers not initialized yet along with the error of their last
+// attempt
+func (group *CollectorGroup) Pending() map[string]error {
+	group.mu.RLock()
+	defer group.mu.RUnlock()
+	return maps.Clone(group.pending)
+}
+
+// Run runs all collectors until the context is done. Collectors added with Replace while
+// the group runs are started as well.
+func (group *CollectorGroup) Run(ctx context.Context) {
+	group.mu.Lock()
+	group.runCtx = ctx
+	for _, collector := range group.collectors {
+		group.start(collector)
+	}
+	group.mu.Unlock()
+
+	<-ctx.Done()
+	group.wg.Wait()
+}
+
+// start runs the collector in the background until the group context is done or the
+// collector is removed. It must be called with the lock held.
+func (group *CollectorGroup) start(collector *Collector) {
+	ctx, cancel := context.WithCancel(group.runCtx)
+	group.cancels[collector] = cancel
+
+	group.wg.Add(1)
+	go func() {
+		defer group.wg.Done()
+		collector.Run(ctx)
+	}()
+}
+
+// Replace replaces the collectors of the group. Collectors kept in the group are not
+// interrupted, removed ones are stopped and new ones are started if the group is running.
+func (group *CollectorGroup) Replace(collectors ...*Collector) {
+	group.mu.Lock()
+	defer group.mu.Unlock()
+
+	for _, collector := range group.collectors {
+		if slices.Contains(collectors, collector) {
+			continue
+		}
+		if cancel, running := group.cancels[collector]; running {
+			cancel()
+			delete(group.cancels, collector)
+		}
+	}
+
+	if group.runCtx != nil && group.runCtx.Err() == nil {
+		for _, collector := range collectors {
+			if _, running := group.cancels[collector]; !running {
+				group.start(collector)
+			}
+		}
+	}
+
+	group.collectors = collectors
+}
+
+// Collect runs a single collection of all collectors concurrently and returns the merged
+// snapshot.
+func (group *CollectorGroup) Collect(ctx context.Context) *Snapshot {
+	wg := new(sync.WaitGroup)
+	for _, collector := range group.Collectors() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			collector.Collect(ctx)
+		}()
+	}
+	wg.Wait()
+
+	return group.Snapshot()
+}
+
+// Snapshot returns the snapshots of all collectors merged into one, or nil if no collector
+// completed a collection yet. The merged snapshot is as old as its oldest part.
+func (group *CollectorGroup) Snapshot() *Snapshot {
+	var merged *Snapshot
+	for _, collector := range group.Collectors() {
+		snapshot := collector.Snapshot()
+		if snapshot == nil {
+			continue
+		}
+
+		if merged == nil {
+			merged = &Snapshot{
+				Impacts:     make([]*Impact, 0),
+				Aggregated:  make([]*Impact, 0),
+				Cumulative:  make([]*CumulativeImpact, 0),
+				Metrics:     make([]*Metric, 0),
+				CollectedAt: snapshot.CollectedAt,
+			}
+		}
+
+		merged.Impacts = append(merged.Impacts, snapshot.Impacts...)
+		merged.Aggregated = append(merged.Aggregated, snapshot.Aggregated...)
+		merged.Cumulative = append(merged.Cumulative, snapshot.Cumulative...)
+		merged.Metrics = append(merged.Metrics, snapshot.Metrics...)
+		merged.Duration = max(merged.Duration, snapshot.Duration)
+		merged.Errors += snapshot.Errors
+		merged.APICalls += snapshot.APICalls
+		if snapshot.CollectedAt.Before(merged.CollectedAt) {
+			merged.CollectedAt = snapshot.CollectedAt
+		}
+	}
+
+	return merged
+}
+
+// Metrics returns the metrics of all collectors. Collectors without snapshot only expose
+// their self metrics. Metrics of the additional sources are only returned along with the
+// collectors ones.
+func (group *CollectorGroup) Metrics() []*Metric {
+	metrics := make([]*Metric, 0)
+	for _, collector := range group.Collectors() {
+		metrics = append(metrics, collector.Metrics()...)
+	}
+	if len(metrics) == 0 {
+		return nil
+	}
+
+	group.mu.RLock()
+	defer group.mu.RUnlock()
+	for _, source := range group.sources {
+		metrics = append(metrics, source.Metrics()...)
+	}
+	return metrics
+}
+
+// Failed returns the names of the explorers whose last collection did not complete,
+// followed by the ones not initialized yet
+func (group *CollectorGroup) Failed() []string {
+	failed := make([]string, 0)
+	for _, collector := range group.Collectors() {
+		if status := collector.status.Load(); status == nil || !status.Success {
+			failed = append(failed, collector.explorerName)
+		}
+	}
+	return append(failed, slices.Sorted(maps.Keys(group.Pending()))...)
+}
@@ -0,0 +1,79 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCollectorGroup(t *testing.T) {
+	blocked := newFakeExplorer()
+	blocked.block = true
+
+	healthy := NewCollector("aws-prod", newFakeExplorer())
+	failing := NewCollector("gcp", blocked, WithCollectTimeout(10*time.Millisecond))
+	group := NewCollectorGroup(healthy, failing)
+
+	assert.Nil(t, group.Snapshot())
+	assert.Nil(t, group.Metrics())
+
+	snapshot := group.Collect(t.Context())
+	assert.Len(t, snapshot.Impacts, 2)
+	assert.Equal(t, []string{"gcp"}, group.Failed())
+	for _, impact := range snapshot.Impacts {
+		assert.Equal(t, "aws-prod", impact.Labels["explorer"])
+	}
+
+	// the failing explorer only exposes its self metrics
+	failed := 0
+	for _, metric := range group.Metrics() {
+		if metric.Labels["explorer"] == "gcp" {
+			failed++
+			assert.NotEqual(t, "snapshot_age_seconds", metric.Name)
+		}
+	}
+	assert.Greater(t, failed, 0)
+
+	rec := httptest.NewRecorder()
+	NewOpenMetricsHandler(group).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.Contains(rec.Body.String(), `collect_success{explorer="gcp"} 0`))
+	assert.True(t, strings.Contains(rec.Body.String(), `collect_success{explorer="aws-prod"} 1`))
+
+	// impacts of both explorers are merged once the second one recovers
+	blocked.block = false
+	snapshot = group.Collect(t.Context())
+	assert.Len(t, snapshot.Impacts, 4)
+	assert.Empty(t, group.Failed())
+}
+
+func TestCollectorGroupReplace(t *testing.T) {
+	kept := NewCollector("kept", newFakeExplorer(), WithCollectInterval(time.Hour))
+	removed := NewCollector("removed", newFakeExplorer(), WithCollectInterval(time.Hour))
+	group := NewCollectorGroup(kept, removed)
+
+	ctx, cancel := context.WithCancel(t.Context())
+	done := make(chan struct{})
+	go func() {
+		group.Run(ctx)
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return kept.Snapshot() != nil }, time.Second, time.Millisecond)
+
+	added := NewCollector("added", newFakeExplorer(), WithCollectInterval(time.Hour))
+	group.Replace(kept, added)
+	assert.Equal(t, []*Collector{kept, added}, group.Collectors())
+	assert.Eventually(t, func() bool { return added.Snapshot() != nil }, time.Second, time.Millisecond)
+
+	reloader := NewReloader(func(ctx context.Context) error { return nil })
+	group.AddMetricsSources(reloader)
+	assert.Contains(t, group.Metrics(), reloader.Metrics()[0])
+
+	cancel()
+	<-done
+}
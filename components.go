@@ -0,0 +1,180 @@
+package cloudcarbonexporter
+
+import "time"
+
+func init() {
+	RegisterMetricFamilies(
+		MetricFamily{
+			Name: "estimated_component_watts",
+			Help: "Estimated power draw of a resource component, datacenter overhead included.",
+			Type: GaugeType,
+			Unit: "watts",
+		},
+		MetricFamily{
+			Name: "estimated_component_usage_emissions_kgCO2eq_day",
+			Help: "Estimated emissions related to the energy usage of a resource component.",
+			Type: GaugeType,
+			Unit: "kgCO2eq_day",
+		},
+		MetricFamily{
+			Name: "estimated_component_embodied_emissions_kgCO2eq_day",
+			Help: "Estimated emissions related to the manufacturing of a resource component, amortized over its lifetime.",
+			Type: GaugeType,
+			Unit: "kgCO2eq_day",
+		},
+	)
+}
+
+// Component is a hardware part of a resource
+type Component string
+
+const (
+	ComponentCPU       Component = "cpu"
+	ComponentMemory    Component = "memory"
+	ComponentLocalDisk Component = "local_disk"
+	ComponentStorage   Component = "storage"
+	ComponentGPU       Component = "gpu"
+)
+
+// ComponentImpact is the share of an impact related to a single component
+type ComponentImpact struct {
+	// Energy in watts
+	Energy Energy
+	// EnergyEmissions are emissions related to energy
+	EnergyEmissions EmissionsOverTime
+	// EmbodiedEmissions are emissions related to the manufacturing
+	EmbodiedEmissions EmissionsOverTime
+}
+
+// AddComponent records the energy and embodied emissions of a component and adds them to the
+// impact totals.
+func (impact *Impact) AddComponent(component Component, energy Energy, embodied EmissionsOverTime) {
+	if impact.Components == nil {
+		impact.Components = make(map[Component]*ComponentImpact)
+	}
+
+	componentImpact, found := impact.Components[component]
+	if !found {
+		componentImpact = new(ComponentImpact)
+		impact.Components[component] = componentImpact
+	}
+
+	componentImpact.Energy += energy
+	componentImpact.EmbodiedEmissions = addEmissionsOverTime(componentImpact.EmbodiedEmissions, embodied)
+
+	impact.Energy += energy
+	impact.EmbodiedEmissions = addEmissionsOverTime(impact.EmbodiedEmissions, embodied)
+}
+
+// ApplyPUE scales the impact and components energy by the datacenter power usage
+// effectiveness and records the overhead energy it adds.
+func (impact *Impact) ApplyPUE(pue float64) {
+	impact.OverheadEnergy = impact.Energy * Energy(pue-1)
+	impact.Energy = impact.Energy * Energy(pue)
+	for _, component := range impact.Components {
+		component.Energy = component.Energy * Energy(pue)
+	}
+	impact.Inputs.PUE = pue
+}
+
+// ApplyCarbonIntensity computes the impact and components energy emissions with the grid
+// carbon intensity given in gCO2eq/kWh.
+func (impact *Impact) ApplyCarbonIntensity(perKWh Emissions) {
+	impact.EnergyEmissions = EnergyEmissions(impact.Energy, perKWh)
+	for _, component := range impact.Components {
+		component.EnergyEmissions = EnergyEmissions(component.Energy, perKWh)
+	}
+	impact.Inputs.CarbonIntensity = float64(perKWh)
+}
+
+// ApplyIntensity computes the impact and components energy emissions with the grid carbon
+// intensity and records its source and timestamp, if any, in the impact inputs. They are
+// not labels as they change between collections.
+func (impact *Impact) ApplyIntensity(intensity Intensity) {
+	impact.ApplyCarbonIntensity(intensity.PerKWh)
+	impact.Inputs.CarbonIntensitySource = intensity.Source
+	impact.Inputs.CarbonIntensityTimestamp = intensity.Timestamp.UTC()
+}
+
+// ApplyCarbonFreeEnergy computes the impact market-based emissions from its location-based
+// ones, given the share of carbon-free energy (0 to 1) matched by the provider energy
+// purchases at the impact location. Components only have location-based emissions.
+func (impact *Impact) ApplyCarbonFreeEnergy(cfe float64) {
+	cfe = min(max(cfe, 0), 1)
+	impact.MarketEmissions = EmissionsOverTime{
+		Emissions: impact.EnergyEmissions.Emissions * Emissions(1-cfe),
+		During:    impact.EnergyEmissions.During,
+	}
+	impact.Inputs.CarbonFreeEnergy = cfe
+}
+
+// ApplyWater computes the impact water consumption: on-site from the datacenter water usage
+// effectiveness, which relates the cooling water to the energy of the resource hardware,
+// and off-site from the water consumed by the grid to generate each kWh. It must be applied
+// after the PUE.
+func (impact *Impact) ApplyWater(wue float64, gridWaterIntensity float64) {
+	impact.OnSiteWater = EnergyWater(impact.Energy-impact.OverheadEnergy, wue)
+	impact.OffSiteWater = EnergyWater(impact.Energy, gridWaterIntensity)
+	impact.Inputs.WUE = wue
+	impact.Inputs.GridWaterIntensity = gridWaterIntensity
+}
+
+// LocationFactors are the factors of the datacenters and of the grid at an impact location
+type LocationFactors struct {
+	// PUE is the power usage effectiveness of the datacenters
+	PUE float64
+	// Intensity is the grid carbon intensity
+	Intensity Intensity
+	// CarbonFreeEnergy is the share of carbon-free energy (0 to 1) matched by the provider
+	// energy purchases
+	CarbonFreeEnergy float64
+	// WUE is the water usage effectiveness of the datacenters in litres/kWh
+	WUE float64
+	// GridWaterIntensity is the water consumed by the grid to generate a kWh, in litres
+	GridWaterIntensity float64
+}
+
+// ApplyLocationFactors applies the factors of the impact location in the order they depend
+// on each other: the PUE first, so that emissions and water consumption account for the
+// datacenter overhead, then the carbon intensity before the carbon-free energy share.
+func (impact *Impact) ApplyLocationFactors(factors LocationFactors) {
+	impact.ApplyPUE(factors.PUE)
+	impact.ApplyIntensity(factors.Intensity)
+	impact.ApplyCarbonFreeEnergy(factors.CarbonFreeEnergy)
+	impact.ApplyWater(factors.WUE, factors.GridWaterIntensity)
+}
+
+// EnergyEmissions returns the hourly emissions of an energy draw given a carbon intensity in
+// gCO2eq/kWh.
+func EnergyEmissions(energy Energy, perKWh Emissions) EmissionsOverTime {
+	kW := float64(energy / 1000)
+	return EmissionsOverTime{
+		Emissions: Emissions(kW) * perKWh,
+		During:    1 * time.Hour,
+	}
+}
+
+// addEmissionsOverTime combines b into a. An unset a (without duration) is replaced by b.
+func addEmissionsOverTime(a, b EmissionsOverTime) EmissionsOverTime {
+	if a.During == 0 {
+		return b
+	}
+	if b.During == 0 {
+		return a
+	}
+	return CombineEmissionsOverTime(a, b)
+}
+
+// ComponentMetrics returns the metrics exposed for each component of an impact.
+func ComponentMetrics(impact *Impact) []*Metric {
+	metrics := make([]*Metric, 0, len(impact.Components)*3)
+	for component, componentImpact := range impact.Components {
+		labels := MergeLabels(impact.Labels, map[string]string{"component": string(component)})
+		metrics = append(metrics,
+			&Metric{Name: "estimated_component_watts", Labels: labels, Value: float64(componentImpact.Energy)},
+			&Metric{Name: "estimated_component_usage_emissions_kgCO2eq_day", Labels: labels, Value: componentImpact.EnergyEmissions.KgCO2eq_day()},
+			&Metric{Name: "estimated_component_embodied_emissions_kgCO2eq_day", Labels: labels, Value: componentImpact.EmbodiedEmissions.KgCO2eq_day()},
+		)
+	}
+	return metrics
+}
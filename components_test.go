@@ -0,0 +1,102 @@
+package cloudcarbonexporter_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+)
+
+func TestImpactComponents(t *testing.T) {
+	impact := &cloudcarbonexporter.Impact{
+		Labels: map[string]string{"kind": "ec2/instance"},
+	}
+
+	day := cloudcarbonexporter.EmissionsOverTime{Emissions: 1000, During: 24 * time.Hour}
+	impact.AddComponent(cloudcarbonexporter.ComponentCPU, 10, day)
+	impact.AddComponent(cloudcarbonexporter.ComponentMemory, 20, day)
+	impact.AddComponent(cloudcarbonexporter.ComponentLocalDisk, 0, day)
+	impact.AddComponent(cloudcarbonexporter.ComponentLocalDisk, 5, day)
+
+	assert.Equal(t, cloudcarbonexporter.Energy(35), impact.Energy)
+	assert.InDelta(t, 4.0, impact.EmbodiedEmissions.KgCO2eq_day(), 0.0001)
+	assert.Len(t, impact.Components, 3)
+	assert.Equal(t, cloudcarbonexporter.Energy(5), impact.Components[cloudcarbonexporter.ComponentLocalDisk].Energy)
+	assert.InDelta(t, 2.0, impact.Components[cloudcarbonexporter.ComponentLocalDisk].EmbodiedEmissions.KgCO2eq_day(), 0.0001)
+
+	impact.ApplyPUE(2)
+	assert.Equal(t, cloudcarbonexporter.Energy(70), impact.Energy)
+	assert.Equal(t, cloudcarbonexporter.Energy(35), impact.OverheadEnergy)
+	assert.Equal(t, cloudcarbonexporter.Energy(20), impact.Components[cloudcarbonexporter.ComponentCPU].Energy)
+	assert.Equal(t, 2.0, impact.Inputs.PUE)
+
+	impact.ApplyCarbonIntensity(100)
+	assert.InDelta(t, 7.0, float64(impact.EnergyEmissions.Emissions), 0.0001)
+	assert.InDelta(t, 2.0, float64(impact.Components[cloudcarbonexporter.ComponentCPU].EnergyEmissions.Emissions), 0.0001)
+	assert.Equal(t, 100.0, impact.Inputs.CarbonIntensity)
+
+	impact.ApplyIntensity(cloudcarbonexporter.Intensity{PerKWh: 200, Source: "live", Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.FixedZone("CEST", 2*3600))})
+	assert.InDelta(t, 14.0, float64(impact.EnergyEmissions.Emissions), 0.0001)
+	assert.Equal(t, "live", impact.Inputs.CarbonIntensitySource)
+	assert.Equal(t, time.Date(2025, 6, 1, 8, 0, 0, 0, time.UTC), impact.Inputs.CarbonIntensityTimestamp)
+	assert.NotContains(t, impact.Labels, "intensity_source")
+	assert.Equal(t, "ec2/instance", impact.Labels["kind"])
+
+	impact.ApplyCarbonFreeEnergy(0.75)
+	assert.InDelta(t, 3.5, float64(impact.MarketEmissions.Emissions), 0.0001)
+	assert.Equal(t, impact.EnergyEmissions.During, impact.MarketEmissions.During)
+	assert.Equal(t, 0.75, impact.Inputs.CarbonFreeEnergy)
+
+	methods := make(map[string]float64)
+	for _, metric := range cloudcarbonexporter.ImpactMetrics(impact) {
+		if metric.Name == "estimated_usage_emissions_kgCO2eq_day" {
+			methods[metric.Labels["method"]] = metric.Value
+		}
+	}
+	assert.InDelta(t, impact.EnergyEmissions.KgCO2eq_day(), methods["location"], 0.0001)
+	assert.InDelta(t, impact.EnergyEmissions.KgCO2eq_day()/4, methods["market"], 0.0001)
+
+	impact.ApplyCarbonFreeEnergy(1.5)
+	assert.Equal(t, cloudcarbonexporter.Emissions(0), impact.MarketEmissions.Emissions, "carbon-free energy is capped")
+
+	// 35W of hardware and 35W of overhead during a day
+	impact.ApplyWater(0.5, 2)
+	assert.InDelta(t, 0.42, float64(impact.OnSiteWater), 0.0001)
+	assert.InDelta(t, 3.36, float64(impact.OffSiteWater), 0.0001)
+	assert.Equal(t, 0.5, impact.Inputs.WUE)
+
+	scopes := make(map[string]float64)
+	for _, metric := range cloudcarbonexporter.ImpactMetrics(impact) {
+		if metric.Name == "estimated_water_litres_day" {
+			scopes[metric.Labels["scope"]] = metric.Value
+		}
+	}
+	assert.Equal(t, map[string]float64{"onsite": float64(impact.OnSiteWater), "offsite": float64(impact.OffSiteWater)}, scopes)
+
+	metrics := cloudcarbonexporter.ComponentMetrics(impact)
+	assert.Len(t, metrics, 3*3)
+	for _, metric := range metrics {
+		assert.Equal(t, "ec2/instance", metric.Labels["kind"])
+		assert.NotEmpty(t, metric.Labels["component"])
+	}
+}
+
+func TestImpactLocationFactors(t *testing.T) {
+	impact := &cloudcarbonexporter.Impact{Labels: map[string]string{"kind": "ec2/instance"}}
+	impact.AddComponent(cloudcarbonexporter.ComponentCPU, 40, cloudcarbonexporter.ZeroEmissions)
+
+	impact.ApplyLocationFactors(cloudcarbonexporter.LocationFactors{
+		PUE:                1.5,
+		Intensity:          cloudcarbonexporter.Intensity{PerKWh: 100, Source: "static"},
+		CarbonFreeEnergy:   0.5,
+		WUE:                1,
+		GridWaterIntensity: 2,
+	})
+	assert.Equal(t, cloudcarbonexporter.Energy(60), impact.Energy)
+	assert.InDelta(t, 6.0, float64(impact.EnergyEmissions.Emissions), 0.0001, "emissions include the datacenter overhead")
+	assert.InDelta(t, 3.0, float64(impact.MarketEmissions.Emissions), 0.0001)
+	assert.InDelta(t, 0.96, float64(impact.OnSiteWater), 0.0001, "40W of hardware during a day")
+	assert.InDelta(t, 2.88, float64(impact.OffSiteWater), 0.0001, "60W from the grid during a day")
+	assert.Equal(t, "static", impact.Inputs.CarbonIntensitySource)
+}
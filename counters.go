@@ -0,0 +1,176 @@
+package cloudcarbonexporter
+
+import (
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterMetricFamilies(
+		MetricFamily{
+			Name: "estimated_energy_joules_total",
+			Help: "Estimated energy consumed by the resource since it was first discovered, datacenter overhead included.",
+			Type: CounterType,
+			Unit: "joules",
+		},
+		MetricFamily{
+			Name: "estimated_usage_emissions_grams_total",
+			Help: "Estimated emissions related to the resource energy usage since it was first discovered, in gCO2eq.",
+			Type: CounterType,
+			Unit: "grams",
+		},
+		MetricFamily{
+			Name: "estimated_embodied_emissions_grams_total",
+			Help: "Estimated embodied emissions amortized since the resource was first discovered, in gCO2eq.",
+			Type: CounterType,
+			Unit: "grams",
+		},
+	)
+}
+
+// CumulativeImpact holds the impact of a resource integrated over time, since its first
+// discovery.
+type CumulativeImpact struct {
+	Labels                 map[string]string
+	Since                  time.Time
+	EnergyJoules           float64
+	UsageEmissionsGrams    float64
+	EmbodiedEmissionsGrams float64
+}
+
+// Metrics returns the counters exposed for a cumulative impact.
+func (cumulative *CumulativeImpact) Metrics() []*Metric {
+	return []*Metric{
+		{Name: "estimated_energy_joules_total", Labels: cumulative.Labels, Value: cumulative.EnergyJoules, Created: cumulative.Since},
+		{Name: "estimated_usage_emissions_grams_total", Labels: cumulative.Labels, Value: cumulative.UsageEmissionsGrams, Created: cumulative.Since},
+		{Name: "estimated_embodied_emissions_grams_total", Labels: cumulative.Labels, Value: cumulative.EmbodiedEmissionsGrams, Created: cumulative.Since},
+	}
+}
+
+// maxMissedCollections is the number of consecutive collections a resource can be missing
+// from before its counters are dropped, so that a failed api call does not reset them
+const maxMissedCollections = 3
+
+// integratedImpact is the integration state of a single resource
+type integratedImpact struct {
+	last       *Impact
+	lastSeenAt time.Time
+	// missed counts the collections the resource has been missing from since it was last seen
+	missed     int
+	cumulative CumulativeImpact
+}
+
+// impactIntegrator integrates impacts between collections using the trapezoidal rule.
+// Resources are identified by their labels. A resource missing from a collection keeps its
+// counters, integrated over the gap when it comes back. It is forgotten once it has been
+// missing from maxMissedCollections collections: if it comes back, its counters start
+// again from zero.
+type impactIntegrator struct {
+	mu     *sync.Mutex
+	series map[string]*integratedImpact
+}
+
+func newImpactIntegrator() *impactIntegrator {
+	return &impactIntegrator{
+		mu:     new(sync.Mutex),
+		series: make(map[string]*integratedImpact),
+	}
+}
+
+// integrate adds the impacts collected at the given time to the counters and returns
+// a copy of all cumulative impacts.
+func (integrator *impactIntegrator) integrate(impacts []*Impact, at time.Time) []*CumulativeImpact {
+	integrator.mu.Lock()
+	defer integrator.mu.Unlock()
+
+	// impacts sharing the same labels are summed, each resource is integrated once
+	fingerprints := make([]string, 0, len(impacts))
+	members := make(map[string][]*Impact, len(impacts))
+	for _, impact := range impacts {
+		fingerprint := labelsFingerprint(impact.Labels)
+		if _, found := members[fingerprint]; !found {
+			fingerprints = append(fingerprints, fingerprint)
+		}
+		members[fingerprint] = append(members[fingerprint], impact)
+	}
+
+	seen := make(map[string]*integratedImpact, len(integrator.series))
+	for _, fingerprint := range fingerprints {
+		impact := members[fingerprint][0]
+		if len(members[fingerprint]) > 1 {
+			impact = sumImpacts(impact.Labels, members[fingerprint])
+		}
+
+		series, found := integrator.series[fingerprint]
+		if !found {
+			series = &integratedImpact{
+				cumulative: CumulativeImpact{
+					Labels: impact.Labels,
+					Since:  at,
+				},
+			}
+		} else {
+			elapsed := at.Sub(series.lastSeenAt).Seconds()
+			series.cumulative.Labels = impact.Labels
+			series.cumulative.EnergyJoules += trapezoid(float64(series.last.Energy), float64(impact.Energy), elapsed)
+			series.cumulative.UsageEmissionsGrams += trapezoid(gramsPerSecond(series.last.EnergyEmissions), gramsPerSecond(impact.EnergyEmissions), elapsed)
+			series.cumulative.EmbodiedEmissionsGrams += trapezoid(gramsPerSecond(series.last.EmbodiedEmissions), gramsPerSecond(impact.EmbodiedEmissions), elapsed)
+		}
+
+		series.last = impact
+		series.lastSeenAt = at
+		series.missed = 0
+		seen[fingerprint] = series
+	}
+
+	for fingerprint, series := range integrator.series {
+		if _, found := seen[fingerprint]; found {
+			continue
+		}
+		if series.missed++; series.missed <= maxMissedCollections {
+			seen[fingerprint] = series
+		}
+	}
+	integrator.series = seen
+
+	cumulatives := make([]*CumulativeImpact, 0, len(seen))
+	for _, series := range seen {
+		cumulative := series.cumulative
+		cumulatives = append(cumulatives, &cumulative)
+	}
+
+	return cumulatives
+}
+
+// trapezoid returns the area under the line going from start to end during elapsed seconds
+func trapezoid(start, end, elapsed float64) float64 {
+	return (start + end) / 2 * elapsed
+}
+
+func gramsPerSecond(emissions EmissionsOverTime) float64 {
+	if emissions.During == 0 {
+		return 0
+	}
+	return emissions.KgCO2eq_second() * 1000
+}
+
+// labelsFingerprint returns a string uniquely identifying a set of labels
+func labelsFingerprint(labels map[string]string) string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	sb := new(strings.Builder)
+	for _, name := range names {
+		sb.WriteString(name)
+		sb.WriteByte(0xff)
+		sb.WriteString(labels[name])
+		sb.WriteByte(0xff)
+	}
+
+	return sb.String()
+}
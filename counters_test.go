@@ -0,0 +1,89 @@
+package cloudcarbonexporter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestImpactIntegrator(t *testing.T) {
+	integrator := newImpactIntegrator()
+	start := time.Now()
+	perDay := func(grams float64) EmissionsOverTime {
+		return EmissionsOverTime{During: 24 * time.Hour, Emissions: Emissions(grams)}
+	}
+
+	vm := func(watts float64) *Impact {
+		return &Impact{Labels: map[string]string{"instance_id": "vm"}, Energy: Energy(watts), EnergyEmissions: perDay(86400), EmbodiedEmissions: perDay(2 * 86400)}
+	}
+	disk := &Impact{Labels: map[string]string{"disk_id": "disk"}, Energy: 1, EnergyEmissions: perDay(0), EmbodiedEmissions: perDay(0)}
+
+	// counters start at zero on first discovery
+	cumulatives := integrator.integrate([]*Impact{vm(10), disk}, start)
+	assert.Len(t, cumulatives, 2)
+	for _, cumulative := range cumulatives {
+		assert.Equal(t, 0.0, cumulative.EnergyJoules)
+		assert.Equal(t, start, cumulative.Since)
+	}
+
+	// 10W then 30W during 10 seconds averages to 20W: 200 joules
+	cumulatives = integrator.integrate([]*Impact{vm(30), disk}, start.Add(10*time.Second))
+	assert.Len(t, cumulatives, 2)
+	vmCumulative := findCumulative(cumulatives, "instance_id")
+	assert.Equal(t, 200.0, vmCumulative.EnergyJoules)
+	assert.InDelta(t, 10.0, vmCumulative.UsageEmissionsGrams, 1e-9)
+	assert.InDelta(t, 20.0, vmCumulative.EmbodiedEmissionsGrams, 1e-9)
+
+	// disk is missing from a collection, its counters are kept
+	cumulatives = integrator.integrate([]*Impact{vm(30)}, start.Add(20*time.Second))
+	assert.Len(t, cumulatives, 2)
+	assert.Equal(t, 500.0, findCumulative(cumulatives, "instance_id").EnergyJoules)
+	assert.Equal(t, 10.0, findCumulative(cumulatives, "disk_id").EnergyJoules)
+
+	// disk comes back and is integrated over the gap
+	cumulatives = integrator.integrate([]*Impact{vm(30), disk}, start.Add(30*time.Second))
+	assert.Equal(t, 30.0, findCumulative(cumulatives, "disk_id").EnergyJoules)
+	assert.Equal(t, start, findCumulative(cumulatives, "disk_id").Since)
+
+	// disk is dropped once missing from too many collections, then starts from scratch
+	at := start.Add(30 * time.Second)
+	for range maxMissedCollections + 1 {
+		at = at.Add(10 * time.Second)
+		cumulatives = integrator.integrate([]*Impact{vm(30)}, at)
+	}
+	assert.Len(t, cumulatives, 1)
+	at = at.Add(10 * time.Second)
+	cumulatives = integrator.integrate([]*Impact{vm(30), disk}, at)
+	assert.Equal(t, 0.0, findCumulative(cumulatives, "disk_id").EnergyJoules)
+	assert.Equal(t, at, findCumulative(cumulatives, "disk_id").Since)
+}
+
+func TestImpactIntegratorSameLabels(t *testing.T) {
+	integrator := newImpactIntegrator()
+	start := time.Now()
+	vm := func(watts float64) *Impact {
+		return &Impact{Labels: map[string]string{"kind": "vm"}, Energy: Energy(watts), EnergyEmissions: ZeroEmissions, EmbodiedEmissions: ZeroEmissions}
+	}
+
+	integrator.integrate([]*Impact{vm(10), vm(20)}, start)
+	cumulatives := integrator.integrate([]*Impact{vm(10), vm(20)}, start.Add(10*time.Second))
+
+	// impacts sharing the same labels are summed before being integrated
+	assert.Len(t, cumulatives, 1)
+	assert.Equal(t, 300.0, cumulatives[0].EnergyJoules)
+}
+
+func findCumulative(cumulatives []*CumulativeImpact, label string) *CumulativeImpact {
+	for _, cumulative := range cumulatives {
+		if _, found := cumulative.Labels[label]; found {
+			return cumulative
+		}
+	}
+	return nil
+}
+
+func TestLabelsFingerprint(t *testing.T) {
+	assert.Equal(t, labelsFingerprint(map[string]string{"a": "1", "b": "2"}), labelsFingerprint(map[string]string{"b": "2", "a": "1"}))
+	assert.NotEqual(t, labelsFingerprint(map[string]string{"a": "1b"}), labelsFingerprint(map[string]string{"a1": "b"}))
+}
@@ -0,0 +1,288 @@
+package cloudcarbonexporter
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Format is an exposition format negotiated with the scraper.
+type Format string
+
+const (
+	// FormatOpenMetrics is the OpenMetrics 1.0.0 text format
+	FormatOpenMetrics Format = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+	// FormatPrometheusText is the legacy Prometheus text format
+	FormatPrometheusText Format = "text/plain; version=0.0.4; charset=utf-8"
+)
+
+// MetricType is the type of a metric family as defined by the OpenMetrics specification.
+type MetricType string
+
+const (
+	GaugeType   MetricType = "gauge"
+	CounterType MetricType = "counter"
+	UnknownType MetricType = "unknown"
+)
+
+// MetricFamily holds the metadata shared by all samples of the same metric.
+type MetricFamily struct {
+	// Name of the samples of the family. Counters names must end with _total.
+	Name string
+	Help string
+	Type MetricType
+	// Unit is optional. When set, the family name must end with it.
+	Unit string
+}
+
+var metricFamiliesMu = new(sync.RWMutex)
+var metricFamilies = map[string]MetricFamily{}
+
+func init() {
+	RegisterMetricFamilies(
+		MetricFamily{
+			Name: "estimated_watts",
+			Help: "Estimated power draw of the resource, datacenter overhead included.",
+			Type: GaugeType,
+			Unit: "watts",
+		},
+		MetricFamily{
+			Name: "estimated_overhead_watts",
+			Help: "Estimated power draw of the datacenter cooling and power distribution for the resource, included in estimated_watts.",
+			Type: GaugeType,
+			Unit: "watts",
+		},
+		MetricFamily{
+			Name: "estimated_usage_emissions_kgCO2eq_day",
+			Help: "Estimated emissions related to the resource energy usage.",
+			Type: GaugeType,
+			Unit: "kgCO2eq_day",
+		},
+		MetricFamily{
+			Name: "estimated_embodied_emissions_kgCO2eq_day",
+			Help: "Estimated emissions related to the manufacturing of the resource hardware, amortized over its lifetime.",
+			Type: GaugeType,
+			Unit: "kgCO2eq_day",
+		},
+		MetricFamily{
+			Name: "estimated_water_litres_day",
+			Help: "Estimated water consumption of the resource, by the datacenter cooling (onsite) and by the electricity generation (offsite).",
+			Type: GaugeType,
+			Unit: "litres_day",
+		},
+		MetricFamily{
+			Name: "collect_duration_ms",
+			Help: "Duration of the last resources collection in milliseconds.",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "error_count",
+			Help: "Number of errors encountered during the last resources collection.",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "api_calls",
+			Help: "Number of cloud api calls made during the last resources collection.",
+			Type: GaugeType,
+		},
+	)
+}
+
+// RegisterMetricFamilies registers metric families metadata. Metadata are written alongside
+// samples when metrics are exposed. Registering an existing family overrides it.
+func RegisterMetricFamilies(families ...MetricFamily) {
+	metricFamiliesMu.Lock()
+	defer metricFamiliesMu.Unlock()
+
+	for _, family := range families {
+		metricFamilies[family.Name] = family
+	}
+}
+
+// LookupMetricFamily returns the registered family of the metric name. Unregistered metrics
+// belong to a family of unknown type.
+func LookupMetricFamily(name string) MetricFamily {
+	metricFamiliesMu.RLock()
+	defer metricFamiliesMu.RUnlock()
+
+	family, found := metricFamilies[name]
+	if !found {
+		return MetricFamily{Name: name, Type: UnknownType}
+	}
+
+	return family
+}
+
+// NegotiateFormat returns the exposition format preferred by the client Accept header.
+// OpenMetrics is only returned when explicitly accepted with a quality greater or equal
+// than the Prometheus text format.
+func NegotiateFormat(accept string) Format {
+	openMetricsQuality := -1.0
+	textQuality := -1.0
+
+	for _, mediaRange := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(mediaRange))
+		if err != nil {
+			continue
+		}
+
+		quality := 1.0
+		if q, found := params["q"]; found {
+			quality, err = strconv.ParseFloat(q, 64)
+			if err != nil {
+				continue
+			}
+		}
+
+		switch mediaType {
+		case "application/openmetrics-text":
+			openMetricsQuality = max(openMetricsQuality, quality)
+		case "text/plain", "text/*", "*/*":
+			textQuality = max(textQuality, quality)
+		}
+	}
+
+	if openMetricsQuality > 0 && openMetricsQuality >= textQuality {
+		return FormatOpenMetrics
+	}
+
+	return FormatPrometheusText
+}
+
+// Encoder writes metrics grouped by family in the OpenMetrics or Prometheus text format.
+type Encoder struct {
+	w      io.Writer
+	format Format
+}
+
+// NewEncoder returns an encoder writing on w in the given format.
+func NewEncoder(w io.Writer, format Format) *Encoder {
+	return &Encoder{
+		w:      w,
+		format: format,
+	}
+}
+
+// Encode writes all metrics grouped by family. Families are written in order of first
+// appearance and samples are sorted by labels. With the OpenMetrics format, the exposition
+// is terminated by the # EOF marker.
+func (encoder *Encoder) Encode(metrics []*Metric) error {
+	families := make([]string, 0)
+	samples := make(map[string][]*Metric)
+
+	for _, metric := range metrics {
+		if metric == nil {
+			continue
+		}
+		if _, found := samples[metric.Name]; !found {
+			families = append(families, metric.Name)
+		}
+		samples[metric.Name] = append(samples[metric.Name], metric)
+	}
+
+	for _, name := range families {
+		if err := encoder.encodeFamily(LookupMetricFamily(name), samples[name]); err != nil {
+			return err
+		}
+	}
+
+	if encoder.format == FormatOpenMetrics {
+		if _, err := io.WriteString(encoder.w, "# EOF\n"); err != nil {
+			return fmt.Errorf("failed to write eof marker: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func (encoder *Encoder) encodeFamily(family MetricFamily, metrics []*Metric) error {
+	sb := new(strings.Builder)
+
+	name := family.Name
+	if encoder.format == FormatOpenMetrics && family.Type == CounterType {
+		name = strings.TrimSuffix(name, "_total")
+	}
+
+	if family.Help != "" {
+		fmt.Fprintf(sb, "# HELP %s %s\n", name, encoder.escapeHelp(family.Help))
+	}
+
+	familyType := family.Type
+	if encoder.format == FormatPrometheusText && familyType == UnknownType {
+		familyType = "untyped"
+	}
+	fmt.Fprintf(sb, "# TYPE %s %s\n", name, familyType)
+
+	if encoder.format == FormatOpenMetrics && family.Unit != "" {
+		fmt.Fprintf(sb, "# UNIT %s %s\n", name, family.Unit)
+	}
+
+	lines := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		sanitized := metric.Clone()
+		lines = append(lines, metric.Name+formatLabels(sanitized.SanitizeLabels().Labels)+" "+formatValue(metric.Value)+"\n")
+	}
+	slices.Sort(lines)
+
+	for _, line := range lines {
+		sb.WriteString(line)
+	}
+
+	if _, err := io.WriteString(encoder.w, sb.String()); err != nil {
+		return fmt.Errorf("writing metric family %s failed: %w", family.Name, err)
+	}
+
+	return nil
+}
+
+func (encoder *Encoder) escapeHelp(help string) string {
+	if encoder.format == FormatOpenMetrics {
+		return escapeLabelValue(help)
+	}
+
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
+
+// formatLabels returns labels sorted in lexicographical order and formatted as {k1="v1",k2="v2"}.
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	pairs := make([]string, len(names))
+	for i, name := range names {
+		pairs[i] = name + `="` + escapeLabelValue(labels[name]) + `"`
+	}
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueReplacer.Replace(value)
+}
+
+func formatValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	default:
+		return strconv.FormatFloat(value, 'g', -1, 64)
+	}
+}
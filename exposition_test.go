@@ -0,0 +1,64 @@
+package cloudcarbonexporter
+
+import (
+	"bytes"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateFormat(t *testing.T) {
+	assert.Equal(t, FormatPrometheusText, NegotiateFormat(""))
+	assert.Equal(t, FormatPrometheusText, NegotiateFormat("text/plain"))
+	assert.Equal(t, FormatOpenMetrics, NegotiateFormat("application/openmetrics-text"))
+	assert.Equal(t, FormatOpenMetrics, NegotiateFormat("application/openmetrics-text;version=1.0.0;q=0.5,text/plain;version=0.0.4;q=0.4,*/*;q=0.1"))
+	assert.Equal(t, FormatPrometheusText, NegotiateFormat("application/openmetrics-text;q=0.2,text/plain;q=0.9"))
+	assert.Equal(t, FormatPrometheusText, NegotiateFormat("application/openmetrics-text;q=0"))
+}
+
+func TestEncodeOpenMetrics(t *testing.T) {
+	RegisterMetricFamilies(MetricFamily{Name: "test_energy_joules_total", Help: "Test\ncounter.", Type: CounterType, Unit: "joules"})
+
+	buf := new(bytes.Buffer)
+	err := NewEncoder(buf, FormatOpenMetrics).Encode([]*Metric{
+		{Name: "estimated_watts", Labels: map[string]string{"kind": "b"}, Value: 2},
+		{Name: "test_energy_joules_total", Labels: map[string]string{"kind": "a"}, Value: 10},
+		{Name: "estimated_watts", Labels: map[string]string{"kind": "a", "tag_name": "my \"quoted\" \\ value\n"}, Value: 1.5},
+		nil,
+		{Name: "unregistered", Value: math.NaN()},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `# HELP estimated_watts Estimated power draw of the resource, datacenter overhead included.
+# TYPE estimated_watts gauge
+# UNIT estimated_watts watts
+estimated_watts{kind="a",tag_name="my \"quoted\" \\ value\n"} 1.5
+estimated_watts{kind="b"} 2
+# HELP test_energy_joules Test\ncounter.
+# TYPE test_energy_joules counter
+# UNIT test_energy_joules joules
+test_energy_joules_total{kind="a"} 10
+# TYPE unregistered unknown
+unregistered NaN
+# EOF
+`, buf.String())
+}
+
+func TestEncodePrometheusText(t *testing.T) {
+	RegisterMetricFamilies(MetricFamily{Name: "test_energy_joules_total", Help: "Test counter.", Type: CounterType, Unit: "joules"})
+
+	buf := new(bytes.Buffer)
+	err := NewEncoder(buf, FormatPrometheusText).Encode([]*Metric{
+		{Name: "test_energy_joules_total", Labels: map[string]string{"karpenter.sh/nodeclaim": "a"}, Value: 10},
+		{Name: "unregistered", Value: math.Inf(1)},
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, `# HELP test_energy_joules_total Test counter.
+# TYPE test_energy_joules_total counter
+test_energy_joules_total{karpenter_sh_nodeclaim="a"} 10
+# TYPE unregistered untyped
+unregistered +Inf
+`, buf.String())
+}
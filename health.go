@@ -0,0 +1,203 @@
+package cloudcarbonexporter
+
+import (
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// ReadinessCheck reports whether a part of an explorer is ready and why it is not
+type ReadinessCheck struct {
+	Name   string `json:"name"`
+	Ready  bool   `json:"ready"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ReadinessReporter is implemented by explorers detailing the readiness of their parts
+// (credentials, discovery, sub explorers...)
+type ReadinessReporter interface {
+	ReadinessChecks() []ReadinessCheck
+}
+
+// Readiness records the state of named checks. Checks are not ready until they are set
+// without error. It is safe for concurrent use.
+type Readiness struct {
+	mu     *sync.Mutex
+	checks map[string]*ReadinessCheck
+}
+
+// NewReadiness returns a Readiness where all checks are pending
+func NewReadiness(names ...string) *Readiness {
+	readiness := &Readiness{
+		mu:     new(sync.Mutex),
+		checks: make(map[string]*ReadinessCheck, len(names)),
+	}
+	for _, name := range names {
+		readiness.checks[name] = &ReadinessCheck{Name: name, Reason: "not initialized"}
+	}
+	return readiness
+}
+
+// Set marks the check ready if err is nil, not ready with err as reason otherwise
+func (readiness *Readiness) Set(name string, err error) {
+	readiness.mu.Lock()
+	defer readiness.mu.Unlock()
+
+	check := &ReadinessCheck{Name: name, Ready: err == nil}
+	if err != nil {
+		check.Reason = err.Error()
+	}
+	readiness.checks[name] = check
+}
+
+// Delete removes the check
+func (readiness *Readiness) Delete(name string) {
+	readiness.mu.Lock()
+	defer readiness.mu.Unlock()
+
+	delete(readiness.checks, name)
+}
+
+// Checks returns the checks sorted by name
+func (readiness *Readiness) Checks() []ReadinessCheck {
+	readiness.mu.Lock()
+	defer readiness.mu.Unlock()
+
+	checks := make([]ReadinessCheck, 0, len(readiness.checks))
+	for _, check := range readiness.checks {
+		checks = append(checks, *check)
+	}
+	slices.SortFunc(checks, func(a, b ReadinessCheck) int { return strings.Compare(a.Name, b.Name) })
+
+	return checks
+}
+
+// IsReady returns true if all checks are ready
+func (readiness *Readiness) IsReady() bool {
+	readiness.mu.Lock()
+	defer readiness.mu.Unlock()
+
+	for _, check := range readiness.checks {
+		if !check.Ready {
+			return false
+		}
+	}
+	return true
+}
+
+// ExplorerReadiness is the readiness of the explorer of a collector
+type ExplorerReadiness struct {
+	Explorer string           `json:"explorer"`
+	Ready    bool             `json:"ready"`
+	Checks   []ReadinessCheck `json:"checks"`
+}
+
+// Readiness returns the readiness of the collector explorer. A collector is ready once its
+// explorer is ready and a first collection completed.
+func (collector *Collector) Readiness() ExplorerReadiness {
+	explorer := collector.Explorer()
+	checks := make([]ReadinessCheck, 0)
+	if reporter, ok := explorer.(ReadinessReporter); ok {
+		checks = append(checks, reporter.ReadinessChecks()...)
+	} else {
+		check := ReadinessCheck{Name: "explorer", Ready: explorer.IsReady()}
+		if !check.Ready {
+			check.Reason = "explorer is not ready"
+		}
+		checks = append(checks, check)
+	}
+
+	snapshotCheck := ReadinessCheck{Name: "snapshot", Ready: collector.Snapshot() != nil}
+	if !snapshotCheck.Ready {
+		snapshotCheck.Reason = "no collection completed yet"
+	}
+	checks = append(checks, snapshotCheck)
+
+	return ExplorerReadiness{
+		Explorer: collector.explorerName,
+		Ready:    explorer.IsReady() && !slices.ContainsFunc(checks, func(check ReadinessCheck) bool { return !check.Ready }),
+		Checks:   checks,
+	}
+}
+
+// ReadinessResponse is the body returned by the readiness endpoint
+type ReadinessResponse struct {
+	Ready     bool                `json:"ready"`
+	Explorers []ExplorerReadiness `json:"explorers"`
+	// Failing lists the explorers that are not ready
+	Failing []string `json:"failing,omitempty"`
+}
+
+// HealthHandler answers liveness probes. It only reports that the exporter is serving
+// http requests.
+type HealthHandler struct{}
+
+// NewHealthHandler creates a new HealthHandler
+func NewHealthHandler() *HealthHandler {
+	return &HealthHandler{}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (handler *HealthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// ReadinessHandler answers readiness probes with the readiness of all collectors of a
+// group. It responds 503 Service Unavailable until one of them has a snapshot to serve, so
+// a broken explorer does not take down the exporter serving the others. Explorers that are
+// not ready, including the ones still failing to initialize, are listed in the response body.
+type ReadinessHandler struct {
+	group *CollectorGroup
+}
+
+// NewReadinessHandler creates a new ReadinessHandler reporting the group collectors readiness
+func NewReadinessHandler(group *CollectorGroup) *ReadinessHandler {
+	return &ReadinessHandler{
+		group: group,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (handler *ReadinessHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	collectors := handler.group.Collectors()
+	response := &ReadinessResponse{
+		Explorers: make([]ExplorerReadiness, 0, len(collectors)),
+	}
+
+	for _, collector := range collectors {
+		readiness := collector.Readiness()
+		response.Ready = response.Ready || collector.Snapshot() != nil
+		if !readiness.Ready {
+			response.Failing = append(response.Failing, readiness.Explorer)
+		}
+		response.Explorers = append(response.Explorers, readiness)
+	}
+
+	pending := handler.group.Pending()
+	for _, name := range slices.Sorted(maps.Keys(pending)) {
+		response.Failing = append(response.Failing, name)
+		response.Explorers = append(response.Explorers, ExplorerReadiness{
+			Explorer: name,
+			Checks:   []ReadinessCheck{{Name: "init", Reason: pending[name].Error()}},
+		})
+	}
+
+	status := http.StatusOK
+	if !response.Ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	writeJSON(w, status, response)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Warn("failed to encode json response", "err", err.Error())
+	}
+}
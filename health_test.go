@@ -0,0 +1,122 @@
+package cloudcarbonexporter
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeReadinessExplorer struct {
+	*fakeExplorer
+	readiness *Readiness
+}
+
+func (explorer *fakeReadinessExplorer) IsReady() bool { return explorer.readiness.IsReady() }
+
+func (explorer *fakeReadinessExplorer) ReadinessChecks() []ReadinessCheck {
+	return explorer.readiness.Checks()
+}
+
+func getReadiness(t *testing.T, handler http.Handler, expectedStatus int) *ReadinessResponse {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, expectedStatus, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	response := new(ReadinessResponse)
+	assert.NoError(t, json.NewDecoder(rec.Body).Decode(response))
+	return response
+}
+
+func TestReadinessHandler(t *testing.T) {
+	explorer := &fakeReadinessExplorer{
+		fakeExplorer: newFakeExplorer(),
+		readiness:    NewReadiness("credentials", "fake/resource"),
+	}
+	collector := NewCollector("fake", explorer)
+	handler := NewReadinessHandler(NewCollectorGroup(collector))
+
+	response := getReadiness(t, handler, http.StatusServiceUnavailable)
+	assert.False(t, response.Ready)
+	assert.Len(t, response.Explorers, 1)
+	assert.Equal(t, "fake", response.Explorers[0].Explorer)
+	assert.Equal(t, []ReadinessCheck{
+		{Name: "credentials", Reason: "not initialized"},
+		{Name: "fake/resource", Reason: "not initialized"},
+		{Name: "snapshot", Reason: "no collection completed yet"},
+	}, response.Explorers[0].Checks)
+
+	explorer.readiness.Set("credentials", nil)
+	explorer.readiness.Set("fake/resource", fmt.Errorf("access denied"))
+	collector.Collect(t.Context())
+
+	// the exporter serves the snapshot of the explorer while one of its checks fails
+	response = getReadiness(t, handler, http.StatusOK)
+	assert.True(t, response.Ready)
+	assert.False(t, response.Explorers[0].Ready)
+	assert.Equal(t, []string{"fake"}, response.Failing)
+	assert.Equal(t, []ReadinessCheck{
+		{Name: "credentials", Ready: true},
+		{Name: "fake/resource", Reason: "access denied"},
+		{Name: "snapshot", Ready: true},
+	}, response.Explorers[0].Checks)
+
+	explorer.readiness.Set("fake/resource", nil)
+	response = getReadiness(t, handler, http.StatusOK)
+	assert.True(t, response.Ready)
+	assert.True(t, response.Explorers[0].Ready)
+	assert.Empty(t, response.Failing)
+
+	// explorers not reporting checks fall back on IsReady
+	readiness := NewCollector("other", newFakeExplorer()).Readiness()
+	assert.Equal(t, ReadinessCheck{Name: "explorer", Ready: true}, readiness.Checks[0])
+	assert.False(t, readiness.Ready)
+}
+
+func TestReadinessHandlerExplorers(t *testing.T) {
+	serving := NewCollector("aws", newFakeExplorer())
+	broken := NewCollector("gcp", &fakeReadinessExplorer{fakeExplorer: newFakeExplorer(), readiness: NewReadiness("credentials")})
+	handler := NewReadinessHandler(NewCollectorGroup(serving, broken))
+
+	response := getReadiness(t, handler, http.StatusServiceUnavailable)
+	assert.Equal(t, []string{"aws", "gcp"}, response.Failing)
+
+	// a broken explorer does not make the exporter unready while another one is served
+	serving.Collect(t.Context())
+	response = getReadiness(t, handler, http.StatusOK)
+	assert.True(t, response.Ready)
+	assert.Equal(t, []string{"gcp"}, response.Failing)
+}
+
+func TestReadinessHandlerPending(t *testing.T) {
+	group := NewCollectorGroup()
+	handler := NewReadinessHandler(group)
+
+	group.SetPending("scw", fmt.Errorf("credentials expired"))
+	response := getReadiness(t, handler, http.StatusServiceUnavailable)
+	assert.Equal(t, []string{"scw"}, response.Failing)
+	assert.Equal(t, []ExplorerReadiness{{
+		Explorer: "scw",
+		Checks:   []ReadinessCheck{{Name: "init", Reason: "credentials expired"}},
+	}}, response.Explorers)
+	assert.Equal(t, []string{"scw"}, group.Failed())
+
+	collector := NewCollector("scw", newFakeExplorer())
+	collector.Collect(t.Context())
+	group.SetPending("scw", nil)
+	group.Replace(collector)
+	response = getReadiness(t, handler, http.StatusOK)
+	assert.Empty(t, response.Failing)
+	assert.Empty(t, group.Failed())
+}
+
+func TestHealthHandler(t *testing.T) {
+	rec := httptest.NewRecorder()
+	NewHealthHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+}
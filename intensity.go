@@ -0,0 +1,74 @@
+package cloudcarbonexporter
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"slices"
+	"time"
+)
+
+func init() {
+	RegisterMetricFamilies(
+		MetricFamily{
+			Name: "carbon_intensity_timestamp_seconds",
+			Help: "Time the grid carbon intensity applied to the impacts of the location was measured, 0 for yearly averages.",
+			Type: GaugeType,
+			Unit: "seconds",
+		},
+	)
+}
+
+// ErrIntensityNotFound is returned by intensity providers not knowing a location
+var ErrIntensityNotFound = errors.New("carbon intensity not found")
+
+// Intensity is the grid carbon intensity of a location at a time
+type Intensity struct {
+	// PerKWh is the carbon intensity in gCO2eq/kWh
+	PerKWh Emissions
+	// Source names where the intensity comes from, e.g. gcp-2023
+	Source string
+	// Timestamp is when the intensity was measured. It is zero for yearly averages.
+	Timestamp time.Time
+}
+
+// IntensityProvider returns the grid carbon intensity of a location at a time. It returns
+// ErrIntensityNotFound if it does not know the location.
+type IntensityProvider interface {
+	Intensity(ctx context.Context, location string, at time.Time) (Intensity, error)
+}
+
+// intensityMetrics returns the time the grid carbon intensity applied to the impacts was
+// measured, by location and intensity source. The latest measure is kept if the impacts of
+// a location were collected across several measures.
+func intensityMetrics(impacts []*Impact) []*Metric {
+	type key struct{ location, source string }
+	measured := make(map[key]time.Time)
+	for _, impact := range impacts {
+		location, source := impact.Labels["location"], impact.Inputs.CarbonIntensitySource
+		if location == "" || source == "" {
+			continue
+		}
+		k := key{location: location, source: source}
+		if timestamp, found := measured[k]; !found || impact.Inputs.CarbonIntensityTimestamp.After(timestamp) {
+			measured[k] = impact.Inputs.CarbonIntensityTimestamp
+		}
+	}
+
+	metrics := make([]*Metric, 0, len(measured))
+	for k, timestamp := range measured {
+		value := 0.0
+		if !timestamp.IsZero() {
+			value = float64(timestamp.Unix())
+		}
+		metrics = append(metrics, &Metric{
+			Name:   "carbon_intensity_timestamp_seconds",
+			Labels: map[string]string{"location": k.location, "source": k.source},
+			Value:  value,
+		})
+	}
+	slices.SortFunc(metrics, func(a, b *Metric) int {
+		return cmp.Or(cmp.Compare(a.Labels["location"], b.Labels["location"]), cmp.Compare(a.Labels["source"], b.Labels["source"]))
+	})
+	return metrics
+}
@@ -26,6 +26,8 @@ SELECT
 	physical_processor,
 	vcpu,
 	memory,
+	gpu,
+	if(gpu_memory is null, 0, gpu_memory) as gpu_memory,
 	if(ssd_count == '', 0, cast(ssd_count as double)) as ssd_count, 
 	if(hdd_count == '', 0, cast(hdd_count as double)) as hdd_count,
 	if(ssd_size == '', 0, cast(ssd_size as double)) as ssd_size, 
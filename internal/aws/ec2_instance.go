@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log/slog"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -49,6 +50,28 @@ type instanceTypeInfos struct {
 	HDDSize           float64 `json:"hdd_size"`
 }
 
+// gpuModels maps the accelerated computing instance families to the model of their GPUs
+var gpuModels = map[string]string{
+	"g2":   "NVIDIA K520",
+	"g3":   "NVIDIA M60",
+	"g3s":  "NVIDIA M60",
+	"g4ad": "AMD Radeon Pro V520",
+	"g4dn": "NVIDIA T4",
+	"g5":   "NVIDIA A10G",
+	"g5g":  "NVIDIA T4G",
+	"g6":   "NVIDIA L4",
+	"g6e":  "NVIDIA L40S",
+	"gr6":  "NVIDIA L4",
+	"p2":   "NVIDIA K80",
+	"p3":   "NVIDIA V100",
+	"p3dn": "NVIDIA V100",
+	"p4d":  "NVIDIA A100 40GB",
+	"p4de": "NVIDIA A100 80GB",
+	"p5":   "NVIDIA H100",
+	"p5e":  "NVIDIA H200",
+	"p5en": "NVIDIA H200",
+}
+
 type EC2InstanceExplorer struct {
 	*Explorer
 }
@@ -103,7 +126,7 @@ func (ec2explorer *EC2InstanceExplorer) collectImpacts(ctx cloudcarbonexporter.C
 	})
 
 	for paginator.HasMorePages() {
-		ctx.IncrCalls()
+		ctx.IncrCalls("service/ec2:DescribeInstances")
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
 			return &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to list region ec2 instances: %w", err), Operation: "service/ec2:DescribeInstances"}
@@ -121,32 +144,46 @@ func (ec2explorer *EC2InstanceExplorer) collectImpacts(ctx cloudcarbonexporter.C
 				}
 
 				processor := primitives.LookupProcessorByName(instanceType.PhysicalProcessor)
-				energy := processor.EstimateCPUEnergy(instanceType.VCPU, intanceAverageCPU)
-				energy += primitives.EstimateMemoryEnergy(instanceType.Memory)
-				cpuEmbodied := primitives.EstimateCPUEmbodiedEmissions(instanceType.VCPU)
-				memoryEmbodied := primitives.EstimateMemoryEmbodiedEmissions(instanceType.Memory)
 
-				diskEmbodied := cloudcarbonexporter.ZeroEmissions
+				impact := &cloudcarbonexporter.Impact{
+					Inputs: cloudcarbonexporter.ImpactInputs{
+						InstanceType: string(instance.InstanceType),
+						VCPU:         instanceType.VCPU,
+						MemoryGB:     instanceType.Memory,
+						Processor:    processor.Inputs(),
+						CPUAverage:   intanceAverageCPU,
+					},
+					Labels: map[string]string{
+						"location":    region,
+						"az":          *instance.Placement.AvailabilityZone,
+						"kind":        "ec2/instance",
+						"instance_id": *instance.InstanceId,
+					},
+					Tags: parseEC2Tags(instance.Tags),
+				}
+
+				impact.AddComponent(cloudcarbonexporter.ComponentCPU,
+					processor.EstimateCPUEnergy(instanceType.VCPU, intanceAverageCPU),
+					primitives.EstimateCPUEmbodiedEmissions(instanceType.VCPU))
+				impact.AddComponent(cloudcarbonexporter.ComponentMemory,
+					primitives.EstimateMemoryEnergy(instanceType.Memory),
+					primitives.EstimateMemoryEmbodiedEmissions(instanceType.Memory))
 				if instanceType.SSDCount > 0 {
-					diskEmbodied = cloudcarbonexporter.CombineEmissionsOverTime(diskEmbodied, primitives.EstimateEmbodiedSSDEmissions(instanceType.SSDSize))
+					impact.AddComponent(cloudcarbonexporter.ComponentLocalDisk, 0, primitives.EstimateEmbodiedSSDEmissions(instanceType.SSDSize))
 				}
 				if instanceType.HDDCount > 0 {
-					diskEmbodied = cloudcarbonexporter.CombineEmissionsOverTime(diskEmbodied, primitives.EstimateEmbodiedHDDEmissions(instanceType.HDDCount))
+					impact.AddComponent(cloudcarbonexporter.ComponentLocalDisk, 0, primitives.EstimateEmbodiedHDDEmissions(instanceType.HDDCount))
 				}
-
-				impacts <- &cloudcarbonexporter.Impact{
-					Energy:            energy,
-					EmbodiedEmissions: cloudcarbonexporter.CombineEmissionsOverTime(cpuEmbodied, memoryEmbodied, diskEmbodied),
-					Labels: cloudcarbonexporter.MergeLabels(
-						parseEC2Tags(instance.Tags),
-						map[string]string{
-							"location":    region,
-							"az":          *instance.Placement.AvailabilityZone,
-							"kind":        "ec2/instance",
-							"instance_id": *instance.InstanceId,
-						},
-					),
+				if instanceType.GPU > 0 {
+					family, _, _ := strings.Cut(string(instance.InstanceType), ".")
+					gpu := primitives.LookupGPUByName(gpuModels[family])
+					impact.Inputs.GPU = gpu.Inputs(instanceType.GPU, primitives.GPUAverageUsage)
+					impact.AddComponent(cloudcarbonexporter.ComponentGPU,
+						gpu.EstimateGPUEnergy(instanceType.GPU, primitives.GPUAverageUsage),
+						primitives.EstimateGPUEmbodiedEmissions(instanceType.GPU))
 				}
+
+				impacts <- impact
 			}
 		}
 	}
@@ -159,7 +196,7 @@ func (ec2explorer *EC2InstanceExplorer) GetInstanceCPUAverage(ctx cloudcarbonexp
 
 	ec2explorer.cache.SetDynamicIfNotExists(ctx, key, func(ctx context.Context) (any, error) {
 		return ec2explorer.ListInstanceCPUAverage(cloudcarbonexporter.WrapCtx(ctx), region)
-	}, 5*time.Minute)
+	}, ec2explorer.cacheTTL)
 
 	entry, err := ec2explorer.cache.Get(ctx, key)
 	if err != nil {
@@ -202,7 +239,7 @@ func (ec2explorer *EC2InstanceExplorer) ListInstanceCPUAverage(ctx cloudcarbonex
 	})
 
 	for paginator.HasMorePages() {
-		ctx.IncrCalls()
+		ctx.IncrCalls("cloudwatch:GetMetricData")
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, &cloudcarbonexporter.ExplorerErr{
@@ -224,7 +261,7 @@ func parseEC2Tags(tags []types.Tag) map[string]string {
 	m := make(map[string]string, len(tags))
 
 	for _, t := range tags {
-		m[fmt.Sprintf("tag_%s", *t.Key)] = *t.Value
+		m[*t.Key] = *t.Value
 	}
 
 	return m
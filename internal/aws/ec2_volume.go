@@ -53,7 +53,7 @@ func (ec2explorer *EC2VolumeExplorer) collectImpacts(ctx cloudcarbonexporter.Con
 	})
 
 	for paginator.HasMorePages() {
-		ctx.IncrCalls()
+		ctx.IncrCalls("service/ec2:DescribeVolumes")
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
 			return &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to list region ec2 volumes: %w", err), Operation: "service/ec2:DescribeVolumes"}
@@ -71,20 +71,19 @@ func (ec2explorer *EC2VolumeExplorer) collectImpacts(ctx cloudcarbonexporter.Con
 				embodiedEmission = cloud.EstimateSSDBlockStorageEmbodiedEmissions(float64(*volume.Size))
 			}
 
-			impacts <- &cloudcarbonexporter.Impact{
-				Energy:            energy,
-				EmbodiedEmissions: embodiedEmission,
-				Labels: cloudcarbonexporter.MergeLabels(
-					map[string]string{
-						"location":    region,
-						"az":          *volume.AvailabilityZone,
-						"kind":        "ec2/volume",
-						"volume_id":   *volume.VolumeId,
-						"volume_type": string(volume.VolumeType),
-					},
-					parseEC2Tags(volume.Tags),
-				),
+			impact := &cloudcarbonexporter.Impact{
+				Labels: map[string]string{
+					"location":    region,
+					"az":          *volume.AvailabilityZone,
+					"kind":        "ec2/volume",
+					"volume_id":   *volume.VolumeId,
+					"volume_type": string(volume.VolumeType),
+				},
+				Tags: parseEC2Tags(volume.Tags),
 			}
+			impact.AddComponent(cloudcarbonexporter.ComponentStorage, energy, embodiedEmission)
+
+			impacts <- impact
 		}
 	}
 
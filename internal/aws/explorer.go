@@ -10,6 +10,7 @@ import (
 	"log/slog"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
@@ -23,6 +24,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/costexplorer"
 	cetypes "github.com/aws/aws-sdk-go-v2/service/costexplorer/types"
 	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
 	"github.com/aws/aws-sdk-go-v2/service/sts"
 )
 
@@ -44,7 +46,43 @@ type Explorer struct {
 	activeServices     map[string][]string // serviceName: [region1, region2, ...]
 	subExplorers       map[string][]subExplorer
 	carbonIntensityMap carbon.IntensityMap
+	wue                carbon.WaterMap
+	gridWaterIntensity carbon.WaterMap
+	locations          *atomic.Pointer[carbon.LocationModel]
 	instanceTypeInfos  map[string]instanceTypeInfos
+	readiness          *cloudcarbonexporter.Readiness
+
+	// regions and services restrict the exploration, everything is explored if empty
+	regions  []string
+	services []string
+	pue      primitives.PUEModel
+	cacheTTL time.Duration
+
+	// intensityOverrides and intensityProviders take precedence over the static carbon
+	// intensity map, in this order
+	intensityOverrides map[string]float64
+	intensityProviders []cloudcarbonexporter.IntensityProvider
+	carbonFreeEnergy   carbon.CarbonFreeEnergyMap
+
+	// accountID and accountName label the impacts of the explored account
+	accountID   string
+	accountName string
+
+	// organizationRole is the role template assumed in member accounts in organization mode
+	organizationRole         string
+	organizationsClient      organizations.ListAccountsAPIClient
+	organizationSyncInterval time.Duration
+	// accounts are the initialized member accounts explorers
+	accounts []*Explorer
+	// accountCancels stops the explorer or the initialization retries of the member
+	// accounts listed in the organization
+	accountCancels map[string]context.CancelFunc
+	// initAccountExplorer initializes the explorer of a member account
+	initAccountExplorer func(ctx context.Context, account *Explorer) error
+	// accountRetryMinBackoff and accountRetryMaxBackoff bound the delay between two
+	// initialization retries of a member account
+	accountRetryMinBackoff time.Duration
+	accountRetryMaxBackoff time.Duration
 }
 
 type ExplorerOption func(*Explorer)
@@ -67,13 +105,91 @@ func WithRoleArn(role string) ExplorerOption {
 	}
 }
 
+// WithRegions restricts the exploration to the regions. Global services are always explored.
+func WithRegions(regions ...string) ExplorerOption {
+	return func(e *Explorer) {
+		e.regions = regions
+	}
+}
+
+// WithServices restricts the exploration to the supported services, e.g. ec2/instance
+func WithServices(services ...string) ExplorerOption {
+	return func(e *Explorer) {
+		if len(services) == 0 {
+			return
+		}
+		e.services = services
+		for service, subExplorers := range e.subExplorers {
+			subExplorers = slices.DeleteFunc(subExplorers, func(subExplorer subExplorer) bool {
+				return !slices.Contains(services, subExplorer.support())
+			})
+			if len(subExplorers) == 0 {
+				delete(e.subExplorers, service)
+				continue
+			}
+			e.subExplorers[service] = subExplorers
+		}
+		e.readiness = e.newReadiness()
+	}
+}
+
+// WithPUEModel sets the power usage effectiveness of the datacenters by location and month
+func WithPUEModel(model primitives.PUEModel) ExplorerOption {
+	return func(e *Explorer) {
+		e.pue = model
+	}
+}
+
+// WithIntensityOverrides replaces the grid carbon intensity (gCO2eq/kWh) of the locations
+func WithIntensityOverrides(overrides map[string]float64) ExplorerOption {
+	return func(e *Explorer) {
+		e.intensityOverrides = overrides
+	}
+}
+
+// WithIntensityProviders sets the time-varying grid carbon intensity providers, tried in
+// order before the static carbon intensity map
+func WithIntensityProviders(providers ...cloudcarbonexporter.IntensityProvider) ExplorerOption {
+	return func(e *Explorer) {
+		e.intensityProviders = providers
+	}
+}
+
+// WithCarbonFreeEnergy sets the carbon-free energy share (0 to 1) of the locations used for
+// market-based emissions. Other locations have none.
+func WithCarbonFreeEnergy(cfe map[string]float64) ExplorerOption {
+	return func(e *Explorer) {
+		e.carbonFreeEnergy = carbon.CarbonFreeEnergyMap(nil).WithOverrides(cfe)
+	}
+}
+
+// WithCacheTTL sets how long monitoring data are kept in cache before being queried again
+func WithCacheTTL(ttl time.Duration) ExplorerOption {
+	return func(e *Explorer) {
+		e.cacheTTL = ttl
+	}
+}
+
 func NewExplorer() *Explorer {
 	explorer := &Explorer{
 		mu:                 new(sync.Mutex),
 		defaultRegion:      "us-east-1",
 		accountAZs:         make([]AvailabilityZone, 0),
 		carbonIntensityMap: carbon.NewAWSCloudCarbonFootprintIntensityMap(),
+		wue:                carbon.NewAWSWUEMap(),
+		gridWaterIntensity: carbon.NewGridWaterIntensityMap(),
 		instanceTypeInfos:  make(map[string]instanceTypeInfos),
+		pue:                primitives.NewPUEModel("aws"),
+		locations:          new(atomic.Pointer[carbon.LocationModel]),
+		cacheTTL:           5 * time.Minute,
+
+		organizationSyncInterval: time.Hour,
+		accountCancels:           make(map[string]context.CancelFunc),
+		initAccountExplorer: func(ctx context.Context, account *Explorer) error {
+			return account.Init(ctx)
+		},
+		accountRetryMinBackoff: accountRetryMinBackoff,
+		accountRetryMaxBackoff: accountRetryMaxBackoff,
 	}
 
 	explorer.subExplorers = map[string][]subExplorer{
@@ -89,9 +205,16 @@ func NewExplorer() *Explorer {
 		},
 	}
 
+	explorer.readiness = explorer.newReadiness()
+
 	return explorer
 }
 
+// newReadiness returns the readiness checks of the sub explorers and account discovery
+func (explorer *Explorer) newReadiness() *cloudcarbonexporter.Readiness {
+	return cloudcarbonexporter.NewReadiness(append(explorer.SupportedServices(), "credentials", "availability_zones", "active_services")...)
+}
+
 func (explorer *Explorer) SupportedServices() []string {
 	supportedServices := make([]string, 0)
 
@@ -120,9 +243,38 @@ func (explorer *Explorer) Configure(opts ...ExplorerOption) *Explorer {
 	return explorer
 }
 
+// newLocationModel returns the factors of the explored locations
+func (explorer *Explorer) newLocationModel() *carbon.LocationModel {
+	return &carbon.LocationModel{
+		PUE: explorer.pue,
+		Intensity: carbon.NewExplorerIntensityProvider(
+			carbon.NewStaticIntensityProvider("ccf-aws", explorer.carbonIntensityMap),
+			explorer.intensityOverrides,
+			explorer.intensityProviders...,
+		),
+		CarbonFreeEnergy:   explorer.carbonFreeEnergy,
+		WUE:                explorer.wue,
+		GridWaterIntensity: explorer.gridWaterIntensity,
+	}
+}
+
+// UpdateFactors replaces the pue, carbon intensity overrides and carbon-free energy of the
+// explorer and of its member accounts. Their clients and cache are kept.
+func (explorer *Explorer) UpdateFactors(settings *cloudcarbonexporter.ExplorerSettings) {
+	explorer.mu.Lock()
+	defer explorer.mu.Unlock()
+
+	explorer.Configure(factorsOptions(settings)...)
+	explorer.locations.Store(explorer.newLocationModel())
+	for _, account := range explorer.accounts {
+		account.UpdateFactors(settings)
+	}
+}
+
 // NewExplorer initialize and returns a new AWS Explorer.
 func (explorer *Explorer) Init(ctx context.Context) (err error) {
-	explorer.cache = cache.NewMemory(ctx, 5*time.Minute)
+	explorer.cache = cache.NewMemory(ctx, explorer.cacheTTL)
+	explorer.locations.Store(explorer.newLocationModel())
 
 	if explorer.roleArn != "" {
 		explorer.awscfg.Credentials = aws.NewCredentialsCache(
@@ -134,6 +286,11 @@ func (explorer *Explorer) Init(ctx context.Context) (err error) {
 		slog.Info("assuming aws role for resource services api calls", "role", explorer.roleArn)
 	}
 
+	if explorer.organizationRole != "" {
+		explorer.readiness = cloudcarbonexporter.NewReadiness("organization", "accounts")
+		return explorer.initOrganization(ctx)
+	}
+
 	errg, errgctx := errgroup.WithContext(ctx)
 	cctx := cloudcarbonexporter.WrapCtx(errgctx)
 
@@ -156,7 +313,9 @@ func (explorer *Explorer) loadSubExplorers(ctx context.Context) error {
 		for _, energyEstimator := range energyEstimators {
 			energyEstimator := energyEstimator
 			errg.Go(func() error {
-				if err := energyEstimator.load(errgctx); err != nil {
+				err := energyEstimator.load(errgctx)
+				explorer.readiness.Set(energyEstimator.support(), err)
+				if err != nil {
 					return fmt.Errorf("failed to load resources creator: %w", err)
 				}
 				return nil
@@ -168,7 +327,13 @@ func (explorer *Explorer) loadSubExplorers(ctx context.Context) error {
 
 // CollectImpacts discover resources
 func (explorer *Explorer) CollectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact, errs chan error) {
+	if explorer.organizationRole != "" {
+		explorer.collectOrganizationImpacts(ctx, impacts, errs)
+		return
+	}
+
 	rawImpacts := make(chan *cloudcarbonexporter.Impact)
+	collectedAt := time.Now()
 
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
@@ -181,8 +346,13 @@ func (explorer *Explorer) CollectImpacts(ctx cloudcarbonexporter.Context, impact
 				slog.Warn("impact location not found, skipping impact. please consider raising a bug.", "labels", rawImpact.Labels)
 				continue
 			}
-			rawImpact.Energy = rawImpact.Energy * primitives.GoodPUE
-			rawImpact.EnergyEmissions = explorer.carbonIntensityMap.EnergyEmissions(rawImpact.Energy, location)
+			factors, err := explorer.locations.Load().Factors(ctx, location, collectedAt)
+			if err != nil {
+				slog.Warn("carbon intensity not found, skipping impact", "location", location, "err", err.Error())
+				continue
+			}
+			rawImpact.Labels = cloudcarbonexporter.MergeLabels(rawImpact.Labels, explorer.accountLabels())
+			rawImpact.ApplyLocationFactors(factors)
 			impacts <- rawImpact
 		}
 	}()
@@ -199,13 +369,18 @@ func (explorer *Explorer) collectImpacts(ctx cloudcarbonexporter.Context, impact
 	wg := new(sync.WaitGroup)
 	for service, regions := range explorer.activeServices {
 		for _, region := range regions {
+			if !explorer.exploresRegion(region) {
+				continue
+			}
 			for _, subExplorer := range explorer.subExplorers[service] {
 				region := region
 				collector := subExplorer
 				wg.Add(1)
 				go func() {
 					defer wg.Done()
-					errs <- collector.collectImpacts(ctx, region, impacts)
+					errs <- cloudcarbonexporter.ObserveSubExplorer(ctx, collector.support(), region, impacts, func(impacts chan *cloudcarbonexporter.Impact) error {
+						return collector.collectImpacts(ctx, region, impacts)
+					})
 				}()
 			}
 		}
@@ -214,14 +389,64 @@ func (explorer *Explorer) collectImpacts(ctx cloudcarbonexporter.Context, impact
 	wg.Wait()
 }
 
+// exploresRegion returns true if the region is not filtered out by the configured regions
+func (explorer *Explorer) exploresRegion(region string) bool {
+	return len(explorer.regions) == 0 || region == "global" || slices.Contains(explorer.regions, region)
+}
+
+// CacheStats returns the lookups and refreshes counts of the explorer cache, including the
+// caches of the organization accounts
+func (explorer *Explorer) CacheStats() cloudcarbonexporter.CacheStats {
+	stats := cloudcarbonexporter.CacheStats{}
+	if explorer.cache != nil {
+		stats = explorer.cache.Stats()
+	}
+
+	explorer.mu.Lock()
+	accounts := explorer.accounts
+	explorer.mu.Unlock()
+
+	for _, account := range accounts {
+		accountStats := account.CacheStats()
+		stats.Hits += accountStats.Hits
+		stats.Misses += accountStats.Misses
+		stats.Refreshes += accountStats.Refreshes
+		stats.RefreshErrors += accountStats.RefreshErrors
+	}
+
+	return stats
+}
+
+// accountLabels returns the labels of the explored account
+func (explorer *Explorer) accountLabels() map[string]string {
+	explorer.mu.Lock()
+	defer explorer.mu.Unlock()
+
+	return map[string]string{
+		"account_id":   explorer.accountID,
+		"account_name": explorer.accountName,
+	}
+}
+
 // Close do nothing else but implementing the Explorer interface
 func (explorer *Explorer) Close() error { return nil }
 
 // IsReady returns true if explorer can effectively return resources
 func (explorer *Explorer) IsReady() bool {
+	if explorer.organizationRole != "" {
+		return explorer.readiness.IsReady()
+	}
+
+	explorer.mu.Lock()
 	availabilityZonesAreLoaded := len(explorer.accountAZs) > 0
+	explorer.mu.Unlock()
 
-	return availabilityZonesAreLoaded
+	return availabilityZonesAreLoaded && explorer.readiness.IsReady()
+}
+
+// ReadinessChecks returns the state of the credentials, account discovery and sub explorers
+func (explorer *Explorer) ReadinessChecks() []cloudcarbonexporter.ReadinessCheck {
+	return explorer.readiness.Checks()
 }
 
 // AvailabilityZone represents an AWS Availability Zone with its name and
@@ -269,7 +494,7 @@ func (explorer *Explorer) getAWSAccountID(ctx cloudcarbonexporter.Context, awscf
 		o.Region = explorer.defaultRegion
 	})
 
-	ctx.IncrCalls()
+	ctx.IncrCalls("service/sts:GetCallerIdentity")
 	output, err := stsapi.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
 	if err != nil {
 		return "", fmt.Errorf("failed to get caller identity: %w", err)
@@ -290,11 +515,13 @@ func (explorer *Explorer) discoverActiveServicesAndRegions(ctx cloudcarbonexport
 	}()
 
 	accountID, err := explorer.getAWSAccountID(ctx, explorer.awscfg)
+	explorer.readiness.Set("credentials", err)
 	if err != nil {
 		return fmt.Errorf("failed to retreive target account id: %w", err)
 	}
 
 	err = explorer.refreshAccountAvailibilityZones(ctx)
+	explorer.readiness.Set("availability_zones", err)
 	if err != nil {
 		return fmt.Errorf("failed to update list of aws availability zones: %w", err)
 	}
@@ -303,7 +530,7 @@ func (explorer *Explorer) discoverActiveServicesAndRegions(ctx cloudcarbonexport
 		o.Region = explorer.defaultRegion
 	})
 
-	ctx.IncrCalls()
+	ctx.IncrCalls("service/ce:GetCostAndUsage")
 	output, err := costs.GetCostAndUsage(ctx, &costexplorer.GetCostAndUsageInput{
 		TimePeriod: &cetypes.DateInterval{
 			Start: aws.String(time.Now().Add(-7 * DAY).Format(time.DateOnly)),
@@ -329,6 +556,7 @@ func (explorer *Explorer) discoverActiveServicesAndRegions(ctx cloudcarbonexport
 		},
 	})
 	if err != nil {
+		explorer.readiness.Set("active_services", err)
 		return fmt.Errorf("failed to get cost and usage for aws account: %w", err)
 	}
 
@@ -349,6 +577,8 @@ func (explorer *Explorer) discoverActiveServicesAndRegions(ctx cloudcarbonexport
 	defer explorer.mu.Unlock()
 
 	explorer.activeServices = services
+	explorer.accountID = accountID
+	explorer.readiness.Set("active_services", nil)
 
 	for service, locations := range services {
 		slog.Debug("discovered service", "service", service, "locations", locations)
@@ -367,7 +597,7 @@ func (explorer *Explorer) refreshAccountAvailibilityZones(ctx cloudcarbonexporte
 		o.Region = explorer.defaultRegion
 	})
 
-	ctx.IncrCalls()
+	ctx.IncrCalls("service/ec2:DescribeRegions")
 	regions, err := ec2api.DescribeRegions(ctx, &ec2.DescribeRegionsInput{})
 	if err != nil {
 		return &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to describe account regions: %w", err), Operation: "service/ec2:DescribeRegions"}
@@ -386,7 +616,7 @@ func (explorer *Explorer) refreshAccountAvailibilityZones(ctx cloudcarbonexporte
 				o.Region = region
 			})
 
-			ctx.IncrCalls()
+			ctx.IncrCalls("service/ec2:DescribeAvailabilityZones")
 			zones, err := ec2api.DescribeAvailabilityZones(errgctx, &ec2.DescribeAvailabilityZonesInput{})
 			if err != nil {
 				return fmt.Errorf("failed to describe account availability zones: %w", err)
@@ -408,6 +638,10 @@ func (explorer *Explorer) refreshAccountAvailibilityZones(ctx cloudcarbonexporte
 		return err
 	}
 
+	if len(azs) == 0 {
+		return fmt.Errorf("no availability zone found in account regions")
+	}
+
 	explorer.mu.Lock()
 	defer explorer.mu.Unlock()
 	explorer.accountAZs = azs
@@ -0,0 +1,66 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/replay"
+)
+
+func TestCollectImpactsReplay(t *testing.T) {
+	player, err := replay.Load("testdata/eu-west-3.jsonl")
+	assert.NoError(t, err)
+
+	explorer, err := newProviderExplorer(t.Context(), &cloudcarbonexporter.ExplorerSettings{
+		Regions:   []string{"eu-west-3"},
+		Services:  []string{"ec2/instance", "ec2/volume"},
+		Transport: player,
+		Offline:   true,
+	}, &Config{DefaultRegion: "eu-west-3"})
+	assert.NoError(t, err)
+	assert.NoError(t, explorer.Init(t.Context()))
+	assert.True(t, explorer.IsReady())
+
+	// collecting twice replays the same exchanges
+	for range 2 {
+		impacts := make(chan *cloudcarbonexporter.Impact)
+		errs := make(chan error)
+		go func() {
+			defer close(impacts)
+			defer close(errs)
+			explorer.CollectImpacts(cloudcarbonexporter.WrapCtx(t.Context()), impacts, errs)
+		}()
+
+		go func() {
+			for err := range errs {
+				assert.NoError(t, err)
+			}
+		}()
+
+		cpuAverages := make(map[string]float64)
+		volumes := make([]string, 0)
+		gpus := make(map[string]*cloudcarbonexporter.GPUInputs)
+		for impact := range impacts {
+			assert.Equal(t, "eu-west-3", impact.Labels["location"])
+			assert.Equal(t, "123456789012", impact.Labels["account_id"])
+			assert.Equal(t, "ccf-aws", impact.Inputs.CarbonIntensitySource)
+			assert.Greater(t, float64(impact.Energy), 0.0)
+			switch impact.Labels["kind"] {
+			case "ec2/instance":
+				cpuAverages[impact.Labels["instance_id"]] = impact.Inputs.CPUAverage
+				if impact.Inputs.GPU != nil {
+					assert.Contains(t, impact.Components, cloudcarbonexporter.ComponentGPU)
+					gpus[impact.Labels["instance_id"]] = impact.Inputs.GPU
+				}
+			case "ec2/volume":
+				volumes = append(volumes, impact.Labels["volume_id"])
+			}
+		}
+		assert.Equal(t, map[string]float64{"i-0000000000000001": 42.5, "i-0000000000000002": 12.5, "i-0000000000000003": 80}, cpuAverages, "stopped instances are skipped")
+		assert.ElementsMatch(t, []string{"vol-0000000000000001", "vol-0000000000000002"}, volumes)
+		assert.Equal(t, map[string]*cloudcarbonexporter.GPUInputs{
+			"i-0000000000000003": {Name: "NVIDIA T4", TDP: 70, Count: 1, Usage: 50},
+		}, gpus, "g4dn instance types have a T4 gpu")
+	}
+}
@@ -0,0 +1,319 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"golang.org/x/sync/errgroup"
+)
+
+// AccountPlaceholder is replaced by the member account id in organization role templates
+const AccountPlaceholder = "{account}"
+
+const (
+	// maxConcurrentAccountInits bounds the number of member accounts initialized at the same time
+	maxConcurrentAccountInits = 10
+	// accountRetryMinBackoff is the delay before the first initialization retry of a member account
+	accountRetryMinBackoff = 10 * time.Second
+	// accountRetryMaxBackoff caps the delay between two initialization retries of a member account
+	accountRetryMaxBackoff = 5 * time.Minute
+)
+
+// Account is a member account of an AWS Organization
+type Account struct {
+	ID   string
+	Name string
+}
+
+// WithOrganizationRole enables the organization mode: member accounts are listed from the
+// management (or delegated administrator) account and explored by assuming the role
+// template in each of them, e.g. arn:aws:iam::{account}:role/carbon-reader
+func WithOrganizationRole(roleTemplate string) ExplorerOption {
+	return func(e *Explorer) {
+		e.organizationRole = roleTemplate
+	}
+}
+
+// WithOrganizationsClient sets the client listing the organization accounts. It defaults
+// to an organizations client built from the explorer aws config.
+func WithOrganizationsClient(client organizations.ListAccountsAPIClient) ExplorerOption {
+	return func(e *Explorer) {
+		e.organizationsClient = client
+	}
+}
+
+// WithOrganizationSyncInterval sets the interval between two listings of the organization
+// accounts, so that new accounts are explored and removed ones are not anymore
+func WithOrganizationSyncInterval(interval time.Duration) ExplorerOption {
+	return func(e *Explorer) {
+		e.organizationSyncInterval = interval
+	}
+}
+
+// withAccount sets the member account explored
+func withAccount(account Account) ExplorerOption {
+	return func(e *Explorer) {
+		e.accountID = account.ID
+		e.accountName = account.Name
+	}
+}
+
+// AccountRoleArn returns the role to assume in the account from the role template
+func AccountRoleArn(roleTemplate string, accountID string) (string, error) {
+	if !strings.Contains(roleTemplate, AccountPlaceholder) {
+		return "", fmt.Errorf("organization role %q must contain the %s placeholder", roleTemplate, AccountPlaceholder)
+	}
+	return strings.ReplaceAll(roleTemplate, AccountPlaceholder, accountID), nil
+}
+
+// ListOrganizationAccounts returns the active accounts of the organization
+func ListOrganizationAccounts(ctx cloudcarbonexporter.Context, client organizations.ListAccountsAPIClient) ([]Account, error) {
+	accounts := make([]Account, 0)
+	paginator := organizations.NewListAccountsPaginator(client, &organizations.ListAccountsInput{})
+	for paginator.HasMorePages() {
+		ctx.IncrCalls("service/organizations:ListAccounts")
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to list organization accounts: %w", err), Operation: "service/organizations:ListAccounts"}
+		}
+
+		for _, account := range page.Accounts {
+			if account.Status != orgtypes.AccountStatusActive {
+				slog.Debug("skipping inactive organization account", "account", *account.Id, "status", account.Status)
+				continue
+			}
+			accounts = append(accounts, Account{ID: *account.Id, Name: *account.Name})
+		}
+	}
+
+	return accounts, nil
+}
+
+// newAccountExplorers returns an explorer per member account, configured to assume the
+// organization role in the account
+func (explorer *Explorer) newAccountExplorers(accounts []Account) ([]*Explorer, error) {
+	accountExplorers := make([]*Explorer, 0, len(accounts))
+	for _, account := range accounts {
+		roleArn, err := AccountRoleArn(explorer.organizationRole, account.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		accountExplorers = append(accountExplorers, NewExplorer().Configure(
+			WithAWSConfig(explorer.awscfg.Copy()),
+			WithDefaultRegion(explorer.defaultRegion),
+			WithRoleArn(roleArn),
+			WithRegions(explorer.regions...),
+			WithServices(explorer.services...),
+			WithPUEModel(explorer.pue),
+			WithCacheTTL(explorer.cacheTTL),
+			WithIntensityOverrides(explorer.intensityOverrides),
+			WithIntensityProviders(explorer.intensityProviders...),
+			WithCarbonFreeEnergy(explorer.carbonFreeEnergy),
+			withAccount(account),
+		))
+	}
+	return accountExplorers, nil
+}
+
+// initOrganization lists the organization accounts and initializes an explorer in each of
+// them. Accounts failing to initialize are retried in the background so they don't prevent
+// the others from being explored, and the accounts are listed again periodically to follow
+// the organization changes until the context is done.
+func (explorer *Explorer) initOrganization(ctx context.Context) error {
+	if explorer.organizationsClient == nil {
+		explorer.organizationsClient = organizations.NewFromConfig(explorer.awscfg, func(o *organizations.Options) {
+			o.Region = explorer.defaultRegion
+		})
+	}
+
+	if err := explorer.syncOrganization(ctx); err != nil {
+		return err
+	}
+	if err := explorer.accountsReadiness(); err != nil {
+		return err
+	}
+
+	go explorer.watchOrganization(ctx)
+	return nil
+}
+
+// watchOrganization lists the organization accounts at each sync interval until the context
+// is done
+func (explorer *Explorer) watchOrganization(ctx context.Context) {
+	ticker := time.NewTicker(explorer.organizationSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := explorer.syncOrganization(ctx); err != nil {
+			slog.Warn("failed to sync aws organization accounts", "err", err.Error())
+		}
+	}
+}
+
+// syncOrganization lists the organization accounts, stops exploring the accounts that left
+// the organization or are not active anymore and initializes the new ones
+func (explorer *Explorer) syncOrganization(ctx context.Context) error {
+	start := time.Now()
+	accounts, err := ListOrganizationAccounts(cloudcarbonexporter.WrapCtx(ctx), explorer.organizationsClient)
+	explorer.readiness.Set("organization", err)
+	if err != nil {
+		return err
+	}
+
+	explorer.mu.Lock()
+	listed := make(map[string]bool, len(accounts))
+	newAccounts := make([]Account, 0)
+	for _, account := range accounts {
+		listed[account.ID] = true
+		if _, known := explorer.accountCancels[account.ID]; !known {
+			newAccounts = append(newAccounts, account)
+		}
+	}
+	for id, cancel := range explorer.accountCancels {
+		if !listed[id] {
+			cancel()
+			delete(explorer.accountCancels, id)
+			explorer.readiness.Delete(accountCheck(id))
+			slog.Info("aws account left the organization", "account", id)
+		}
+	}
+	explorer.accounts = slices.DeleteFunc(slices.Clone(explorer.accounts), func(account *Explorer) bool {
+		return !listed[account.accountID]
+	})
+	accountCtxs := make(map[string]context.Context, len(newAccounts))
+	for _, account := range newAccounts {
+		accountCtx, cancel := context.WithCancel(ctx)
+		accountCtxs[account.ID] = accountCtx
+		explorer.accountCancels[account.ID] = cancel
+	}
+	explorer.mu.Unlock()
+
+	failed := new(atomic.Int64)
+	errg := new(errgroup.Group)
+	errg.SetLimit(maxConcurrentAccountInits)
+	for _, account := range newAccounts {
+		errg.Go(func() error {
+			err := explorer.initAccount(accountCtxs[account.ID], account)
+			if err != nil {
+				failed.Add(1)
+				explorer.readiness.Set(accountCheck(account.ID), err)
+				slog.Warn("failed to init aws account explorer, retrying in the background", "account", account.ID, "name", account.Name, "err", err.Error())
+				go explorer.retryAccount(accountCtxs[account.ID], account)
+			}
+			return nil
+		})
+	}
+	_ = errg.Wait()
+
+	slog.Info("aws organization accounts synced", "accounts", len(accounts), "new", len(newAccounts), "failed", failed.Load(), "duration_ms", time.Since(start).Milliseconds())
+	return explorer.accountsReadiness()
+}
+
+// initAccount initializes an explorer in the member account and explores it along with the
+// others, unless the account left the organization in the meantime
+func (explorer *Explorer) initAccount(ctx context.Context, account Account) error {
+	explorer.mu.Lock()
+	accountExplorers, err := explorer.newAccountExplorers([]Account{account})
+	explorer.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	// a failed initialization leaves nothing running behind
+	initCtx, cancel := context.WithCancel(ctx)
+	if err := explorer.initAccountExplorer(initCtx, accountExplorers[0]); err != nil {
+		cancel()
+		return err
+	}
+
+	explorer.mu.Lock()
+	defer explorer.mu.Unlock()
+	if ctx.Err() != nil {
+		cancel()
+		return nil
+	}
+	// the account explorer runs until the account leaves the organization
+	context.AfterFunc(ctx, cancel)
+	explorer.accounts = append(slices.Clone(explorer.accounts), accountExplorers[0])
+	explorer.readiness.Delete(accountCheck(account.ID))
+	return nil
+}
+
+// retryAccount initializes the member account again, doubling the delay between attempts,
+// until it succeeds or the context is done
+func (explorer *Explorer) retryAccount(ctx context.Context, account Account) {
+	backoff := explorer.accountRetryMinBackoff
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		err := explorer.initAccount(ctx, account)
+		if err == nil {
+			slog.Info("aws account explorer initialized", "account", account.ID, "name", account.Name)
+			_ = explorer.accountsReadiness()
+			return
+		}
+
+		backoff = min(2*backoff, explorer.accountRetryMaxBackoff)
+		explorer.readiness.Set(accountCheck(account.ID), err)
+		slog.Warn("failed to init aws account explorer", "account", account.ID, "name", account.Name, "retry_in", backoff, "err", err.Error())
+	}
+}
+
+// accountsReadiness sets the accounts check, which fails if the organization has accounts
+// and none of them could be explored
+func (explorer *Explorer) accountsReadiness() error {
+	explorer.mu.Lock()
+	defer explorer.mu.Unlock()
+
+	var err error
+	if len(explorer.accounts) == 0 && len(explorer.accountCancels) > 0 {
+		failed := slices.Sorted(maps.Keys(explorer.accountCancels))
+		err = fmt.Errorf("no organization account could be explored, failed accounts: %s", strings.Join(failed, ", "))
+	}
+	explorer.readiness.Set("accounts", err)
+	return err
+}
+
+// accountCheck returns the name of the readiness check of a member account failing to
+// initialize
+func accountCheck(accountID string) string {
+	return "account/" + accountID
+}
+
+// collectOrganizationImpacts collects the impacts of all member accounts concurrently
+func (explorer *Explorer) collectOrganizationImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact, errs chan error) {
+	explorer.mu.Lock()
+	accounts := explorer.accounts
+	explorer.mu.Unlock()
+
+	wg := new(sync.WaitGroup)
+	for _, accountExplorer := range accounts {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			accountExplorer.CollectImpacts(ctx, impacts, errs)
+		}()
+	}
+	wg.Wait()
+}
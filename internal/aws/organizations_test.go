@@ -0,0 +1,152 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/organizations"
+	orgtypes "github.com/aws/aws-sdk-go-v2/service/organizations/types"
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+)
+
+// fakeOrganizations returns one page of accounts per call
+type fakeOrganizations struct {
+	pages [][]orgtypes.Account
+	err   error
+	mu    sync.Mutex
+}
+
+func (fake *fakeOrganizations) ListAccounts(ctx context.Context, input *organizations.ListAccountsInput, optFns ...func(*organizations.Options)) (*organizations.ListAccountsOutput, error) {
+	fake.mu.Lock()
+	defer fake.mu.Unlock()
+	if fake.err != nil {
+		return nil, fake.err
+	}
+
+	page := 0
+	if input.NextToken != nil {
+		fmt.Sscan(*input.NextToken, &page)
+	}
+
+	output := &organizations.ListAccountsOutput{Accounts: fake.pages[page]}
+	if page+1 < len(fake.pages) {
+		output.NextToken = aws.String(fmt.Sprint(page + 1))
+	}
+	return output, nil
+}
+
+func TestListOrganizationAccounts(t *testing.T) {
+	client := &fakeOrganizations{pages: [][]orgtypes.Account{
+		{
+			{Id: aws.String("111111111111"), Name: aws.String("management"), Status: orgtypes.AccountStatusActive},
+			{Id: aws.String("222222222222"), Name: aws.String("closed"), Status: orgtypes.AccountStatusSuspended},
+		},
+		{
+			{Id: aws.String("333333333333"), Name: aws.String("data-prod"), Status: orgtypes.AccountStatusActive},
+		},
+	}}
+
+	ctx := cloudcarbonexporter.WrapCtx(t.Context())
+	accounts, err := ListOrganizationAccounts(ctx, client)
+	assert.NoError(t, err)
+	assert.Equal(t, []Account{{ID: "111111111111", Name: "management"}, {ID: "333333333333", Name: "data-prod"}}, accounts)
+	assert.Equal(t, map[string]int{"service/organizations:ListAccounts": 2}, ctx.Stats().Calls())
+
+	_, err = ListOrganizationAccounts(ctx, &fakeOrganizations{err: fmt.Errorf("access denied")})
+	experr := new(cloudcarbonexporter.ExplorerErr)
+	assert.ErrorAs(t, err, &experr)
+	assert.Equal(t, "service/organizations:ListAccounts", experr.Operation)
+}
+
+func TestAccountExplorers(t *testing.T) {
+	_, err := AccountRoleArn("arn:aws:iam::123456789012:role/carbon-reader", "333333333333")
+	assert.Error(t, err)
+
+	explorer := NewExplorer().Configure(
+		WithDefaultRegion("eu-west-3"),
+		WithOrganizationRole("arn:aws:iam::{account}:role/carbon-reader"),
+	)
+
+	accountExplorers, err := explorer.newAccountExplorers([]Account{{ID: "333333333333", Name: "data-prod"}})
+	assert.NoError(t, err)
+	assert.Len(t, accountExplorers, 1)
+	assert.Equal(t, "arn:aws:iam::333333333333:role/carbon-reader", accountExplorers[0].roleArn)
+	assert.Equal(t, "eu-west-3", accountExplorers[0].defaultRegion)
+	assert.Empty(t, accountExplorers[0].organizationRole)
+	assert.Equal(t, map[string]string{"account_id": "333333333333", "account_name": "data-prod"}, accountExplorers[0].accountLabels())
+}
+
+// exploredAccounts returns the ids of the initialized member accounts
+func exploredAccounts(explorer *Explorer) []string {
+	explorer.mu.Lock()
+	defer explorer.mu.Unlock()
+
+	ids := make([]string, 0, len(explorer.accounts))
+	for _, account := range explorer.accounts {
+		ids = append(ids, account.accountID)
+	}
+	return ids
+}
+
+func TestOrganizationSync(t *testing.T) {
+	client := &fakeOrganizations{pages: [][]orgtypes.Account{{
+		{Id: aws.String("111111111111"), Name: aws.String("management"), Status: orgtypes.AccountStatusActive},
+		{Id: aws.String("222222222222"), Name: aws.String("data-prod"), Status: orgtypes.AccountStatusActive},
+	}}}
+	explorer := NewExplorer().Configure(
+		WithOrganizationRole("arn:aws:iam::{account}:role/carbon-reader"),
+		WithOrganizationsClient(client),
+	)
+	explorer.readiness = cloudcarbonexporter.NewReadiness("organization", "accounts")
+	explorer.accountRetryMinBackoff, explorer.accountRetryMaxBackoff = time.Millisecond, time.Millisecond
+
+	mu := new(sync.Mutex)
+	failures := map[string]int{"222222222222": 3}
+	explorer.initAccountExplorer = func(ctx context.Context, account *Explorer) error {
+		mu.Lock()
+		defer mu.Unlock()
+		if failures[account.accountID] > 0 {
+			failures[account.accountID]--
+			return fmt.Errorf("access denied")
+		}
+		return nil
+	}
+
+	assert.NoError(t, explorer.initOrganization(t.Context()))
+	assert.Equal(t, []string{"111111111111"}, exploredAccounts(explorer))
+	assert.Contains(t, explorer.ReadinessChecks(), cloudcarbonexporter.ReadinessCheck{Name: "account/222222222222", Reason: "access denied"})
+	assert.False(t, explorer.IsReady(), "failed accounts are reported")
+
+	assert.Eventually(t, func() bool { return len(exploredAccounts(explorer)) == 2 }, time.Second, time.Millisecond, "failed accounts are retried")
+	assert.True(t, explorer.IsReady())
+
+	client.mu.Lock()
+	client.pages = [][]orgtypes.Account{{
+		{Id: aws.String("222222222222"), Name: aws.String("data-prod"), Status: orgtypes.AccountStatusActive},
+		{Id: aws.String("333333333333"), Name: aws.String("data-dev"), Status: orgtypes.AccountStatusActive},
+	}}
+	client.mu.Unlock()
+
+	assert.NoError(t, explorer.syncOrganization(t.Context()))
+	assert.Equal(t, []string{"222222222222", "333333333333"}, exploredAccounts(explorer), "removed accounts are not explored anymore, new ones are")
+}
+
+func TestOrganizationSyncNoAccount(t *testing.T) {
+	explorer := NewExplorer().Configure(
+		WithOrganizationRole("arn:aws:iam::{account}:role/carbon-reader"),
+		WithOrganizationsClient(&fakeOrganizations{pages: [][]orgtypes.Account{{
+			{Id: aws.String("111111111111"), Name: aws.String("management"), Status: orgtypes.AccountStatusActive},
+		}}}),
+	)
+	explorer.readiness = cloudcarbonexporter.NewReadiness("organization", "accounts")
+	explorer.initAccountExplorer = func(ctx context.Context, account *Explorer) error { return fmt.Errorf("access denied") }
+
+	ctx, cancel := context.WithCancel(t.Context())
+	defer cancel()
+	assert.EqualError(t, explorer.initOrganization(ctx), "no organization account could be explored, failed accounts: 111111111111")
+}
@@ -0,0 +1,104 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
+)
+
+func init() {
+	cloudcarbonexporter.RegisterExplorerProvider(cloudcarbonexporter.ExplorerProvider{
+		Name:              "aws",
+		SupportedServices: slices.Sorted(slices.Values(NewExplorer().SupportedServices())),
+		Locations:         slices.Sorted(maps.Keys(NewExplorer().carbonIntensityMap)),
+		NewConfig:         func() cloudcarbonexporter.ProviderConfig { return new(Config) },
+		New:               newProviderExplorer,
+	})
+}
+
+// Config is the aws section of an explorer configuration
+type Config struct {
+	// RoleArn is the role assumed to explore the account
+	RoleArn       string `yaml:"role_arn"`
+	DefaultRegion string `yaml:"default_region"`
+	// OrganizationRole is the role template assumed in each organization member account,
+	// e.g. arn:aws:iam::{account}:role/carbon-reader. A single account is explored if empty.
+	OrganizationRole string `yaml:"organization_role"`
+}
+
+// Validate implements the cloudcarbonexporter.ProviderConfig interface
+func (config *Config) Validate(settings *cloudcarbonexporter.ExplorerSettings) []*cloudcarbonexporter.FieldError {
+	errs := make([]*cloudcarbonexporter.FieldError, 0)
+	if config.OrganizationRole != "" {
+		if _, err := AccountRoleArn(config.OrganizationRole, ""); err != nil {
+			errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"aws", "organization_role"}, Msg: err.Error()})
+		}
+	}
+	if settings.Credentials.AccessKeyEnv != "" || settings.Credentials.SecretKeyEnv != "" {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"credentials"}, Msg: "access and secret key environment variables are not supported by aws explorers"})
+	}
+	return errs
+}
+
+// newProviderExplorer creates an explorer from the shared settings and aws configuration.
+// Credentials are loaded from the profile or shared credentials file if set, from the
+// default chain otherwise. Offline explorers use placeholder credentials.
+func newProviderExplorer(ctx context.Context, settings *cloudcarbonexporter.ExplorerSettings, providerConfig cloudcarbonexporter.ProviderConfig) (cloudcarbonexporter.Explorer, error) {
+	config := providerConfig.(*Config)
+
+	loadOpts := make([]func(*awsconfig.LoadOptions) error, 0)
+	switch {
+	case settings.Offline:
+		loadOpts = append(loadOpts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider("OFFLINE", "OFFLINE", "")))
+	default:
+		if profile := settings.Credentials.Profile; profile != "" {
+			loadOpts = append(loadOpts, awsconfig.WithSharedConfigProfile(profile))
+		}
+		if file := settings.Credentials.File; file != "" {
+			loadOpts = append(loadOpts, awsconfig.WithSharedCredentialsFiles([]string{file}))
+		}
+	}
+	awscfg, err := awsconfig.LoadDefaultConfig(ctx, loadOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load aws config: %w", err)
+	}
+	if settings.Transport != nil {
+		awscfg.HTTPClient = &http.Client{Transport: settings.Transport}
+	}
+
+	opts := []ExplorerOption{
+		WithAWSConfig(awscfg),
+		WithRoleArn(config.RoleArn),
+		WithRegions(settings.Regions...),
+		WithServices(settings.Services...),
+		WithIntensityProviders(settings.IntensityProviders...),
+	}
+	opts = append(opts, factorsOptions(settings)...)
+	if config.DefaultRegion != "" {
+		opts = append(opts, WithDefaultRegion(config.DefaultRegion))
+	}
+	if config.OrganizationRole != "" {
+		opts = append(opts, WithOrganizationRole(config.OrganizationRole))
+	}
+	if settings.CacheTTL != 0 {
+		opts = append(opts, WithCacheTTL(settings.CacheTTL))
+	}
+
+	return NewExplorer().Configure(opts...), nil
+}
+
+// factorsOptions returns the options setting the emission factors of the explorer
+func factorsOptions(settings *cloudcarbonexporter.ExplorerSettings) []ExplorerOption {
+	return []ExplorerOption{
+		WithIntensityOverrides(settings.Intensity),
+		WithCarbonFreeEnergy(settings.CarbonFreeEnergy),
+		WithPUEModel(primitives.NewPUEModel("aws").WithOverrides(settings.PUE, settings.LocationPUE, settings.MonthlyPUE)),
+	}
+}
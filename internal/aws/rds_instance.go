@@ -60,27 +60,35 @@ func (rdsExplorer *RDSInstanceExplorer) collectImpacts(ctx cloudcarbonexporter.C
 	})
 
 	for paginator.HasMorePages() {
-		ctx.IncrCalls()
+		ctx.IncrCalls("service/rds:DescribeDBInstances")
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
 			return &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to list region rds instance: %w", err), Operation: "service/rds:DescribeDBInstances"}
 		}
 
 		for _, instance := range output.DBInstances {
-			var energy cloudcarbonexporter.Energy
-			var embodiedEmission cloudcarbonexporter.EmissionsOverTime
-
 			instanceID := *instance.DBInstanceIdentifier
 			instanceType := strings.TrimPrefix(*instance.DBInstanceClass, "db.")
 
+			impact := &cloudcarbonexporter.Impact{
+				Inputs: cloudcarbonexporter.ImpactInputs{InstanceType: *instance.DBInstanceClass},
+				Labels: map[string]string{
+					"kind":        "rds/db_instance",
+					"location":    rdsExplorer.Region(*instance.AvailabilityZone),
+					"az":          *instance.AvailabilityZone,
+					"instance_id": *instance.DBInstanceIdentifier,
+				},
+				Tags: parseRDSTagList(instance.TagList),
+			}
+
 			switch instanceType {
 			case "serverless":
-				energy, embodiedEmission, err = rdsExplorer.serverlessInstanceImpacts(ctx, region, instance)
+				err = rdsExplorer.serverlessInstanceImpacts(ctx, region, instance, impact)
 				if err != nil {
 					return fmt.Errorf("failed to get energy for serverless rds instance '%s': %w", instanceID, err)
 				}
 			default:
-				energy, embodiedEmission, err = rdsExplorer.classicInstanceToEnergy(ctx, region, instance, instanceType)
+				err = rdsExplorer.classicInstanceToEnergy(ctx, region, instance, instanceType, impact)
 				if err != nil {
 					return fmt.Errorf("failed to get energy for classic rds instance '%s': %w", instanceID, err)
 				}
@@ -92,73 +100,74 @@ func (rdsExplorer *RDSInstanceExplorer) collectImpacts(ctx cloudcarbonexporter.C
 				storageEnergy = cloud.EstimateHDDBlockStorageEnergy(float64(*instance.AllocatedStorage))
 				storageEmbodied = cloud.EstimateHDDBlockStorageEmbodiedEmissions(float64(*instance.AllocatedStorage))
 			}
-			energy += storageEnergy
-
-			impacts <- &cloudcarbonexporter.Impact{
-				Energy:            cloudcarbonexporter.Energy(energy),
-				EmbodiedEmissions: cloudcarbonexporter.CombineEmissionsOverTime(embodiedEmission, storageEmbodied),
-				Labels: cloudcarbonexporter.MergeLabels(
-					map[string]string{
-						"kind":        "rds/db_instance",
-						"location":    rdsExplorer.Region(*instance.AvailabilityZone),
-						"az":          *instance.AvailabilityZone,
-						"instance_id": *instance.DBInstanceIdentifier,
-					},
-					parseRDSTagList(instance.TagList),
-				),
-			}
+			impact.AddComponent(cloudcarbonexporter.ComponentStorage, storageEnergy, storageEmbodied)
+
+			impacts <- impact
 		}
 	}
 
 	return nil
 }
 
-// classicInstanceToEnergy estimates energy for classic instance using machine type and CPU usage
-func (rdsExplorer *RDSInstanceExplorer) classicInstanceToEnergy(ctx cloudcarbonexporter.Context, region string, instance types.DBInstance, instanceType string) (cloudcarbonexporter.Energy, cloudcarbonexporter.EmissionsOverTime, error) {
-
+// classicInstanceToEnergy estimates cpu and memory impacts for classic instance using machine type
+// and CPU usage. Components and model inputs are recorded in the impact.
+func (rdsExplorer *RDSInstanceExplorer) classicInstanceToEnergy(ctx cloudcarbonexporter.Context, region string, instance types.DBInstance, instanceType string, impact *cloudcarbonexporter.Impact) error {
 	instanceInfos, found := rdsExplorer.instanceTypeInfos[instanceType]
 	if !found {
-		return 0.0, cloudcarbonexporter.ZeroEmissions, fmt.Errorf("rds instance infos not found for type: %s", instanceType)
+		return fmt.Errorf("rds instance infos not found for type: %s", instanceType)
 	}
 	cpuAverage, err := rdsExplorer.GetInstanceCPUAverage(ctx, region, *instance.DBInstanceIdentifier)
 	if err != nil {
-		return 0.0, cloudcarbonexporter.ZeroEmissions, fmt.Errorf("failed to get rds instance cpu average: %w", err)
+		return fmt.Errorf("failed to get rds instance cpu average: %w", err)
 	}
-	energy := primitives.EstimateMemoryEnergy(instanceInfos.Memory)
-	energy += primitives.
-		LookupProcessorByName(instanceInfos.PhysicalProcessor).
-		EstimateCPUEnergy(instanceInfos.VCPU, cpuAverage)
+	processor := primitives.LookupProcessorByName(instanceInfos.PhysicalProcessor)
+
+	impact.AddComponent(cloudcarbonexporter.ComponentCPU,
+		processor.EstimateCPUEnergy(instanceInfos.VCPU, cpuAverage),
+		primitives.EstimateCPUEmbodiedEmissions(instanceInfos.VCPU))
+	impact.AddComponent(cloudcarbonexporter.ComponentMemory,
+		primitives.EstimateMemoryEnergy(instanceInfos.Memory),
+		primitives.EstimateMemoryEmbodiedEmissions(instanceInfos.Memory))
 
-	cpuEmbodied := primitives.EstimateCPUEmbodiedEmissions(instanceInfos.VCPU)
-	memoryEmbodied := primitives.EstimateMemoryEmbodiedEmissions(instanceInfos.Memory)
+	impact.Inputs.VCPU = instanceInfos.VCPU
+	impact.Inputs.MemoryGB = instanceInfos.Memory
+	impact.Inputs.Processor = processor.Inputs()
+	impact.Inputs.CPUAverage = cpuAverage
 
-	return energy, cloudcarbonexporter.CombineEmissionsOverTime(cpuEmbodied, memoryEmbodied), err
+	return nil
 }
 
-// serverlessInstanceImpacts estimates energy and embodied emissions for serverless instance using ACUs
-func (rdsExplorer *RDSInstanceExplorer) serverlessInstanceImpacts(ctx cloudcarbonexporter.Context, region string, instance types.DBInstance) (cloudcarbonexporter.Energy, cloudcarbonexporter.EmissionsOverTime, error) {
-	energy := cloudcarbonexporter.Energy(0)
+// serverlessInstanceImpacts estimates cpu and memory impacts for serverless instance using ACUs.
+// Components and model inputs are recorded in the impact.
+func (rdsExplorer *RDSInstanceExplorer) serverlessInstanceImpacts(ctx cloudcarbonexporter.Context, region string, instance types.DBInstance, impact *cloudcarbonexporter.Impact) error {
 	acuAverage, err := rdsExplorer.GetInstanceACUAverage(ctx, region, *instance.DBInstanceIdentifier)
 	if err != nil {
-		return 0.0, cloudcarbonexporter.ZeroEmissions, fmt.Errorf("failed to get rds instance cpu average: %w", err)
+		return fmt.Errorf("failed to get rds instance cpu average: %w", err)
 	}
 
 	noACU := acuAverage == 0.0
 	if noACU {
-		return 0.0, cloudcarbonexporter.ZeroEmissions, nil
+		return nil
 	}
 
 	cpuThreadsByACU := 0.5
 	memoryByACU := 2.0
 	threads := acuAverage * cpuThreadsByACU
+	processor := primitives.LookupProcessorByName("Graviton4")
 
-	energy += primitives.EstimateMemoryEnergy(acuAverage * memoryByACU)
-	energy += primitives.LookupProcessorByName("Graviton4").EstimateCPUEnergy(threads, 60)
+	impact.AddComponent(cloudcarbonexporter.ComponentCPU,
+		processor.EstimateCPUEnergy(threads, 60),
+		primitives.EstimateCPUEmbodiedEmissions(threads))
+	impact.AddComponent(cloudcarbonexporter.ComponentMemory,
+		primitives.EstimateMemoryEnergy(acuAverage*memoryByACU),
+		primitives.EstimateMemoryEmbodiedEmissions(acuAverage*memoryByACU))
 
-	cpuEmbodied := primitives.EstimateCPUEmbodiedEmissions(threads)
-	memoryEmbodied := primitives.EstimateMemoryEmbodiedEmissions(acuAverage * memoryByACU)
+	impact.Inputs.VCPU = threads
+	impact.Inputs.MemoryGB = acuAverage * memoryByACU
+	impact.Inputs.Processor = processor.Inputs()
+	impact.Inputs.CPUAverage = 60
 
-	return energy, cloudcarbonexporter.CombineEmissionsOverTime(cpuEmbodied, memoryEmbodied), err
+	return nil
 }
 
 func (rc *RDSInstanceExplorer) load(ctx context.Context) error { return nil }
@@ -168,7 +177,7 @@ func (rdsExplorer *RDSInstanceExplorer) GetInstanceCPUAverage(ctx cloudcarbonexp
 
 	rdsExplorer.cache.SetDynamicIfNotExists(ctx, key, func(ctx context.Context) (any, error) {
 		return rdsExplorer.ListInstanceCPUAverage(cloudcarbonexporter.WrapCtx(ctx), region)
-	}, 5*time.Minute)
+	}, rdsExplorer.cacheTTL)
 
 	entry, err := rdsExplorer.cache.Get(ctx, key)
 	if err != nil {
@@ -211,7 +220,7 @@ func (ec2explorer *RDSInstanceExplorer) ListInstanceCPUAverage(ctx cloudcarbonex
 	})
 
 	for paginator.HasMorePages() {
-		ctx.IncrCalls()
+		ctx.IncrCalls("cloudwatch:GetMetricData")
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, &cloudcarbonexporter.ExplorerErr{
@@ -234,7 +243,7 @@ func (rdsExplorer *RDSInstanceExplorer) GetInstanceACUAverage(ctx cloudcarbonexp
 
 	rdsExplorer.cache.SetDynamicIfNotExists(ctx, key, func(ctx context.Context) (any, error) {
 		return rdsExplorer.ListInstanceACUAverage(cloudcarbonexporter.WrapCtx(ctx), region)
-	}, 5*time.Minute)
+	}, rdsExplorer.cacheTTL)
 
 	entry, err := rdsExplorer.cache.Get(ctx, key)
 	if err != nil {
@@ -277,7 +286,7 @@ func (ec2explorer *RDSInstanceExplorer) ListInstanceACUAverage(ctx cloudcarbonex
 	})
 
 	for paginator.HasMorePages() {
-		ctx.IncrCalls()
+		ctx.IncrCalls("cloudwatch:GetMetricData")
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
 			return nil, &cloudcarbonexporter.ExplorerErr{
@@ -297,9 +306,9 @@ func (ec2explorer *RDSInstanceExplorer) ListInstanceACUAverage(ctx cloudcarbonex
 }
 
 func parseRDSTagList(list []types.Tag) map[string]string {
-	labels := make(map[string]string)
+	tags := make(map[string]string, len(list))
 	for _, t := range list {
-		labels[*t.Key] = "tag_" + *t.Value
+		tags[*t.Key] = *t.Value
 	}
-	return labels
+	return tags
 }
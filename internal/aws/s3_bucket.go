@@ -69,7 +69,7 @@ func (s3explorer *S3BucketsExplorer) collectImpacts(ctx cloudcarbonexporter.Cont
 
 	for paginator.HasMorePages() {
 
-		ctx.IncrCalls()
+		ctx.IncrCalls("service/s3:ListBucket")
 		output, err := paginator.NextPage(ctx)
 		if err != nil {
 			return &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to list buckets: %w", err), Operation: "service/s3:ListBucket"}
@@ -79,7 +79,7 @@ func (s3explorer *S3BucketsExplorer) collectImpacts(ctx cloudcarbonexporter.Cont
 			bucket := bucket
 			errg.Go(func() error {
 				var apiErr smithy.APIError
-				ctx.IncrCalls()
+				ctx.IncrCalls("service/s3:GetBucketTagging")
 				s3api := s3.NewFromConfig(s3explorer.awscfg, func(o *s3.Options) {
 					o.Region = *bucket.BucketRegion
 				})
@@ -104,18 +104,19 @@ func (s3explorer *S3BucketsExplorer) collectImpacts(ctx cloudcarbonexporter.Cont
 
 				slog.Debug("bucket size", "bucket", *bucket.Name, "size_gb", sizeGB)
 
-				impacts <- &cloudcarbonexporter.Impact{
-					Energy:            cloud.EstimateObjectStorageEnergy(sizeGB),
-					EmbodiedEmissions: cloud.EstimateObjectStorageEmbodiedEmissions(sizeGB),
-					Labels: cloudcarbonexporter.MergeLabels(
-						map[string]string{
-							"kind":        "s3/bucket",
-							"location":    s3explorer.Region(*bucket.BucketRegion),
-							"bucket_name": *bucket.Name,
-						},
-						parseS3TagList(tagsOutput.TagSet),
-					),
+				impact := &cloudcarbonexporter.Impact{
+					Labels: map[string]string{
+						"kind":        "s3/bucket",
+						"location":    s3explorer.Region(*bucket.BucketRegion),
+						"bucket_name": *bucket.Name,
+					},
+					Tags: parseS3TagList(tagsOutput.TagSet),
 				}
+				impact.AddComponent(cloudcarbonexporter.ComponentStorage,
+					cloud.EstimateObjectStorageEnergy(sizeGB),
+					cloud.EstimateObjectStorageEmbodiedEmissions(sizeGB))
+
+				impacts <- impact
 
 				return nil
 			})
@@ -186,7 +187,7 @@ func (s3explorer *S3BucketsExplorer) bucketSizeBytes(ctx cloudcarbonexporter.Con
 
 	lastValue := 0.0
 	for paginator.HasMorePages() {
-		ctx.IncrCalls()
+		ctx.IncrCalls("cloudwatch:GetMetricData")
 		page, err := paginator.NextPage(ctx)
 		if err != nil {
 			return 0.0, &cloudcarbonexporter.ExplorerErr{
@@ -208,9 +209,9 @@ func (s3explorer *S3BucketsExplorer) bucketSizeBytes(ctx cloudcarbonexporter.Con
 }
 
 func parseS3TagList(list []types.Tag) map[string]string {
-	labels := make(map[string]string)
+	tags := make(map[string]string, len(list))
 	for _, t := range list {
-		labels[*t.Key] = "tag_" + *t.Value
+		tags[*t.Key] = *t.Value
 	}
-	return labels
+	return tags
 }
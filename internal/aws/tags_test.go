@@ -0,0 +1,19 @@
+package aws
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	rdstypes "github.com/aws/aws-sdk-go-v2/service/rds/types"
+	s3types "github.com/aws/aws-sdk-go-v2/service/s3/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTags(t *testing.T) {
+	expected := map[string]string{"team": "data"}
+
+	assert.Equal(t, expected, parseEC2Tags([]ec2types.Tag{{Key: aws.String("team"), Value: aws.String("data")}}))
+	assert.Equal(t, expected, parseRDSTagList([]rdstypes.Tag{{Key: aws.String("team"), Value: aws.String("data")}}))
+	assert.Equal(t, expected, parseS3TagList([]s3types.Tag{{Key: aws.String("team"), Value: aws.String("data")}}))
+}
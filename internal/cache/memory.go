@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/superdango/cloud-carbon-exporter/internal/must"
@@ -12,12 +13,16 @@ import (
 
 type DynamicValueFunc func(ctx context.Context) (any, error)
 
+// entry is a cached value. Its fields are guarded by its mutex, except the dynamic func and
+// the cache duration which never change.
 type entry struct {
 	mu            *sync.Mutex
 	expiresAt     time.Time
 	v             any
 	dynamicFunc   DynamicValueFunc
 	cacheDuration time.Duration
+	// loaded is true once a dynamic value has been refreshed successfully
+	loaded bool
 }
 
 func (e *entry) isExpired() bool {
@@ -34,22 +39,48 @@ func (e *entry) refresh(ctx context.Context) error {
 		return err
 	}
 	e.v = v
+	e.loaded = true
 	e.expiresAt = time.Now().Add(e.cacheDuration)
 	return nil
 }
 
+// Stats counts the lookups and refreshes of a cache since its creation
+type Stats struct {
+	Hits          int64
+	Misses        int64
+	Refreshes     int64
+	RefreshErrors int64
+}
+
 type Memory struct {
-	m          *sync.Map
-	defaultTTL time.Duration
+	m             *sync.Map
+	defaultTTL    time.Duration
+	hits          *atomic.Int64
+	misses        *atomic.Int64
+	refreshes     *atomic.Int64
+	refreshErrors *atomic.Int64
+	done          chan struct{}
 }
 
+// NewMemory returns a cache whose entries are expired and refreshed in the background
+// until the context is done
 func NewMemory(ctx context.Context, defaultTTL time.Duration) *Memory {
+	return newMemory(ctx, defaultTTL, time.Second)
+}
+
+// newMemory returns a cache whose entries are expired and refreshed at each interval
+func newMemory(ctx context.Context, defaultTTL time.Duration, interval time.Duration) *Memory {
 	cache := &Memory{
-		m:          new(sync.Map),
-		defaultTTL: defaultTTL,
+		m:             new(sync.Map),
+		defaultTTL:    defaultTTL,
+		hits:          new(atomic.Int64),
+		misses:        new(atomic.Int64),
+		refreshes:     new(atomic.Int64),
+		refreshErrors: new(atomic.Int64),
+		done:          make(chan struct{}),
 	}
 
-	go cache.expirerer(ctx)
+	go cache.expirerer(ctx, interval)
 
 	return cache
 }
@@ -122,52 +153,98 @@ func (m *Memory) SetDynamicIfNotExists(ctx context.Context, k string, fn Dynamic
 func (m *Memory) Get(ctx context.Context, k string) (v any, err error) {
 	v, found := m.m.Load(k)
 	if !found {
+		m.misses.Add(1)
 		return nil, ErrNotFound
 	}
 
 	entry, ok := v.(*entry)
 	must.Assert(ok, "loaded value is not an entry")
 
+	// avoid concurrent refreshes on the same entry
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
 	if entry.isExpired() && !entry.isDynamic() {
 		slog.Debug("cache expired", "key", k)
 		m.m.Delete(k)
+		m.misses.Add(1)
 		return nil, ErrNotFound
 	}
 
-	// avoid concurrent refreshes on the same entry
-	entry.mu.Lock()
-	defer entry.mu.Unlock()
 	start := time.Now()
 	if entry.isExpired() && entry.isDynamic() {
-		if err := entry.refresh(ctx); err != nil {
+		m.misses.Add(1)
+		if err := m.refresh(ctx, entry); err != nil {
 			return nil, err
 		}
 		slog.Debug("dynamic entry refreshed", "key", k, "duration_ms", time.Since(start))
+		return entry.v, nil
 	}
 
+	m.hits.Add(1)
 	return entry.v, nil
 }
 
+// refresh refreshes a dynamic entry and counts the refresh outcome
+func (m *Memory) refresh(ctx context.Context, entry *entry) error {
+	m.refreshes.Add(1)
+	if err := entry.refresh(ctx); err != nil {
+		m.refreshErrors.Add(1)
+		return err
+	}
+	return nil
+}
+
+// Stats returns the number of hits, misses and refreshes since the cache creation. A Get on
+// an expired dynamic entry counts as a miss followed by a refresh.
+func (m *Memory) Stats() Stats {
+	return Stats{
+		Hits:          m.hits.Load(),
+		Misses:        m.misses.Load(),
+		Refreshes:     m.refreshes.Load(),
+		RefreshErrors: m.refreshErrors.Load(),
+	}
+}
+
 func (m *Memory) Exists(ctx context.Context, k string) (bool, error) {
 	_, found := m.m.Load(k)
 	return found, nil
 }
 
-func (m *Memory) expirerer(ctx context.Context) {
+// Done returns a channel closed once the cache stopped expiring and refreshing its entries
+func (m *Memory) Done() <-chan struct{} {
+	return m.done
+}
+
+func (m *Memory) expirerer(ctx context.Context, interval time.Duration) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
 	for {
-		<-time.Tick(time.Second)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
 
 		m.m.Range(func(k, v any) bool {
 			entry, ok := v.(*entry)
 			must.Assert(ok, "loaded value is not an entry")
 
+			entry.mu.Lock()
+			defer entry.mu.Unlock()
+
 			if entry.isExpired() && !entry.isDynamic() {
 				slog.Debug("cache expired", "key", k)
 				m.m.Delete(k)
 			}
 
-			if entry.isExpired() && entry.isDynamic() {
-				if err := entry.refresh(ctx); err != nil {
+			// the previous value is served until the next expiration if the refresh fails.
+			// Entries that never loaded stay expired so the next Get loads them or fails.
+			if entry.isExpired() && entry.isDynamic() && entry.loaded {
+				if err := m.refresh(ctx, entry); err != nil {
 					slog.Warn("failed to refresh dynamic entry", "key", k, "err", err.Error())
 				}
 				entry.expiresAt = time.Now().Add(entry.cacheDuration)
@@ -176,5 +253,4 @@ func (m *Memory) expirerer(ctx context.Context) {
 			return true
 		})
 	}
-
 }
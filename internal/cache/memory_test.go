@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"sync"
 	"testing"
 	"time"
 
@@ -49,3 +50,90 @@ func TestMemory(t *testing.T) {
 	assert.Error(t, err)
 
 }
+
+func TestMemoryStats(t *testing.T) {
+	memory := NewMemory(t.Context(), time.Minute)
+
+	_, err := memory.Get(t.Context(), "missing")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	err = memory.SetDynamic(t.Context(), "d1", func(ctx context.Context) (any, error) {
+		return "v1", nil
+	})
+	assert.NoError(t, err)
+
+	// first get refreshes the dynamic entry, the second one is served from memory
+	for range 2 {
+		_, err = memory.Get(t.Context(), "d1")
+		assert.NoError(t, err)
+	}
+
+	err = memory.SetDynamic(t.Context(), "d2", func(ctx context.Context) (any, error) {
+		return nil, fmt.Errorf("expected error")
+	})
+	assert.NoError(t, err)
+	_, err = memory.Get(t.Context(), "d2")
+	assert.Error(t, err)
+
+	assert.Equal(t, Stats{Hits: 1, Misses: 3, Refreshes: 2, RefreshErrors: 1}, memory.Stats())
+}
+
+func TestMemoryStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(t.Context())
+	memory := NewMemory(ctx, time.Minute)
+
+	select {
+	case <-memory.Done():
+		t.Fatal("cache stopped before its context is done")
+	default:
+	}
+
+	cancel()
+	select {
+	case <-memory.Done():
+	case <-time.After(time.Second):
+		t.Fatal("cache did not stop once its context is done")
+	}
+}
+
+func TestMemoryConcurrentExpiration(t *testing.T) {
+	memory := newMemory(t.Context(), time.Millisecond, time.Millisecond)
+	err := memory.SetDynamic(t.Context(), "d1", func(ctx context.Context) (any, error) {
+		return "v1", nil
+	})
+	assert.NoError(t, err)
+
+	// gets refresh the entry while the expirer does, run with -race
+	wg := new(sync.WaitGroup)
+	deadline := time.Now().Add(50 * time.Millisecond)
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for time.Now().Before(deadline) {
+				v, err := memory.Get(t.Context(), "d1")
+				assert.NoError(t, err)
+				assert.Equal(t, "v1", v)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestMemoryExpirationWithoutValue(t *testing.T) {
+	memory := newMemory(t.Context(), time.Minute, time.Millisecond)
+	err := memory.SetDynamic(t.Context(), "d1", func(ctx context.Context) (any, error) {
+		return nil, fmt.Errorf("expected error")
+	})
+	assert.NoError(t, err)
+
+	_, err = memory.Get(t.Context(), "d1")
+	assert.Error(t, err)
+
+	// the expirer leaves the entry expired, so gets keep trying to load it
+	time.Sleep(10 * time.Millisecond)
+	v, err := memory.Get(t.Context(), "d1")
+	assert.Error(t, err)
+	assert.Nil(t, v)
+	assert.Equal(t, Stats{Misses: 2, Refreshes: 2, RefreshErrors: 2}, memory.Stats())
+}
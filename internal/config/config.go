@@ -0,0 +1,535 @@
+// Package config loads the exporter configuration file. Every setting of the file can be
+// overridden by a command line flag or an environment variable.
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/factors"
+	"gopkg.in/yaml.v3"
+)
+
+// Config is the exporter configuration
+type Config struct {
+	Listen       string        `yaml:"listen"`
+	Mode         string        `yaml:"mode"`
+	Log          Log           `yaml:"log"`
+	Collect      Collect       `yaml:"collect"`
+	Explorers    []Explorer    `yaml:"explorers"`
+	Labels       Labels        `yaml:"labels"`
+	Aggregations []Aggregation `yaml:"aggregations"`
+	Sinks        Sinks         `yaml:"sinks"`
+	Intensity    Intensity     `yaml:"intensity"`
+	Factors      Factors       `yaml:"factors"`
+
+	// root is the parsed document, used to locate errors in the file
+	root *yaml.Node
+}
+
+// Log configures the exporter logs
+type Log struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// Collect configures the background collections
+type Collect struct {
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// Explorer configures an explorer of a cloud provider account, project or organization
+type Explorer struct {
+	// Name is the explorer label of the impacts. Defaults to the provider.
+	Name     string `yaml:"name"`
+	Provider string `yaml:"provider"`
+	// Collect overrides the global collection interval and timeout
+	Collect Collect `yaml:"collect"`
+	// Regions restricts the exploration to these regions. All regions are explored if empty.
+	Regions []string `yaml:"regions"`
+	// Services restricts the exploration to these supported services. All supported
+	// services are explored if empty.
+	Services    []string    `yaml:"services"`
+	Credentials Credentials `yaml:"credentials"`
+	// PUE overrides the power usage effectiveness of the provider datacenters
+	PUE float64 `yaml:"pue"`
+	// Intensity overrides the grid carbon intensity (gCO2eq/kWh) by location prefix
+	Intensity map[string]float64 `yaml:"intensity"`
+	// CarbonFreeEnergy sets the share of carbon-free energy (0 to 1) matched by the provider
+	// energy purchases by location prefix, for market-based emissions
+	CarbonFreeEnergy map[string]float64 `yaml:"carbon_free_energy"`
+	Cache            Cache              `yaml:"cache"`
+	// Config is the configuration specific to the provider, decoded from the section named
+	// after it (e.g. aws:). Nil if the provider is not registered.
+	Config cloudcarbonexporter.ProviderConfig `yaml:"-"`
+
+	// sections lists the provider sections found in the file
+	sections []string
+}
+
+// DisplayName returns the explorer name, or its provider if not set
+func (explorer *Explorer) DisplayName() string {
+	if explorer.Name != "" {
+		return explorer.Name
+	}
+	return explorer.Provider
+}
+
+// Credentials references the credentials of an explorer. Secrets are never written in the
+// configuration file.
+type Credentials struct {
+	// Profile is the aws shared configuration profile
+	Profile string `yaml:"profile"`
+	// File is the aws shared credentials file or the gcp credentials file
+	File string `yaml:"file"`
+	// AccessKeyEnv and SecretKeyEnv name the environment variables holding the scaleway keys
+	AccessKeyEnv string `yaml:"access_key_env"`
+	SecretKeyEnv string `yaml:"secret_key_env"`
+}
+
+// Cache configures the explorer cache of cloud api responses
+type Cache struct {
+	// TTL is how long discovery and monitoring data are kept before being queried again
+	TTL time.Duration `yaml:"ttl"`
+}
+
+// Labels configures how cloud tags are exposed as labels
+type Labels struct {
+	TagPrefix string            `yaml:"tag_prefix"`
+	Allow     []string          `yaml:"allow"`
+	Deny      []string          `yaml:"deny"`
+	Rename    map[string]string `yaml:"rename"`
+}
+
+// Policy returns the label policy of the configuration
+func (labels *Labels) Policy() (policy *cloudcarbonexporter.LabelPolicy, err error) {
+	policy = cloudcarbonexporter.DefaultLabelPolicy()
+	policy.TagPrefix = labels.TagPrefix
+
+	if policy.Allow, err = cloudcarbonexporter.CompileTagPatterns(labels.Allow...); err != nil {
+		return nil, err
+	}
+	if policy.Deny, err = cloudcarbonexporter.CompileTagPatterns(labels.Deny...); err != nil {
+		return nil, err
+	}
+
+	renames := make([]string, 0, len(labels.Rename))
+	for from, to := range labels.Rename {
+		renames = append(renames, from+"="+to)
+	}
+	sort.Strings(renames)
+	if policy.Renames, err = cloudcarbonexporter.ParseRenames(renames...); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+// Aggregation configures an aggregation rule applied to impacts before they are exposed
+type Aggregation struct {
+	Kind string   `yaml:"kind"`
+	By   []string `yaml:"by"`
+	Top  int      `yaml:"top"`
+}
+
+// Rule returns the aggregation rule
+func (aggregation *Aggregation) Rule() cloudcarbonexporter.AggregationRule {
+	return cloudcarbonexporter.AggregationRule{
+		Kind: aggregation.Kind,
+		By:   aggregation.By,
+		TopN: aggregation.Top,
+	}
+}
+
+// AggregationRules returns the aggregation rules of the configuration
+func (cfg *Config) AggregationRules() []cloudcarbonexporter.AggregationRule {
+	rules := make([]cloudcarbonexporter.AggregationRule, 0, len(cfg.Aggregations))
+	for _, aggregation := range cfg.Aggregations {
+		rules = append(rules, aggregation.Rule())
+	}
+	return rules
+}
+
+// Sinks configures where metrics are pushed. A sink is disabled if its endpoint is empty.
+type Sinks struct {
+	OTLP        OTLP        `yaml:"otlp"`
+	RemoteWrite RemoteWrite `yaml:"remotewrite"`
+}
+
+// OTLP configures the otlp sink
+type OTLP struct {
+	Endpoint string            `yaml:"endpoint"`
+	Protocol string            `yaml:"protocol"`
+	Headers  map[string]string `yaml:"headers"`
+	Interval time.Duration     `yaml:"interval"`
+	Insecure bool              `yaml:"insecure"`
+}
+
+// RemoteWrite configures the prometheus remote write sink
+type RemoteWrite struct {
+	URL      string            `yaml:"url"`
+	Headers  map[string]string `yaml:"headers"`
+	Interval time.Duration     `yaml:"interval"`
+}
+
+// Intensity configures the live grid carbon intensity sources of all explorers. Explorers
+// use their static intensities for the locations these sources do not cover or when they
+// fail.
+type Intensity struct {
+	ElectricityMaps ElectricityMaps `yaml:"electricitymaps"`
+}
+
+// ElectricityMaps configures the Electricity Maps api
+type ElectricityMaps struct {
+	Enabled bool   `yaml:"enabled"`
+	URL     string `yaml:"url"`
+	// APIKeyEnv names the environment variable holding the api key
+	APIKeyEnv string `yaml:"api_key_env"`
+	// CacheTTL is how long the intensity of a zone is kept before being queried again
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+	// Zones maps location prefixes to zones, in addition to the built-in mapping of the
+	// provider regions
+	Zones map[string]string `yaml:"zones"`
+}
+
+// Factors configures the emission factor files overriding the built-in carbon intensity
+// and power usage effectiveness of the explorers. Files are read again on reload and the
+// last ones take precedence.
+type Factors struct {
+	Files []string `yaml:"files"`
+}
+
+// Default returns the configuration used when no file is given
+func Default() *Config {
+	return &Config{
+		Listen: "0.0.0.0:2922",
+		Mode:   "serve",
+		Log: Log{
+			Level:  "info",
+			Format: "text",
+		},
+		Collect: Collect{
+			Interval: time.Minute,
+			Timeout:  3 * time.Minute,
+		},
+		Explorers: make([]Explorer, 0),
+		Labels: Labels{
+			TagPrefix: "tag_",
+		},
+		Aggregations: make([]Aggregation, 0),
+		Sinks: Sinks{
+			OTLP: OTLP{
+				Protocol: "grpc",
+				Interval: time.Minute,
+			},
+			RemoteWrite: RemoteWrite{
+				Interval: time.Minute,
+			},
+		},
+		Intensity: Intensity{
+			ElectricityMaps: ElectricityMaps{
+				URL:       "https://api.electricitymap.org",
+				APIKeyEnv: "ELECTRICITYMAPS_API_KEY",
+				CacheTTL:  15 * time.Minute,
+			},
+		},
+	}
+}
+
+// Load reads and parses the configuration file
+func Load(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read configuration file: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse parses a yaml configuration over the default configuration. Unknown fields and
+// invalid values are reported with their line in an Errors.
+func Parse(data []byte) (*Config, error) {
+	cfg := Default()
+
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, decodeErrors(err)
+	}
+
+	cfg.root = new(yaml.Node)
+	if err := yaml.Unmarshal(data, cfg.root); err != nil {
+		return nil, decodeErrors(err)
+	}
+
+	return cfg, nil
+}
+
+// Error is a configuration error. Line is zero if the setting does not come from the file.
+type Error struct {
+	Line int
+	Path string
+	Msg  string
+}
+
+func (err *Error) Error() string {
+	msg := err.Msg
+	if err.Path != "" {
+		msg = err.Path + ": " + msg
+	}
+	if err.Line > 0 {
+		return fmt.Sprintf("line %d: %s", err.Line, msg)
+	}
+	return msg
+}
+
+// Errors lists the errors of a configuration
+type Errors []*Error
+
+func (errs Errors) Error() string {
+	msgs := make([]string, 0, len(errs))
+	for _, err := range errs {
+		msgs = append(msgs, err.Error())
+	}
+	return strings.Join(msgs, "\n")
+}
+
+var yamlErrLine = regexp.MustCompile(`^(?:yaml: )?line (\d+): (.*)$`)
+
+// decodeErrors converts yaml errors into Errors
+func decodeErrors(err error) Errors {
+	msgs := []string{err.Error()}
+	typeErr := new(yaml.TypeError)
+	if errors.As(err, &typeErr) {
+		msgs = typeErr.Errors
+	}
+
+	errs := make(Errors, 0, len(msgs))
+	for _, msg := range msgs {
+		matches := yamlErrLine.FindStringSubmatch(msg)
+		if matches == nil {
+			errs = append(errs, &Error{Msg: strings.TrimPrefix(msg, "yaml: ")})
+			continue
+		}
+		line, _ := strconv.Atoi(matches[1])
+		errs = append(errs, &Error{Line: line, Msg: matches[2]})
+	}
+	return errs
+}
+
+// Validate checks the configuration against the registered explorer providers. All errors
+// are returned at once in an Errors sorted by line.
+func (cfg *Config) Validate() error {
+	errs := make(Errors, 0)
+	report := func(msg string, path ...any) {
+		errs = append(errs, &Error{Line: cfg.line(path...), Path: formatPath(path...), Msg: msg})
+	}
+
+	if cfg.Mode != "serve" && cfg.Mode != "push" {
+		report(fmt.Sprintf("run mode %q is not supported (serve, push)", cfg.Mode), "mode")
+	}
+	if !slices.Contains([]string{"debug", "info", "warn", "error"}, cfg.Log.Level) {
+		report(fmt.Sprintf("log level %q is not supported (debug, info, warn, error)", cfg.Log.Level), "log", "level")
+	}
+	if cfg.Log.Format != "text" && cfg.Log.Format != "json" {
+		report(fmt.Sprintf("log format %q is not supported (text, json)", cfg.Log.Format), "log", "format")
+	}
+	if cfg.Collect.Interval <= 0 {
+		report("must be positive", "collect", "interval")
+	}
+	if cfg.Collect.Timeout <= 0 {
+		report("must be positive", "collect", "timeout")
+	}
+
+	if len(cfg.Explorers) == 0 {
+		report("at least one explorer must be configured", "explorers")
+	}
+	names := make(map[string]bool, len(cfg.Explorers))
+	for i, explorer := range cfg.Explorers {
+		cfg.validateExplorer(&explorer, func(msg string, path ...any) {
+			report(msg, append([]any{"explorers", i}, path...)...)
+		})
+
+		if names[explorer.DisplayName()] {
+			report(fmt.Sprintf("name %s is already used, set a unique name", explorer.DisplayName()), "explorers", i, "name")
+		}
+		names[explorer.DisplayName()] = true
+	}
+
+	if _, err := cfg.Labels.Policy(); err != nil {
+		report(err.Error(), "labels")
+	}
+
+	for i, aggregation := range cfg.Aggregations {
+		if len(aggregation.By) == 0 {
+			report("must set at least one label", "aggregations", i, "by")
+		}
+		if aggregation.Top < 0 {
+			report("must be a positive integer", "aggregations", i, "top")
+		}
+	}
+
+	if otlp := cfg.Sinks.OTLP; otlp.Endpoint != "" {
+		if otlp.Protocol != "grpc" && otlp.Protocol != "http/protobuf" {
+			report(fmt.Sprintf("otlp protocol %q is not supported (grpc, http/protobuf)", otlp.Protocol), "sinks", "otlp", "protocol")
+		}
+		if otlp.Interval <= 0 {
+			report("must be positive", "sinks", "otlp", "interval")
+		}
+	}
+	if cfg.Sinks.RemoteWrite.URL != "" && cfg.Sinks.RemoteWrite.Interval <= 0 {
+		report("must be positive", "sinks", "remotewrite", "interval")
+	}
+
+	if electricityMaps := cfg.Intensity.ElectricityMaps; electricityMaps.Enabled {
+		if u, err := url.Parse(electricityMaps.URL); err != nil || u.Scheme == "" || u.Host == "" {
+			report(fmt.Sprintf("url %q is not valid", electricityMaps.URL), "intensity", "electricitymaps", "url")
+		}
+		if electricityMaps.APIKeyEnv == "" {
+			report("must name the environment variable holding the api key", "intensity", "electricitymaps", "api_key_env")
+		}
+		if electricityMaps.CacheTTL <= 0 {
+			report("must be positive", "intensity", "electricitymaps", "cache_ttl")
+		}
+		for location, zone := range electricityMaps.Zones {
+			if zone == "" {
+				report(fmt.Sprintf("zone of %s is empty", location), "intensity", "electricitymaps", "zones", location)
+			}
+		}
+	}
+
+	for i, file := range cfg.Factors.Files {
+		if _, err := factors.Load(file); err != nil {
+			report(err.Error(), "factors", "files", i)
+		}
+	}
+
+	if len(errs) == 0 {
+		return nil
+	}
+	slices.SortStableFunc(errs, func(a, b *Error) int { return a.Line - b.Line })
+	return errs
+}
+
+func (cfg *Config) validateExplorer(explorer *Explorer, report func(msg string, path ...any)) {
+	provider, found := cloudcarbonexporter.LookupExplorerProvider(explorer.Provider)
+	if explorer.Provider == "" {
+		report("cloud provider is not set", "provider")
+		return
+	}
+	if !found {
+		report(fmt.Sprintf("cloud provider %s is not supported", explorer.Provider), "provider")
+		return
+	}
+
+	for i, service := range explorer.Services {
+		if !slices.Contains(provider.SupportedServices, service) {
+			report(fmt.Sprintf("service %s is not supported by %s", service, explorer.Provider), "services", i)
+		}
+	}
+	if explorer.Collect.Interval < 0 {
+		report("must be positive", "collect", "interval")
+	}
+	if explorer.Collect.Timeout < 0 {
+		report("must be positive", "collect", "timeout")
+	}
+	if explorer.Cache.TTL < 0 {
+		report("must be positive", "cache", "ttl")
+	}
+	if explorer.PUE != 0 && explorer.PUE < 1 {
+		report("must be greater than or equal to 1", "pue")
+	}
+	for location, intensity := range explorer.Intensity {
+		if intensity <= 0 {
+			report(fmt.Sprintf("intensity of %s must be positive", location), "intensity", location)
+		}
+	}
+	for location, cfe := range explorer.CarbonFreeEnergy {
+		if cfe < 0 || cfe > 1 {
+			report(fmt.Sprintf("carbon-free energy of %s must be between 0 and 1", location), "carbon_free_energy", location)
+		}
+	}
+
+	for _, section := range explorer.sections {
+		if section != explorer.Provider {
+			report(fmt.Sprintf("is not supported by %s explorers", explorer.Provider), section)
+		}
+	}
+
+	providerConfig := explorer.Config
+	if providerConfig == nil {
+		providerConfig = provider.NewConfig()
+	}
+	for _, err := range providerConfig.Validate(explorer.Settings()) {
+		path := make([]any, 0, len(err.Path))
+		for _, elem := range err.Path {
+			path = append(path, elem)
+		}
+		report(err.Msg, path...)
+	}
+}
+
+// line returns the line of the setting at path, or of its closest parent found in the
+// file. It returns zero if the setting is not in the file.
+func (cfg *Config) line(path ...any) int {
+	if cfg.root == nil || len(cfg.root.Content) == 0 {
+		return 0
+	}
+
+	node := cfg.root.Content[0]
+	line := 0
+	for _, elem := range path {
+		switch elem := elem.(type) {
+		case string:
+			if node.Kind != yaml.MappingNode {
+				return line
+			}
+			found := false
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				if node.Content[i].Value == elem {
+					line, node, found = node.Content[i].Line, node.Content[i+1], true
+					break
+				}
+			}
+			if !found {
+				return line
+			}
+		case int:
+			if node.Kind != yaml.SequenceNode || elem >= len(node.Content) {
+				return line
+			}
+			node = node.Content[elem]
+			line = node.Line
+		}
+	}
+
+	return line
+}
+
+// formatPath formats a setting path, e.g. explorers[0].collect.timeout
+func formatPath(path ...any) string {
+	formatted := ""
+	for _, elem := range path {
+		switch elem := elem.(type) {
+		case int:
+			formatted += fmt.Sprintf("[%d]", elem)
+		default:
+			if formatted != "" {
+				formatted += "."
+			}
+			formatted += fmt.Sprint(elem)
+		}
+	}
+	return formatted
+}
@@ -0,0 +1,196 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/superdango/cloud-carbon-exporter/internal/aws"
+	"github.com/superdango/cloud-carbon-exporter/internal/gcp"
+
+	_ "github.com/superdango/cloud-carbon-exporter/internal/scw"
+)
+
+func TestParse(t *testing.T) {
+	cfg, err := Parse([]byte(`
+listen: 127.0.0.1:9000
+collect:
+  timeout: 5m
+explorers:
+  - name: aws-prod
+    provider: aws
+    regions: [eu-west-1]
+    services: [ec2/instance]
+    credentials:
+      profile: prod
+    aws:
+      organization_role: arn:aws:iam::{account}:role/carbon-reader
+    pue: 1.2
+    intensity:
+      eu-west-1: 50
+    carbon_free_energy:
+      eu-west-1: 0.9
+    cache:
+      ttl: 10m
+labels:
+  allow: [team]
+  rename:
+    tag_team: owner
+aggregations:
+  - kind: ec2/instance
+    by: [location]
+    top: 3
+sinks:
+  remotewrite:
+    url: http://localhost:9090/api/v1/write
+intensity:
+  electricitymaps:
+    enabled: true
+    zones:
+      on-prem: FR
+`))
+	assert.NoError(t, err)
+	assert.NoError(t, cfg.Validate())
+
+	assert.Equal(t, "127.0.0.1:9000", cfg.Listen)
+	assert.Equal(t, "serve", cfg.Mode, "default must be kept")
+	assert.Equal(t, time.Minute, cfg.Collect.Interval, "default must be kept")
+	assert.Equal(t, 5*time.Minute, cfg.Collect.Timeout)
+	assert.Equal(t, time.Minute, cfg.Sinks.RemoteWrite.Interval, "default must be kept")
+
+	assert.Len(t, cfg.Explorers, 1)
+	explorer := cfg.Explorers[0]
+	assert.Equal(t, "aws-prod", explorer.DisplayName())
+	assert.Equal(t, "prod", explorer.Credentials.Profile)
+	assert.Equal(t, 1.2, explorer.PUE)
+	assert.Equal(t, map[string]float64{"eu-west-1": 50}, explorer.Intensity)
+	assert.Equal(t, map[string]float64{"eu-west-1": 0.9}, explorer.Settings().CarbonFreeEnergy)
+	assert.Equal(t, 10*time.Minute, explorer.Cache.TTL)
+	assert.Equal(t, &aws.Config{OrganizationRole: "arn:aws:iam::{account}:role/carbon-reader"}, explorer.Config)
+
+	policy, err := cfg.Labels.Policy()
+	assert.NoError(t, err)
+	assert.Equal(t, "tag_", policy.TagPrefix)
+	assert.Equal(t, map[string]string{"tag_team": "owner"}, policy.Renames)
+
+	rules := cfg.AggregationRules()
+	assert.Len(t, rules, 1)
+	assert.Equal(t, 3, rules[0].TopN)
+
+	electricityMaps := cfg.Intensity.ElectricityMaps
+	assert.True(t, electricityMaps.Enabled)
+	assert.Equal(t, "ELECTRICITYMAPS_API_KEY", electricityMaps.APIKeyEnv, "default must be kept")
+	assert.Equal(t, 15*time.Minute, electricityMaps.CacheTTL, "default must be kept")
+	assert.Equal(t, map[string]string{"on-prem": "FR"}, electricityMaps.Zones)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([]byte(`
+collect:
+  interval: soon
+explorers:
+  - provider: aws
+    unknown: true
+`))
+	errs, ok := err.(Errors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 2)
+	assert.Equal(t, 3, errs[0].Line)
+	assert.Equal(t, 6, errs[1].Line)
+	assert.Contains(t, errs[1].Msg, "field unknown not found")
+
+	_, err = Parse([]byte(`
+explorers:
+  - provider: gcp
+    gcp:
+      project: myproject
+`))
+	errs, ok = err.(Errors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 5, errs[0].Line)
+	assert.Equal(t, "field project not found in type gcp.Config", errs[0].Msg)
+
+	_, err = Parse([]byte("explorers: [\n"))
+	assert.Error(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	cfg, err := Parse([]byte(`
+mode: once
+explorers:
+  - name: prod
+    provider: aws
+    services: [lambda/function]
+    aws:
+      organization_role: arn:aws:iam::123:role/reader
+  - name: prod
+    provider: gcp
+    pue: 0.9
+  - provider: azure
+aggregations:
+  - by: []
+intensity:
+  electricitymaps:
+    enabled: true
+    cache_ttl: 0s
+factors:
+  files: [testdata/missing.yaml]
+`))
+	assert.NoError(t, err)
+
+	err = cfg.Validate()
+	errs, ok := err.(Errors)
+	assert.True(t, ok)
+
+	lines := make([]int, 0, len(errs))
+	paths := make([]string, 0, len(errs))
+	for _, err := range errs {
+		lines = append(lines, err.Line)
+		paths = append(paths, err.Path)
+	}
+	assert.Equal(t, []int{2, 6, 8, 9, 9, 11, 12, 14, 18, 20}, lines)
+	assert.Equal(t, []string{
+		"mode",
+		"explorers[0].services[0]",
+		"explorers[0].aws.organization_role",
+		"explorers[1].gcp",
+		"explorers[1].name",
+		"explorers[1].pue",
+		"explorers[2].provider",
+		"aggregations[0].by",
+		"intensity.electricitymaps.cache_ttl",
+		"factors.files[0]",
+	}, paths)
+	assert.Equal(t, "line 2: mode: run mode \"once\" is not supported (serve, push)", errs[0].Error())
+}
+
+func TestValidateWithoutFile(t *testing.T) {
+	cfg := Default()
+	explorer := Explorer{}
+	explorer.SetProvider("gcp")
+	assert.NoError(t, explorer.SetProviderField("scope", "billingAccounts/1"))
+	cfg.Explorers = append(cfg.Explorers, explorer)
+
+	err := cfg.Validate()
+	errs, ok := err.(Errors)
+	assert.True(t, ok)
+	assert.Len(t, errs, 1)
+	assert.Equal(t, 0, errs[0].Line)
+	assert.Equal(t, "explorers[0].gcp.scope", errs[0].Path)
+
+	assert.NoError(t, cfg.Explorers[0].SetProviderField("scope", "folders/1"))
+	assert.NoError(t, cfg.Validate())
+}
+
+func TestSetProviderField(t *testing.T) {
+	explorer := Explorer{}
+	explorer.SetProvider("gcp")
+	assert.NoError(t, explorer.SetProviderField("project_id", "123"))
+	assert.Equal(t, &gcp.Config{ProjectID: "123"}, explorer.Config)
+	assert.Error(t, explorer.SetProviderField("role_arn", "arn"))
+
+	explorer.SetProvider("azure")
+	assert.Nil(t, explorer.Config)
+	assert.Error(t, explorer.SetProviderField("subscription", "1"))
+}
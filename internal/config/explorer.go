@@ -0,0 +1,145 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"gopkg.in/yaml.v3"
+)
+
+// Settings returns the settings shared by the explorers of all providers
+func (explorer *Explorer) Settings() *cloudcarbonexporter.ExplorerSettings {
+	return &cloudcarbonexporter.ExplorerSettings{
+		Regions:  explorer.Regions,
+		Services: explorer.Services,
+		Credentials: cloudcarbonexporter.ExplorerCredentials{
+			Profile:      explorer.Credentials.Profile,
+			File:         explorer.Credentials.File,
+			AccessKeyEnv: explorer.Credentials.AccessKeyEnv,
+			SecretKeyEnv: explorer.Credentials.SecretKeyEnv,
+		},
+		PUE:              explorer.PUE,
+		Intensity:        explorer.Intensity,
+		CarbonFreeEnergy: explorer.CarbonFreeEnergy,
+		CacheTTL:         explorer.Cache.TTL,
+	}
+}
+
+// SetProvider sets the explorer provider and resets its configuration to the provider
+// defaults
+func (explorer *Explorer) SetProvider(provider string) {
+	explorer.Provider = provider
+	explorer.Config = nil
+	if registered, found := cloudcarbonexporter.LookupExplorerProvider(provider); found {
+		explorer.Config = registered.NewConfig()
+	}
+}
+
+// SetProviderField sets a field of the provider configuration from its yaml key
+func (explorer *Explorer) SetProviderField(key string, value string) error {
+	if explorer.Config == nil {
+		return fmt.Errorf("cloud provider %s is not supported", explorer.Provider)
+	}
+
+	node := &yaml.Node{
+		Kind: yaml.MappingNode,
+		Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: key},
+			{Kind: yaml.ScalarNode, Value: value},
+		},
+	}
+	if errs := unknownFields(node, reflect.TypeOf(explorer.Config)); len(errs) > 0 {
+		return fmt.Errorf("%s setting %s is not supported", explorer.Provider, key)
+	}
+	if err := node.Decode(explorer.Config); err != nil {
+		return fmt.Errorf("invalid %s setting %s: %w", explorer.Provider, key, err)
+	}
+	return nil
+}
+
+// UnmarshalYAML implements the yaml.Unmarshaler interface. The section named after the
+// provider is decoded into the configuration registered by the provider. Sections of other
+// providers are reported by Validate.
+func (explorer *Explorer) UnmarshalYAML(node *yaml.Node) error {
+	type plain Explorer
+	if node.Kind != yaml.MappingNode {
+		return node.Decode((*plain)(explorer))
+	}
+
+	// the decoder does not check unknown fields of unmarshalers, they are checked here
+	settings := &yaml.Node{Kind: yaml.MappingNode, Line: node.Line, Column: node.Column}
+	sections := make(map[string]*yaml.Node)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i], node.Content[i+1]
+		if _, found := cloudcarbonexporter.LookupExplorerProvider(key.Value); found {
+			sections[key.Value] = value
+			explorer.sections = append(explorer.sections, key.Value)
+			continue
+		}
+		settings.Content = append(settings.Content, key, value)
+	}
+
+	errs := unknownFields(settings, reflect.TypeOf(explorer))
+	if err := settings.Decode((*plain)(explorer)); err != nil {
+		errs = append(errs, typeErrors(err)...)
+	}
+
+	explorer.SetProvider(explorer.Provider)
+	if section, found := sections[explorer.Provider]; found {
+		errs = append(errs, unknownFields(section, reflect.TypeOf(explorer.Config))...)
+		if err := section.Decode(explorer.Config); err != nil {
+			errs = append(errs, typeErrors(err)...)
+		}
+	}
+
+	if len(errs) > 0 {
+		return &yaml.TypeError{Errors: errs}
+	}
+	return nil
+}
+
+// unknownFields returns the errors of the keys of a mapping node that are not fields of
+// the struct, in the format of the yaml decoder
+func unknownFields(node *yaml.Node, typ reflect.Type) []string {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if node.Kind != yaml.MappingNode || typ.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := make(map[string]reflect.Type, typ.NumField())
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+		if !field.IsExported() || name == "-" {
+			continue
+		}
+		if name == "" {
+			name = strings.ToLower(field.Name)
+		}
+		fields[name] = field.Type
+	}
+
+	errs := make([]string, 0)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		fieldType, found := fields[key.Value]
+		if !found {
+			errs = append(errs, fmt.Sprintf("line %d: field %s not found in type %s", key.Line, key.Value, typ))
+			continue
+		}
+		errs = append(errs, unknownFields(node.Content[i+1], fieldType)...)
+	}
+	return errs
+}
+
+// typeErrors returns the messages of a yaml decoding error
+func typeErrors(err error) []string {
+	if typeErr, ok := err.(*yaml.TypeError); ok {
+		return typeErr.Errors
+	}
+	return []string{err.Error()}
+}
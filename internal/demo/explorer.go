@@ -2,42 +2,358 @@ package demo
 
 import (
 	"context"
+	"fmt"
+	"maps"
 	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/carbon"
+	"github.com/superdango/cloud-carbon-exporter/model/cloud"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
 )
 
-// Explorer implements the cloudcarbon collector interface.
-// It is used to generate fake data for demonstration purpose
-type Explorer struct{}
+func init() {
+	cloudcarbonexporter.RegisterMetricFamilies(cloudcarbonexporter.MetricFamily{
+		Name: "demo_connected_users",
+		Help: "Users connected to the synthetic demo service, a business KPI to relate emissions to.",
+		Type: cloudcarbonexporter.GaugeType,
+	})
+}
+
+const (
+	instanceService = "demo/instance"
+	volumeService   = "demo/volume"
+	bucketService   = "demo/bucket"
+)
+
+// peakTraffic is the highest traffic generated by naturalTrafficInstant
+const peakTraffic = 863.0
+
+type ExplorerOption func(*Explorer)
+
+// WithSeed sets the seed of the generated fleet and of its usage noise
+func WithSeed(seed uint64) ExplorerOption {
+	return func(e *Explorer) {
+		e.seed = seed
+	}
+}
+
+// WithFleetSize sets the number of generated instances, volumes and buckets
+func WithFleetSize(instances, volumes, buckets int) ExplorerOption {
+	return func(e *Explorer) {
+		e.instances, e.volumes, e.buckets = instances, volumes, buckets
+	}
+}
+
+// WithRegions sets the regions the fleet is spread over
+func WithRegions(regions ...string) ExplorerOption {
+	return func(e *Explorer) {
+		if len(regions) > 0 {
+			e.regions = regions
+		}
+	}
+}
+
+// WithServices restricts the generated impacts to the services, e.g. demo/instance
+func WithServices(services ...string) ExplorerOption {
+	return func(e *Explorer) {
+		if len(services) > 0 {
+			e.services = services
+		}
+	}
+}
+
+// WithPUEModel sets the power usage effectiveness of the datacenters by location and month
+func WithPUEModel(model primitives.PUEModel) ExplorerOption {
+	return func(e *Explorer) {
+		e.pue = model
+	}
+}
+
+// WithIntensityOverrides replaces the grid carbon intensity (gCO2eq/kWh) of the locations
+func WithIntensityOverrides(overrides map[string]float64) ExplorerOption {
+	return func(e *Explorer) {
+		e.intensityOverrides = overrides
+	}
+}
+
+// WithIntensityProviders sets the time-varying grid carbon intensity providers, tried in
+// order before the static carbon intensity map
+func WithIntensityProviders(providers ...cloudcarbonexporter.IntensityProvider) ExplorerOption {
+	return func(e *Explorer) {
+		e.intensityProviders = providers
+	}
+}
+
+// WithCarbonFreeEnergy sets the carbon-free energy share (0 to 1) of the locations used for
+// market-based emissions. Other locations have none.
+func WithCarbonFreeEnergy(cfe map[string]float64) ExplorerOption {
+	return func(e *Explorer) {
+		e.carbonFreeEnergy = carbon.CarbonFreeEnergyMap(nil).WithOverrides(cfe)
+	}
+}
+
+// Explorer generates the impacts of a synthetic fleet of instances, volumes and buckets,
+// estimated with the same models as the cloud explorers. It needs no credentials and is
+// used to demonstrate dashboards and to load test the exporter.
+type Explorer struct {
+	seed               uint64
+	instances          int
+	volumes            int
+	buckets            int
+	regions            []string
+	services           []string
+	pue                primitives.PUEModel
+	carbonIntensityMap carbon.IntensityMap
+	wue                carbon.WaterMap
+	gridWaterIntensity carbon.WaterMap
+	intensityOverrides map[string]float64
+	intensityProviders []cloudcarbonexporter.IntensityProvider
+	carbonFreeEnergy   carbon.CarbonFreeEnergyMap
+	now                func() time.Time
+
+	locations *atomic.Pointer[carbon.LocationModel]
+
+	fleet *fleet
+	// connectedUsers is the business KPI of the last collection
+	connectedUsers float64
+	mu             *sync.Mutex
+}
 
-// NewExplorer returns a new demo collector
+// NewExplorer returns a new demo explorer
 func NewExplorer() *Explorer {
-	return &Explorer{}
+	return &Explorer{
+		seed:               1,
+		instances:          24,
+		volumes:            16,
+		buckets:            6,
+		regions:            slices.Sorted(maps.Keys(regionOffsets)),
+		services:           []string{instanceService, volumeService, bucketService},
+		pue:                primitives.NewPUEModel("aws"),
+		carbonIntensityMap: carbon.NewAWSCloudCarbonFootprintIntensityMap(),
+		wue:                carbon.NewAWSWUEMap(),
+		gridWaterIntensity: carbon.NewGridWaterIntensityMap(),
+		now:                time.Now,
+		locations:          new(atomic.Pointer[carbon.LocationModel]),
+		mu:                 new(sync.Mutex),
+	}
 }
 
-func (explorer *Explorer) Close() error {
+func (explorer *Explorer) Configure(opts ...ExplorerOption) *Explorer {
+	for _, opt := range opts {
+		opt(explorer)
+	}
+	return explorer
+}
+
+// newLocationModel returns the factors of the explored locations
+func (explorer *Explorer) newLocationModel() *carbon.LocationModel {
+	return &carbon.LocationModel{
+		PUE: explorer.pue,
+		Intensity: carbon.NewExplorerIntensityProvider(
+			carbon.NewStaticIntensityProvider("ccf-aws", explorer.carbonIntensityMap),
+			explorer.intensityOverrides,
+			explorer.intensityProviders...,
+		),
+		CarbonFreeEnergy:   explorer.carbonFreeEnergy,
+		WUE:                explorer.wue,
+		GridWaterIntensity: explorer.gridWaterIntensity,
+	}
+}
+
+// UpdateFactors replaces the pue, carbon intensity overrides and carbon-free energy of the
+// explorer. Its fleet is kept.
+func (explorer *Explorer) UpdateFactors(settings *cloudcarbonexporter.ExplorerSettings) {
+	explorer.Configure(factorsOptions(settings)...)
+	explorer.locations.Store(explorer.newLocationModel())
+}
+
+// Init generates the fleet
+func (explorer *Explorer) Init(ctx context.Context) error {
+	if explorer.instances < 0 || explorer.volumes < 0 || explorer.buckets < 0 {
+		return fmt.Errorf("fleet size must be positive")
+	}
+	explorer.fleet = newFleet(explorer.seed, explorer.regions, explorer.instances, explorer.volumes, explorer.buckets)
+	explorer.locations.Store(explorer.newLocationModel())
 	return nil
 }
 
 func (explorer *Explorer) IsReady() bool {
-	return true
+	return explorer.fleet != nil
 }
 
-func (explorer *Explorer) CollectMetrics(ctx context.Context, metrics chan *cloudcarbonexporter.Metric, errs chan error) {
-	explorer.generatesDemoMetrics(metrics)
+func (explorer *Explorer) SupportedServices() []string {
+	return []string{instanceService, volumeService, bucketService}
 }
 
-func (explorer *Explorer) generatesDemoMetrics(metrics chan *cloudcarbonexporter.Metric) {
-	now := time.Now()
-	metrics <- &cloudcarbonexporter.Metric{
-		Name:  "demo_connected_users",
-		Value: float64(naturalTrafficInstant(now.Hour(), now.Minute(), rand.IntN(10))),
+func (explorer *Explorer) Tags() map[string]string {
+	return map[string]string{
+		"cloud_provider": "demo",
+	}
+}
+
+func (explorer *Explorer) Close() error {
+	return nil
+}
+
+// CollectImpacts generates the impacts of the fleet at the current time. The usage noise
+// only depends on the seed and the current minute, so replicas with the same seed report
+// the same values.
+func (explorer *Explorer) CollectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact, errs chan error) {
+	if explorer.fleet == nil {
+		errs <- fmt.Errorf("demo explorer is not initialized")
+		return
+	}
+
+	now := explorer.now().UTC()
+	random := rand.New(rand.NewPCG(explorer.seed, uint64(now.Unix()/60)))
+
+	traffic := make(map[string]float64, len(explorer.regions))
+	connectedUsers := 0.0
+	for _, region := range explorer.regions {
+		local := now.Add(time.Duration(regionOffsets[region]) * time.Hour)
+		traffic[region] = float64(naturalTrafficInstant(local.Hour(), local.Minute(), random.IntN(10)))
+		connectedUsers += traffic[region]
+	}
+
+	explorer.mu.Lock()
+	explorer.connectedUsers = connectedUsers
+	explorer.mu.Unlock()
+
+	emit := func(impacts chan *cloudcarbonexporter.Impact, impact *cloudcarbonexporter.Impact) error {
+		factors, err := explorer.locations.Load().Factors(ctx, impact.Labels["location"], now)
+		if err != nil {
+			return err
+		}
+		impact.ApplyLocationFactors(factors)
+		impacts <- impact
+		return nil
+	}
+
+	if slices.Contains(explorer.services, instanceService) {
+		errs <- cloudcarbonexporter.ObserveSubExplorer(ctx, instanceService, "global", impacts, func(impacts chan *cloudcarbonexporter.Impact) error {
+			for _, instance := range explorer.fleet.instances {
+				cpu := instance.idleCPU + instance.trafficCPU*traffic[instance.region]/peakTraffic + random.Float64()
+				if err := emit(impacts, instance.impact(min(cpu, 100))); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	if slices.Contains(explorer.services, volumeService) {
+		errs <- cloudcarbonexporter.ObserveSubExplorer(ctx, volumeService, "global", impacts, func(impacts chan *cloudcarbonexporter.Impact) error {
+			for _, volume := range explorer.fleet.volumes {
+				if err := emit(impacts, volume.impact()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+
+	if slices.Contains(explorer.services, bucketService) {
+		errs <- cloudcarbonexporter.ObserveSubExplorer(ctx, bucketService, "global", impacts, func(impacts chan *cloudcarbonexporter.Impact) error {
+			for _, bucket := range explorer.fleet.buckets {
+				if err := emit(impacts, bucket.impact()); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}
+}
+
+// ReportMetrics returns the business KPI of the last collection
+func (explorer *Explorer) ReportMetrics() []*cloudcarbonexporter.Metric {
+	explorer.mu.Lock()
+	defer explorer.mu.Unlock()
+
+	return []*cloudcarbonexporter.Metric{
+		{
+			Name:   "demo_connected_users",
+			Value:  explorer.connectedUsers,
+			Labels: map[string]string{"app": "demo.carbondriven.dev"},
+		},
+	}
+}
+
+// impact returns the impact of the instance running at the cpu usage, in percent
+func (instance *instance) impact(cpu float64) *cloudcarbonexporter.Impact {
+	processor := primitives.LookupProcessorByName(instance.typ.processor)
+
+	impact := &cloudcarbonexporter.Impact{
+		Inputs: cloudcarbonexporter.ImpactInputs{
+			InstanceType: instance.typ.name,
+			VCPU:         instance.typ.vcpu,
+			MemoryGB:     instance.typ.memoryGB,
+			Processor:    processor.Inputs(),
+			CPUAverage:   cpu,
+		},
+		Labels: map[string]string{
+			"location":    instance.region,
+			"az":          instance.zone,
+			"kind":        instanceService,
+			"instance_id": instance.id,
+		},
+		Tags: instance.tags,
+	}
+	impact.AddComponent(cloudcarbonexporter.ComponentCPU,
+		processor.EstimateCPUEnergy(instance.typ.vcpu, cpu),
+		primitives.EstimateCPUEmbodiedEmissions(instance.typ.vcpu))
+	impact.AddComponent(cloudcarbonexporter.ComponentMemory,
+		primitives.EstimateMemoryEnergy(instance.typ.memoryGB),
+		primitives.EstimateMemoryEmbodiedEmissions(instance.typ.memoryGB))
+
+	return impact
+}
+
+// impact returns the impact of the volume
+func (volume *volume) impact() *cloudcarbonexporter.Impact {
+	energy := cloud.EstimateHDDBlockStorageEnergy(volume.sizeGB)
+	embodied := cloud.EstimateHDDBlockStorageEmbodiedEmissions(volume.sizeGB)
+	volumeType := "hdd"
+	if volume.ssd {
+		energy = cloud.EstimateSSDBlockStorageEnergy(volume.sizeGB)
+		embodied = cloud.EstimateSSDBlockStorageEmbodiedEmissions(volume.sizeGB)
+		volumeType = "ssd"
+	}
+
+	impact := &cloudcarbonexporter.Impact{
 		Labels: map[string]string{
-			"app": "demo.carbondriven.dev",
+			"location":    volume.region,
+			"az":          volume.zone,
+			"kind":        volumeService,
+			"volume_id":   volume.id,
+			"volume_type": volumeType,
 		},
+		Tags: volume.tags,
 	}
+	impact.AddComponent(cloudcarbonexporter.ComponentStorage, energy, embodied)
+
+	return impact
+}
+
+// impact returns the impact of the bucket
+func (bucket *bucket) impact() *cloudcarbonexporter.Impact {
+	impact := &cloudcarbonexporter.Impact{
+		Labels: map[string]string{
+			"location":    bucket.region,
+			"kind":        bucketService,
+			"bucket_name": bucket.name,
+		},
+		Tags: bucket.tags,
+	}
+	impact.AddComponent(cloudcarbonexporter.ComponentStorage,
+		cloud.EstimateObjectStorageEnergy(bucket.sizeGB),
+		cloud.EstimateObjectStorageEmbodiedEmissions(bucket.sizeGB))
+
+	return impact
 }
 
 // naturalTrafficInstant generate a trafic value with hourly variation
@@ -1,18 +1,101 @@
-package demo_test
+package demo
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
-	"github.com/superdango/cloud-carbon-exporter/internal/demo"
 )
 
-func TestDemoExplorer(t *testing.T) {
-	metrics := make(chan *cloudcarbonexporter.Metric, 1)
+func collect(t *testing.T, explorer *Explorer) []*cloudcarbonexporter.Impact {
+	impacts := make(chan *cloudcarbonexporter.Impact)
 	errs := make(chan error)
-	demoExplorer := demo.NewExplorer()
-	demoExplorer.CollectMetrics(t.Context(), metrics, errs)
-	m := <-metrics
-	assert.Equal(t, "demo_connected_users", m.Name)
+	collected := make([]*cloudcarbonexporter.Impact, 0)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for impact := range impacts {
+			collected = append(collected, impact)
+		}
+	}()
+	go func() {
+		for err := range errs {
+			assert.NoError(t, err)
+		}
+	}()
+
+	explorer.CollectImpacts(cloudcarbonexporter.WrapCtx(t.Context()), impacts, errs)
+	close(impacts)
+	<-done
+	close(errs)
+
+	return collected
+}
+
+func TestDemoExplorer(t *testing.T) {
+	explorer := NewExplorer().Configure(WithFleetSize(10, 5, 2))
+	explorer.now = func() time.Time { return time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC) }
+	assert.False(t, explorer.IsReady())
+	assert.NoError(t, explorer.Init(t.Context()))
+	assert.True(t, explorer.IsReady())
+
+	impacts := collect(t, explorer)
+	assert.Len(t, impacts, 17)
+
+	kinds := make(map[string]int)
+	for _, impact := range impacts {
+		kinds[impact.Labels["kind"]]++
+		assert.Greater(t, float64(impact.Energy), 0.0)
+		assert.Greater(t, float64(impact.EnergyEmissions.KgCO2eq_day()), 0.0)
+		assert.Contains(t, explorer.regions, impact.Labels["location"])
+		assert.Equal(t, "demo.carbondriven.dev", impact.Tags["app"])
+		assert.Equal(t, "ccf-aws", impact.Inputs.CarbonIntensitySource)
+		assert.Greater(t, float64(impact.OnSiteWater), 0.0)
+		assert.Greater(t, float64(impact.OffSiteWater), float64(impact.OnSiteWater))
+	}
+	assert.Equal(t, map[string]int{instanceService: 10, volumeService: 5, bucketService: 2}, kinds)
+
+	metrics := explorer.ReportMetrics()
+	assert.Equal(t, "demo_connected_users", metrics[0].Name)
+	assert.Greater(t, metrics[0].Value, 0.0)
+
+	// the same seed generates the same fleet and usage
+	replica := NewExplorer().Configure(WithFleetSize(10, 5, 2), WithServices(instanceService))
+	replica.now = explorer.now
+	assert.NoError(t, replica.Init(t.Context()))
+	replicaImpacts := collect(t, replica)
+	assert.Len(t, replicaImpacts, 10)
+	assert.Equal(t, impacts[0].Labels, replicaImpacts[0].Labels)
+	assert.Equal(t, impacts[0].Energy, replicaImpacts[0].Energy)
+}
+
+func TestDemoExplorerIntensityOverrides(t *testing.T) {
+	explorer := NewExplorer().Configure(WithFleetSize(0, 0, 2), WithRegions("eu-west-3"), WithIntensityOverrides(map[string]float64{"eu-west-3": 10}))
+	assert.NoError(t, explorer.Init(t.Context()))
+
+	for _, impact := range collect(t, explorer) {
+		assert.Equal(t, "override", impact.Inputs.CarbonIntensitySource)
+		assert.Equal(t, 10.0, impact.Inputs.CarbonIntensity)
+	}
+}
+
+func TestDemoExplorerCarbonFreeEnergy(t *testing.T) {
+	explorer := NewExplorer().Configure(WithFleetSize(2, 0, 0), WithCarbonFreeEnergy(map[string]float64{"eu-west-3": 0.6}))
+	assert.NoError(t, explorer.Init(t.Context()))
+
+	for _, impact := range collect(t, explorer) {
+		expected := impact.EnergyEmissions.KgCO2eq_day()
+		if impact.Labels["location"] == "eu-west-3" {
+			expected = expected * 0.4
+		}
+		assert.InDelta(t, expected, impact.MarketEmissions.KgCO2eq_day(), 0.000001, impact.Labels["location"])
+	}
+}
+
+func TestNaturalTrafficInstant(t *testing.T) {
+	assert.Equal(t, 300, naturalTrafficInstant(3, 0, 0))
+	assert.Equal(t, 750, naturalTrafficInstant(20, 30, 0))
+	assert.Greater(t, naturalTrafficInstant(20, 0, 9), naturalTrafficInstant(3, 0, 9))
 }
@@ -0,0 +1,135 @@
+package demo
+
+import (
+	"fmt"
+	"math/rand/v2"
+)
+
+// instanceType describes the shape of a synthetic instance
+type instanceType struct {
+	name      string
+	vcpu      float64
+	memoryGB  float64
+	processor string
+}
+
+// instanceTypes are the shapes of the synthetic instances, named after their aws equivalent
+var instanceTypes = []instanceType{
+	{name: "m5.large", vcpu: 2, memoryGB: 8, processor: "Intel Xeon Platinum 8259CL"},
+	{name: "m5.xlarge", vcpu: 4, memoryGB: 16, processor: "Intel Xeon Platinum 8259CL"},
+	{name: "c6i.2xlarge", vcpu: 8, memoryGB: 16, processor: "Intel Xeon Platinum 8375C"},
+	{name: "m6a.xlarge", vcpu: 4, memoryGB: 16, processor: "AMD EPYC 7R13"},
+	{name: "m7g.large", vcpu: 2, memoryGB: 8, processor: "Annapurna Labs Graviton3"},
+	{name: "r7g.2xlarge", vcpu: 8, memoryGB: 64, processor: "Annapurna Labs Graviton3"},
+}
+
+// regionOffsets are the utc offsets of the default regions, shifting their daily traffic
+var regionOffsets = map[string]int{
+	"us-east-1":      -5,
+	"us-west-2":      -8,
+	"eu-west-1":      0,
+	"eu-west-3":      1,
+	"eu-central-1":   1,
+	"ap-northeast-1": 9,
+	"ap-southeast-2": 10,
+}
+
+var (
+	teams        = []string{"checkout", "search", "catalog", "platform"}
+	environments = []string{"production", "production", "production", "staging"}
+)
+
+// instance is a synthetic instance. Its cpu usage follows the traffic of its region.
+type instance struct {
+	id     string
+	region string
+	zone   string
+	typ    instanceType
+	tags   map[string]string
+	// idleCPU is the cpu usage without traffic and trafficCPU the usage added at peak
+	// traffic, in percent
+	idleCPU    float64
+	trafficCPU float64
+}
+
+// volume is a synthetic block storage volume
+type volume struct {
+	id     string
+	region string
+	zone   string
+	sizeGB float64
+	ssd    bool
+	tags   map[string]string
+}
+
+// bucket is a synthetic object storage bucket
+type bucket struct {
+	name   string
+	region string
+	sizeGB float64
+	tags   map[string]string
+}
+
+// fleet is the synthetic estate explored by the demo explorer
+type fleet struct {
+	instances []*instance
+	volumes   []*volume
+	buckets   []*bucket
+}
+
+// newFleet generates a fleet spread over the regions. The same seed always generates the
+// same fleet.
+func newFleet(seed uint64, regions []string, instances, volumes, buckets int) *fleet {
+	random := rand.New(rand.NewPCG(seed, seed))
+	fleet := &fleet{
+		instances: make([]*instance, 0, instances),
+		volumes:   make([]*volume, 0, volumes),
+		buckets:   make([]*bucket, 0, buckets),
+	}
+
+	for range instances {
+		region := regions[random.IntN(len(regions))]
+		fleet.instances = append(fleet.instances, &instance{
+			id:         fmt.Sprintf("i-%017x", random.Uint64()),
+			region:     region,
+			zone:       region + string(rune('a'+random.IntN(3))),
+			typ:        instanceTypes[random.IntN(len(instanceTypes))],
+			tags:       randomTags(random),
+			idleCPU:    2 + random.Float64()*8,
+			trafficCPU: 20 + random.Float64()*60,
+		})
+	}
+
+	for range volumes {
+		region := regions[random.IntN(len(regions))]
+		fleet.volumes = append(fleet.volumes, &volume{
+			id:     fmt.Sprintf("vol-%017x", random.Uint64()),
+			region: region,
+			zone:   region + string(rune('a'+random.IntN(3))),
+			sizeGB: float64(int(8) << random.IntN(8)),
+			ssd:    random.IntN(4) > 0,
+			tags:   randomTags(random),
+		})
+	}
+
+	for i := range buckets {
+		tags := randomTags(random)
+		fleet.buckets = append(fleet.buckets, &bucket{
+			name:   fmt.Sprintf("demo-%s-%d", tags["team"], i),
+			region: regions[random.IntN(len(regions))],
+			sizeGB: float64(random.IntN(50_000)) + 1,
+			tags:   tags,
+		})
+	}
+
+	return fleet
+}
+
+// randomTags returns the team and environment tags of a resource
+func randomTags(random *rand.Rand) map[string]string {
+	return map[string]string{
+		"app":  "demo.carbondriven.dev",
+		"team": teams[random.IntN(len(teams))],
+		"env":  environments[random.IntN(len(environments))],
+	}
+}
@@ -0,0 +1,75 @@
+package demo
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
+)
+
+func init() {
+	cloudcarbonexporter.RegisterExplorerProvider(cloudcarbonexporter.ExplorerProvider{
+		Name:              "demo",
+		SupportedServices: NewExplorer().SupportedServices(),
+		Locations:         slices.Sorted(maps.Keys(NewExplorer().carbonIntensityMap)),
+		NewConfig: func() cloudcarbonexporter.ProviderConfig {
+			explorer := NewExplorer()
+			return &Config{Seed: explorer.seed, Instances: explorer.instances, Volumes: explorer.volumes, Buckets: explorer.buckets}
+		},
+		New: newProviderExplorer,
+	})
+}
+
+// Config is the demo section of an explorer configuration
+type Config struct {
+	// Seed generates the fleet, explorers with the same seed explore the same fleet
+	Seed      uint64 `yaml:"seed"`
+	Instances int    `yaml:"instances"`
+	Volumes   int    `yaml:"volumes"`
+	Buckets   int    `yaml:"buckets"`
+}
+
+// Validate implements the cloudcarbonexporter.ProviderConfig interface
+func (config *Config) Validate(settings *cloudcarbonexporter.ExplorerSettings) []*cloudcarbonexporter.FieldError {
+	errs := make([]*cloudcarbonexporter.FieldError, 0)
+	if config.Instances < 0 {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"demo", "instances"}, Msg: "must be positive"})
+	}
+	if config.Volumes < 0 {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"demo", "volumes"}, Msg: "must be positive"})
+	}
+	if config.Buckets < 0 {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"demo", "buckets"}, Msg: "must be positive"})
+	}
+	if settings.Credentials != (cloudcarbonexporter.ExplorerCredentials{}) {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"credentials"}, Msg: "is not supported by demo explorers"})
+	}
+	return errs
+}
+
+// newProviderExplorer creates an explorer of the configured synthetic fleet
+func newProviderExplorer(ctx context.Context, settings *cloudcarbonexporter.ExplorerSettings, providerConfig cloudcarbonexporter.ProviderConfig) (cloudcarbonexporter.Explorer, error) {
+	config := providerConfig.(*Config)
+
+	opts := []ExplorerOption{
+		WithSeed(config.Seed),
+		WithFleetSize(config.Instances, config.Volumes, config.Buckets),
+		WithRegions(settings.Regions...),
+		WithServices(settings.Services...),
+		WithIntensityProviders(settings.IntensityProviders...),
+	}
+	opts = append(opts, factorsOptions(settings)...)
+
+	return NewExplorer().Configure(opts...), nil
+}
+
+// factorsOptions returns the options setting the emission factors of the explorer
+func factorsOptions(settings *cloudcarbonexporter.ExplorerSettings) []ExplorerOption {
+	return []ExplorerOption{
+		WithIntensityOverrides(settings.Intensity),
+		WithCarbonFreeEnergy(settings.CarbonFreeEnergy),
+		WithPUEModel(primitives.NewPUEModel("aws").WithOverrides(settings.PUE, settings.LocationPUE, settings.MonthlyPUE)),
+	}
+}
@@ -0,0 +1,257 @@
+// Package electricitymaps provides the live grid carbon intensity of the provider regions
+// from the Electricity Maps api (https://www.electricitymaps.com).
+package electricitymaps
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/cache"
+)
+
+const (
+	// Source is the source of the Electricity Maps intensities
+	Source = "electricitymaps"
+	// DefaultURL is the Electricity Maps api url
+	DefaultURL = "https://api.electricitymap.org"
+	// DefaultCacheTTL is how long the intensity of a zone is kept before being queried again
+	DefaultCacheTTL = 15 * time.Minute
+	// defaultBackoff is how long the api is not queried after a failure without Retry-After
+	defaultBackoff = time.Minute
+)
+
+// ErrRateLimited is returned while the api rate limit is exceeded
+var ErrRateLimited = errors.New("electricity maps rate limit exceeded")
+
+// IntensityProvider returns the latest carbon intensity of the zone of a location. It
+// implements the cloudcarbonexporter.IntensityProvider interface.
+//
+// The intensity of each zone is cached. After a failure or a rate limited response, the api
+// is not queried until the Retry-After delay has passed and errors are returned instead, so
+// explorers fall back to their static intensities.
+type IntensityProvider struct {
+	url        string
+	apiKey     string
+	zones      zoneMap
+	cacheTTL   time.Duration
+	httpClient *http.Client
+	cache      *cache.Memory
+
+	mu       *sync.Mutex
+	retryAt  time.Time
+	retryErr error
+	now      func() time.Time
+}
+
+type IntensityProviderOption func(*IntensityProvider)
+
+// WithURL sets the api url, DefaultURL by default
+func WithURL(url string) IntensityProviderOption {
+	return func(provider *IntensityProvider) {
+		provider.url = strings.TrimSuffix(url, "/")
+	}
+}
+
+// WithAPIKey sets the api key sent in the auth-token header
+func WithAPIKey(apiKey string) IntensityProviderOption {
+	return func(provider *IntensityProvider) {
+		provider.apiKey = apiKey
+	}
+}
+
+// WithZones maps location prefixes to zones, in addition to the built-in mapping of the
+// provider regions
+func WithZones(zones map[string]string) IntensityProviderOption {
+	return func(provider *IntensityProvider) {
+		for location, zone := range zones {
+			if location != "" {
+				provider.zones[strings.ToLower(location)] = zone
+			}
+		}
+	}
+}
+
+// WithCacheTTL sets how long the intensity of a zone is cached, DefaultCacheTTL by default
+func WithCacheTTL(ttl time.Duration) IntensityProviderOption {
+	return func(provider *IntensityProvider) {
+		provider.cacheTTL = ttl
+	}
+}
+
+// WithHTTPClient sets the client querying the api
+func WithHTTPClient(httpClient *http.Client) IntensityProviderOption {
+	return func(provider *IntensityProvider) {
+		provider.httpClient = httpClient
+	}
+}
+
+// NewIntensityProvider returns a provider with the default settings
+func NewIntensityProvider() *IntensityProvider {
+	zones := make(zoneMap, len(defaultZones))
+	for location, zone := range defaultZones {
+		zones[location] = zone
+	}
+
+	return &IntensityProvider{
+		url:        DefaultURL,
+		zones:      zones,
+		cacheTTL:   DefaultCacheTTL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+		mu:         new(sync.Mutex),
+		now:        time.Now,
+	}
+}
+
+// Configure applies options to the provider
+func (provider *IntensityProvider) Configure(opts ...IntensityProviderOption) *IntensityProvider {
+	for _, opt := range opts {
+		opt(provider)
+	}
+	return provider
+}
+
+// Init checks the provider settings and starts the cache. The cache expiration stops with
+// the context.
+func (provider *IntensityProvider) Init(ctx context.Context) error {
+	if provider.apiKey == "" {
+		return fmt.Errorf("electricity maps api key is not set")
+	}
+	if _, err := url.ParseRequestURI(provider.url); err != nil {
+		return fmt.Errorf("invalid electricity maps url: %w", err)
+	}
+	if provider.cacheTTL <= 0 {
+		return fmt.Errorf("electricity maps cache ttl must be positive")
+	}
+	provider.cache = cache.NewMemory(ctx, provider.cacheTTL)
+	return nil
+}
+
+// latest is the response of the latest carbon intensity endpoint
+type latest struct {
+	Zone            string    `json:"zone"`
+	CarbonIntensity float64   `json:"carbonIntensity"`
+	Datetime        time.Time `json:"datetime"`
+}
+
+// Intensity implements the cloudcarbonexporter.IntensityProvider interface. It returns the
+// latest intensity of the location zone whatever the time: impacts are computed from the
+// current usage of resources. It returns ErrIntensityNotFound if the location has no zone or
+// if the api does not know it.
+func (provider *IntensityProvider) Intensity(ctx context.Context, location string, at time.Time) (cloudcarbonexporter.Intensity, error) {
+	zone, found := provider.zones.zone(location)
+	if !found {
+		return cloudcarbonexporter.Intensity{}, cloudcarbonexporter.ErrIntensityNotFound
+	}
+
+	err := provider.cache.SetDynamicIfNotExists(ctx, zone, func(ctx context.Context) (any, error) {
+		return provider.latest(ctx, zone)
+	})
+	if err != nil {
+		return cloudcarbonexporter.Intensity{}, err
+	}
+
+	v, err := provider.cache.Get(ctx, zone)
+	if err != nil {
+		return cloudcarbonexporter.Intensity{}, err
+	}
+	intensity, ok := v.(*latest)
+	if !ok || intensity == nil {
+		return cloudcarbonexporter.Intensity{}, cloudcarbonexporter.ErrIntensityNotFound
+	}
+
+	return cloudcarbonexporter.Intensity{
+		PerKWh:    cloudcarbonexporter.Emissions(intensity.CarbonIntensity),
+		Source:    Source,
+		Timestamp: intensity.Datetime,
+	}, nil
+}
+
+// latest queries the latest intensity of the zone. It returns nil if the api does not know
+// the zone, so unknown zones are cached too.
+func (provider *IntensityProvider) latest(ctx context.Context, zone string) (*latest, error) {
+	if err := provider.blocked(); err != nil {
+		return nil, err
+	}
+
+	query := url.Values{"zone": []string{zone}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, provider.url+"/v3/carbon-intensity/latest?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create electricity maps request: %w", err)
+	}
+	req.Header.Set("auth-token", provider.apiKey)
+
+	resp, err := provider.httpClient.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to query electricity maps: %w", err)
+		// the api is not at fault if the collection has been canceled
+		if ctx.Err() == nil {
+			provider.block(defaultBackoff, err)
+		}
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		slog.Warn("zone is unknown to electricity maps", "zone", zone)
+		return nil, nil
+	case resp.StatusCode == http.StatusTooManyRequests:
+		provider.block(retryAfter(resp.Header.Get("Retry-After"), provider.now()), ErrRateLimited)
+		return nil, ErrRateLimited
+	case resp.StatusCode != http.StatusOK:
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		err := fmt.Errorf("electricity maps returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		provider.block(retryAfter(resp.Header.Get("Retry-After"), provider.now()), err)
+		return nil, err
+	}
+
+	intensity := new(latest)
+	if err := json.NewDecoder(resp.Body).Decode(intensity); err != nil {
+		return nil, fmt.Errorf("failed to decode electricity maps response: %w", err)
+	}
+	return intensity, nil
+}
+
+// blocked returns an error while the api must not be queried
+func (provider *IntensityProvider) blocked() error {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	if provider.now().Before(provider.retryAt) {
+		return fmt.Errorf("electricity maps is not queried until %s: %w", provider.retryAt.Format(time.RFC3339), provider.retryErr)
+	}
+	return nil
+}
+
+// block stops querying the api for the delay after a failure
+func (provider *IntensityProvider) block(delay time.Duration, err error) {
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+
+	provider.retryAt = provider.now().Add(delay)
+	provider.retryErr = err
+	slog.Warn("electricity maps is unavailable, using static intensities", "retry_at", provider.retryAt, "err", err.Error())
+}
+
+// retryAfter returns the delay of a Retry-After header, in seconds or an http date, or the
+// default backoff if the header is not set or invalid
+func retryAfter(header string, now time.Time) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(header); err == nil && date.After(now) {
+		return date.Sub(now)
+	}
+	return defaultBackoff
+}
@@ -0,0 +1,163 @@
+package electricitymaps
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/carbon"
+)
+
+// stub is a local Electricity Maps api answering with status for the zones it does not
+// know the intensity of
+type stub struct {
+	intensities map[string]string
+	status      *atomic.Int64
+	requests    *atomic.Int64
+}
+
+func newStub(t *testing.T, intensities map[string]string) (*stub, *httptest.Server) {
+	stub := &stub{intensities: intensities, status: new(atomic.Int64), requests: new(atomic.Int64)}
+	stub.status.Store(http.StatusOK)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		stub.requests.Add(1)
+		if r.Header.Get("auth-token") != "secret" || r.URL.Path != "/v3/carbon-intensity/latest" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		status := int(stub.status.Load())
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "120")
+		}
+		intensity, found := stub.intensities[r.URL.Query().Get("zone")]
+		if !found && status == http.StatusOK {
+			status = http.StatusNotFound
+		}
+		w.WriteHeader(status)
+		if status == http.StatusOK {
+			w.Write([]byte(`{"zone":"` + r.URL.Query().Get("zone") + `","carbonIntensity":` + intensity + `,"datetime":"2025-06-01T10:00:00.000Z","isEstimated":false}`))
+		}
+	}))
+	t.Cleanup(server.Close)
+	return stub, server
+}
+
+func TestZones(t *testing.T) {
+	zones := NewIntensityProvider().Configure(WithZones(map[string]string{"EU-West-1": "GB", "on-prem": "FR"})).zones
+
+	for location, expected := range map[string]string{
+		"europe-west1-b":  "BE",
+		"europe-west10-a": "DE",
+		"us-east1":        "US-CAR-SCEG",
+		"us-east-1":       "US-MIDA-PJM",
+		"fr-par-1":        "FR",
+		"eu-west-1":       "GB",
+		"on-prem-lyon":    "FR",
+	} {
+		zone, found := zones.zone(location)
+		assert.True(t, found, location)
+		assert.Equal(t, expected, zone, location)
+	}
+
+	_, found := zones.zone("mars-north1")
+	assert.False(t, found)
+}
+
+func TestIntensityProvider(t *testing.T) {
+	stub, server := newStub(t, map[string]string{"FR": "32.5"})
+	provider := NewIntensityProvider().Configure(WithURL(server.URL+"/"), WithAPIKey("secret"))
+	assert.NoError(t, provider.Init(t.Context()))
+
+	for _, location := range []string{"eu-west-3", "europe-west9-a", "fr-par"} {
+		intensity, err := provider.Intensity(t.Context(), location, time.Now())
+		assert.NoError(t, err)
+		assert.Equal(t, cloudcarbonexporter.Intensity{
+			PerKWh:    32.5,
+			Source:    Source,
+			Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC),
+		}, intensity, location)
+	}
+	assert.Equal(t, int64(1), stub.requests.Load(), "zones are cached")
+
+	_, err := provider.Intensity(t.Context(), "mars-north1", time.Now())
+	assert.ErrorIs(t, err, cloudcarbonexporter.ErrIntensityNotFound)
+	_, err = provider.Intensity(t.Context(), "eu-west-1", time.Now())
+	assert.ErrorIs(t, err, cloudcarbonexporter.ErrIntensityNotFound)
+	_, err = provider.Intensity(t.Context(), "eu-west-1", time.Now())
+	assert.ErrorIs(t, err, cloudcarbonexporter.ErrIntensityNotFound)
+	assert.Equal(t, int64(2), stub.requests.Load(), "unknown zones are cached")
+
+	assert.Error(t, NewIntensityProvider().Init(t.Context()), "api key is required")
+}
+
+func TestIntensityProviderRateLimit(t *testing.T) {
+	stub, server := newStub(t, map[string]string{"FR": "32.5", "DE": "380"})
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	provider := NewIntensityProvider().Configure(WithURL(server.URL), WithAPIKey("secret"))
+	provider.now = func() time.Time { return now }
+	assert.NoError(t, provider.Init(t.Context()))
+
+	stub.status.Store(http.StatusTooManyRequests)
+	_, err := provider.Intensity(t.Context(), "eu-west-3", now)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	_, err = provider.Intensity(t.Context(), "eu-central-1", now)
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, int64(1), stub.requests.Load(), "api is not queried before Retry-After")
+
+	stub.status.Store(http.StatusOK)
+	now = now.Add(2 * time.Minute)
+	intensity, err := provider.Intensity(t.Context(), "eu-central-1", now)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Emissions(380), intensity.PerKWh)
+	assert.Equal(t, int64(2), stub.requests.Load())
+}
+
+func TestIntensityProviderFallback(t *testing.T) {
+	stub, server := newStub(t, map[string]string{"FR": "32.5"})
+	provider := NewIntensityProvider().Configure(WithURL(server.URL), WithAPIKey("secret"))
+	assert.NoError(t, provider.Init(t.Context()))
+	static := carbon.NewStaticIntensityProvider("static", carbon.IntensityMap{"eu-west-3": 56, "global": 400})
+	explorerProvider := carbon.NewExplorerIntensityProvider(static, nil, provider)
+
+	stub.status.Store(http.StatusServiceUnavailable)
+	intensity, err := explorerProvider.Intensity(t.Context(), "eu-west-3", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Intensity{PerKWh: 56, Source: "static"}, intensity, "api is down")
+
+	intensity, err = explorerProvider.Intensity(t.Context(), "on-prem", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Intensity{PerKWh: 400, Source: "static"}, intensity, "location has no zone")
+
+	unauthorized := NewIntensityProvider().Configure(WithURL(server.URL), WithAPIKey("invalid"))
+	assert.NoError(t, unauthorized.Init(t.Context()))
+	_, err = unauthorized.Intensity(t.Context(), "eu-west-3", time.Now())
+	assert.ErrorContains(t, err, "401")
+}
+
+func TestIntensityProviderDownAtStartup(t *testing.T) {
+	stub, server := newStub(t, map[string]string{"FR": "32.5"})
+	now := time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)
+	provider := NewIntensityProvider().Configure(WithURL(server.URL), WithAPIKey("secret"))
+	provider.now = func() time.Time { return now }
+	assert.NoError(t, provider.Init(t.Context()))
+
+	stub.status.Store(http.StatusServiceUnavailable)
+	_, err := provider.Intensity(t.Context(), "eu-west-3", now)
+	assert.ErrorContains(t, err, "503")
+
+	// the cache expirer must not keep the zone entry alive without value
+	time.Sleep(1500 * time.Millisecond)
+	_, err = provider.Intensity(t.Context(), "eu-west-3", now)
+	assert.ErrorContains(t, err, "503")
+	assert.Equal(t, int64(1), stub.requests.Load(), "api is not queried while blocked")
+
+	stub.status.Store(http.StatusOK)
+	now = now.Add(2 * defaultBackoff)
+	intensity, err := provider.Intensity(t.Context(), "eu-west-3", now)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Emissions(32.5), intensity.PerKWh)
+}
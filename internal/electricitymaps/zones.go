@@ -0,0 +1,104 @@
+package electricitymaps
+
+import (
+	"strings"
+
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
+)
+
+// defaultZones maps the provider regions to the Electricity Maps zone of their grid. Zones
+// of a region, like europe-west1-b or fr-par-1, are matched by prefix.
+var defaultZones = map[string]string{
+	// aws
+	"af-south-1":     "ZA",
+	"ap-east-1":      "HK",
+	"ap-northeast-1": "JP-TK",
+	"ap-northeast-2": "KR",
+	"ap-northeast-3": "JP-KN",
+	"ap-south-1":     "IN-WE",
+	"ap-south-2":     "IN-SO",
+	"ap-southeast-1": "SG",
+	"ap-southeast-2": "AU-NSW",
+	"ap-southeast-3": "ID",
+	"ap-southeast-4": "AU-VIC",
+	"ca-central-1":   "CA-QC",
+	"ca-west-1":      "CA-AB",
+	"eu-central-1":   "DE",
+	"eu-central-2":   "CH",
+	"eu-north-1":     "SE-SE3",
+	"eu-south-1":     "IT-NO",
+	"eu-south-2":     "ES",
+	"eu-west-1":      "IE",
+	"eu-west-2":      "GB",
+	"eu-west-3":      "FR",
+	"il-central-1":   "IL",
+	"me-central-1":   "AE",
+	"me-south-1":     "BH",
+	"sa-east-1":      "BR-CS",
+	"us-east-1":      "US-MIDA-PJM",
+	"us-east-2":      "US-MIDA-PJM",
+	"us-west-1":      "US-CAL-CISO",
+	"us-west-2":      "US-NW-BPAT",
+
+	// gcp
+	"africa-south1":           "ZA",
+	"asia-east1":              "TW",
+	"asia-east2":              "HK",
+	"asia-northeast1":         "JP-TK",
+	"asia-northeast2":         "JP-KN",
+	"asia-northeast3":         "KR",
+	"asia-south1":             "IN-WE",
+	"asia-south2":             "IN-NO",
+	"asia-southeast1":         "SG",
+	"asia-southeast2":         "ID",
+	"australia-southeast1":    "AU-NSW",
+	"australia-southeast2":    "AU-VIC",
+	"europe-central2":         "PL",
+	"europe-north1":           "FI",
+	"europe-southwest1":       "ES",
+	"europe-west1":            "BE",
+	"europe-west2":            "GB",
+	"europe-west3":            "DE",
+	"europe-west4":            "NL",
+	"europe-west6":            "CH",
+	"europe-west8":            "IT-NO",
+	"europe-west9":            "FR",
+	"europe-west10":           "DE",
+	"europe-west12":           "IT-NO",
+	"me-central1":             "QA",
+	"me-central2":             "SA",
+	"me-west1":                "IL",
+	"northamerica-northeast1": "CA-QC",
+	"northamerica-northeast2": "CA-ON",
+	"southamerica-east1":      "BR-CS",
+	"southamerica-west1":      "CL-SEN",
+	"us-central1":             "US-MIDW-MISO",
+	"us-east1":                "US-CAR-SCEG",
+	"us-east4":                "US-MIDA-PJM",
+	"us-east5":                "US-MIDA-PJM",
+	"us-south1":               "US-TEX-ERCO",
+	"us-west1":                "US-NW-BPAT",
+	"us-west2":                "US-CAL-LDWP",
+	"us-west3":                "US-NW-PACE",
+	"us-west4":                "US-NW-NEVP",
+
+	// scaleway
+	"fr-par": "FR",
+	"nl-ams": "NL",
+	"pl-waw": "PL",
+}
+
+// zoneMap maps lowercase location prefixes to Electricity Maps zones
+type zoneMap map[string]string
+
+// zone returns the zone of the longest prefix of the location
+func (zones zoneMap) zone(location string) (string, bool) {
+	location = strings.ToLower(location)
+	zone, longest := "", -1
+	for prefix, candidate := range zones {
+		if len(prefix) > longest && primitives.HasLocationPrefix(location, prefix) {
+			zone, longest = candidate, len(prefix)
+		}
+	}
+	return zone, longest >= 0
+}
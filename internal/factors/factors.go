@@ -0,0 +1,298 @@
+// Package factors loads the emission factor files overriding the built-in carbon intensity
+// and power usage effectiveness of the explorers. Factor files are read at startup and on
+// each reload, so the factors of a reporting period can be pinned without rebuilding the
+// exporter.
+//
+// YAML files hold the factors of each provider:
+//
+//	aws:
+//	  pue: 1.135
+//	  locations:
+//	    eu-west-3:
+//	      intensity: 32
+//	      pue: 1.12
+//	    eu-north-1:
+//	      monthly_pue: [1.08, 1.08, 1.09, 1.1, 1.12, 1.15, 1.18, 1.17, 1.13, 1.1, 1.09, 1.08]
+//
+// CSV files hold one location per line, with the provider, location, intensity and pue
+// columns in any order. Other columns, like a source or a comment, are ignored. Empty cells
+// are not overridden and a line without location sets the pue of the provider. Monthly pue
+// can only be set in yaml files.
+//
+//	provider,location,intensity,pue,source
+//	aws,,,1.135,aws sustainability report 2024
+//	aws,eu-west-3,32,1.12,rte eco2mix 2024
+package factors
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"gopkg.in/yaml.v3"
+)
+
+// Factors are the factors of each provider
+type Factors map[string]*ProviderFactors
+
+// ProviderFactors are the factors of a provider
+type ProviderFactors struct {
+	// PUE is the power usage effectiveness of the provider datacenters if not zero
+	PUE float64 `yaml:"pue"`
+	// Locations holds the factors by lowercase location prefix
+	Locations map[string]LocationFactors `yaml:"locations"`
+}
+
+// LocationFactors are the factors of a location. Zero factors are not overridden.
+type LocationFactors struct {
+	// Intensity is the grid carbon intensity in gCO2eq/kWh
+	Intensity float64 `yaml:"intensity"`
+	PUE       float64 `yaml:"pue"`
+	// MonthlyPUE holds the pue of each month, January first, if not empty
+	MonthlyPUE []float64 `yaml:"monthly_pue"`
+}
+
+// Load reads the factor files. Factors of the last files take precedence.
+func Load(paths ...string) (Factors, error) {
+	factors := make(Factors)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read factors file: %w", err)
+		}
+		fileFactors, err := Parse(path, data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		if err := fileFactors.Validate(); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		factors.merge(fileFactors)
+	}
+	return factors, nil
+}
+
+// Parse parses a factors file, in csv if its name ends with .csv and in yaml otherwise
+func Parse(name string, data []byte) (Factors, error) {
+	var factors Factors
+	var err error
+	if strings.EqualFold(filepath.Ext(name), ".csv") {
+		factors, err = parseCSV(data)
+	} else {
+		factors, err = parseYAML(data)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// locations are matched by prefix, whatever their case
+	for _, providerFactors := range factors {
+		locations := make(map[string]LocationFactors, len(providerFactors.Locations))
+		for location, locationFactors := range providerFactors.Locations {
+			locations[strings.ToLower(location)] = locationFactors
+		}
+		providerFactors.Locations = locations
+	}
+	return factors, nil
+}
+
+// parseYAML parses a yaml factors file. Unknown fields are errors.
+func parseYAML(data []byte) (Factors, error) {
+	factors := make(Factors)
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&factors); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("failed to decode factors: %w", err)
+	}
+	for provider, providerFactors := range factors {
+		if providerFactors == nil {
+			factors[provider] = new(ProviderFactors)
+		}
+	}
+	return factors, nil
+}
+
+// csvColumns are the columns of a csv factors file
+var csvColumns = []string{"provider", "location", "intensity", "pue"}
+
+// parseCSV parses a csv factors file
+func parseCSV(data []byte) (Factors, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode factors: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("csv header is missing")
+	}
+
+	columns := make(map[string]int)
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvColumns {
+		if _, found := columns[name]; !found {
+			return nil, fmt.Errorf("csv header must have the %s columns", strings.Join(csvColumns, ", "))
+		}
+	}
+
+	factors := make(Factors)
+	for i, record := range records[1:] {
+		line := i + 2
+		cell := func(name string) string {
+			if columns[name] >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[columns[name]])
+		}
+		value := func(name string) (float64, error) {
+			if cell(name) == "" {
+				return 0, nil
+			}
+			v, err := strconv.ParseFloat(cell(name), 64)
+			if err != nil {
+				return 0, fmt.Errorf("line %d: invalid %s %q", line, name, cell(name))
+			}
+			return v, nil
+		}
+
+		provider, location := cell("provider"), cell("location")
+		if provider == "" {
+			return nil, fmt.Errorf("line %d: provider is not set", line)
+		}
+		intensity, err := value("intensity")
+		if err != nil {
+			return nil, err
+		}
+		pue, err := value("pue")
+		if err != nil {
+			return nil, err
+		}
+
+		providerFactors, found := factors[provider]
+		if !found {
+			providerFactors = &ProviderFactors{Locations: make(map[string]LocationFactors)}
+			factors[provider] = providerFactors
+		}
+		if location == "" {
+			if intensity != 0 {
+				return nil, fmt.Errorf("line %d: intensity must be set by location", line)
+			}
+			providerFactors.PUE = pue
+			continue
+		}
+		providerFactors.Locations[location] = LocationFactors{Intensity: intensity, PUE: pue}
+	}
+	return factors, nil
+}
+
+// Validate checks the factors values and that their providers are registered and know
+// their locations
+func (factors Factors) Validate() error {
+	errs := make([]error, 0)
+	for _, provider := range slices.Sorted(maps.Keys(factors)) {
+		providerFactors := factors[provider]
+		registered, found := cloudcarbonexporter.LookupExplorerProvider(provider)
+		if !found {
+			errs = append(errs, fmt.Errorf("cloud provider %s is not supported", provider))
+			continue
+		}
+		if providerFactors.PUE != 0 && providerFactors.PUE < 1 {
+			errs = append(errs, fmt.Errorf("%s: pue must be greater than or equal to 1", provider))
+		}
+		for _, location := range slices.Sorted(maps.Keys(providerFactors.Locations)) {
+			locationFactors := providerFactors.Locations[location]
+			if !registered.KnowsLocation(location) {
+				errs = append(errs, fmt.Errorf("%s: location %s is unknown", provider, location))
+			}
+			if locationFactors.Intensity < 0 {
+				errs = append(errs, fmt.Errorf("%s: intensity of %s must be positive", provider, location))
+			}
+			if locationFactors.PUE != 0 && locationFactors.PUE < 1 {
+				errs = append(errs, fmt.Errorf("%s: pue of %s must be greater than or equal to 1", provider, location))
+			}
+			if len(locationFactors.MonthlyPUE) != 0 && len(locationFactors.MonthlyPUE) != 12 {
+				errs = append(errs, fmt.Errorf("%s: monthly pue of %s must have 12 values", provider, location))
+			}
+			if slices.ContainsFunc(locationFactors.MonthlyPUE, func(pue float64) bool { return pue < 1 }) {
+				errs = append(errs, fmt.Errorf("%s: monthly pue of %s must be greater than or equal to 1", provider, location))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// merge adds the factors to the ones of factors, overriding the factors they both set
+func (factors Factors) merge(other Factors) {
+	for provider, otherFactors := range other {
+		providerFactors, found := factors[provider]
+		if !found {
+			providerFactors = &ProviderFactors{Locations: make(map[string]LocationFactors)}
+			factors[provider] = providerFactors
+		}
+		if otherFactors.PUE != 0 {
+			providerFactors.PUE = otherFactors.PUE
+		}
+		for location, otherLocation := range otherFactors.Locations {
+			locationFactors := providerFactors.Locations[location]
+			if otherLocation.Intensity != 0 {
+				locationFactors.Intensity = otherLocation.Intensity
+			}
+			if otherLocation.PUE != 0 {
+				locationFactors.PUE = otherLocation.PUE
+			}
+			if len(otherLocation.MonthlyPUE) != 0 {
+				locationFactors.MonthlyPUE = otherLocation.MonthlyPUE
+			}
+			providerFactors.Locations[location] = locationFactors
+		}
+	}
+}
+
+// Apply sets the factors of the provider in the explorer settings. The explorer settings
+// take precedence: its pue over the provider pue and its intensity overrides over the
+// locations intensity.
+func (factors Factors) Apply(provider string, settings *cloudcarbonexporter.ExplorerSettings) {
+	providerFactors, found := factors[provider]
+	if !found {
+		return
+	}
+
+	if settings.PUE == 0 {
+		settings.PUE = providerFactors.PUE
+	}
+
+	intensity := make(map[string]float64)
+	locationPUE := make(map[string]float64)
+	monthlyPUE := make(map[string][]float64)
+	for location, locationFactors := range providerFactors.Locations {
+		if locationFactors.Intensity != 0 {
+			intensity[location] = locationFactors.Intensity
+		}
+		if locationFactors.PUE != 0 {
+			locationPUE[location] = locationFactors.PUE
+		}
+		if len(locationFactors.MonthlyPUE) != 0 {
+			monthlyPUE[location] = locationFactors.MonthlyPUE
+		}
+	}
+	for location, carbonIntensity := range settings.Intensity {
+		intensity[strings.ToLower(location)] = carbonIntensity
+	}
+	maps.Copy(locationPUE, settings.LocationPUE)
+	maps.Copy(monthlyPUE, settings.MonthlyPUE)
+
+	settings.Intensity = intensity
+	settings.LocationPUE = locationPUE
+	settings.MonthlyPUE = monthlyPUE
+}
@@ -0,0 +1,89 @@
+package factors
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+
+	_ "github.com/superdango/cloud-carbon-exporter/internal/aws"
+	_ "github.com/superdango/cloud-carbon-exporter/internal/gcp"
+	_ "github.com/superdango/cloud-carbon-exporter/internal/scw"
+)
+
+func TestLoad(t *testing.T) {
+	factors, err := Load("testdata/factors.yaml", "testdata/factors.csv")
+	assert.NoError(t, err)
+
+	assert.Equal(t, &ProviderFactors{
+		PUE: 1.135,
+		Locations: map[string]LocationFactors{
+			"eu-west-3":  {Intensity: 30, PUE: 1.12},
+			"eu-west-1":  {PUE: 1.1},
+			"eu-north-1": {MonthlyPUE: []float64{1.08, 1.08, 1.09, 1.1, 1.12, 1.15, 1.18, 1.17, 1.13, 1.1, 1.09, 1.08}},
+		},
+	}, factors["aws"], "last files take precedence")
+	assert.Equal(t, map[string]LocationFactors{"europe-west1-b": {PUE: 1.09}}, factors["gcp"].Locations)
+	assert.Equal(t, 1.37, factors["scw"].PUE)
+	assert.Equal(t, LocationFactors{PUE: 1.25}, factors["scw"].Locations["fr-par-2"])
+
+	_, err = Load("testdata/missing.yaml")
+	assert.Error(t, err)
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse("factors.yaml", []byte("aws:\n  locations:\n    eu-west-3:\n      intensty: 32\n"))
+	assert.ErrorContains(t, err, "field intensty not found")
+
+	_, err = Parse("factors.csv", []byte("provider,location,pue\naws,eu-west-3,1.1\n"))
+	assert.ErrorContains(t, err, "csv header must have the provider, location, intensity, pue columns")
+
+	_, err = Parse("factors.csv", []byte("provider,location,intensity,pue\naws,eu-west-3,low,\n"))
+	assert.EqualError(t, err, `line 2: invalid intensity "low"`)
+
+	_, err = Parse("factors.csv", []byte("provider,location,intensity,pue\naws,,32,\n"))
+	assert.EqualError(t, err, "line 2: intensity must be set by location")
+}
+
+func TestValidate(t *testing.T) {
+	factors, err := Parse("factors.yaml", []byte(`
+aws:
+  pue: 0.9
+  locations:
+    eu-west-30:
+      intensity: 32
+    eu-west-3a:
+      intensity: -1
+    eu-north-1:
+      monthly_pue: [1.1, 0.9]
+azure:
+  pue: 1.2
+`))
+	assert.NoError(t, err)
+	assert.EqualError(t, factors.Validate(), `aws: pue must be greater than or equal to 1
+aws: monthly pue of eu-north-1 must have 12 values
+aws: monthly pue of eu-north-1 must be greater than or equal to 1
+aws: location eu-west-30 is unknown
+aws: intensity of eu-west-3a must be positive
+cloud provider azure is not supported`)
+}
+
+func TestApply(t *testing.T) {
+	factors, err := Load("testdata/factors.yaml")
+	assert.NoError(t, err)
+
+	settings := &cloudcarbonexporter.ExplorerSettings{Intensity: map[string]float64{"EU-West-3": 20, "eu-central-1": 300}}
+	factors.Apply("aws", settings)
+	assert.Equal(t, 1.135, settings.PUE)
+	assert.Equal(t, map[string]float64{"eu-west-3": 20, "eu-central-1": 300}, settings.Intensity, "explorer overrides take precedence")
+	assert.Equal(t, map[string]float64{"eu-west-1": 1.1}, settings.LocationPUE)
+	assert.Equal(t, map[string][]float64{"eu-north-1": {1.08, 1.08, 1.09, 1.1, 1.12, 1.15, 1.18, 1.17, 1.13, 1.1, 1.09, 1.08}}, settings.MonthlyPUE)
+
+	settings = &cloudcarbonexporter.ExplorerSettings{PUE: 1.3}
+	factors.Apply("aws", settings)
+	assert.Equal(t, 1.3, settings.PUE, "explorer pue takes precedence")
+
+	settings = &cloudcarbonexporter.ExplorerSettings{}
+	factors.Apply("scw", settings)
+	assert.Equal(t, &cloudcarbonexporter.ExplorerSettings{}, settings)
+}
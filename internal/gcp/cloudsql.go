@@ -24,42 +24,68 @@ func (sqlExplorer *CloudSQLExplorer) init(ctx context.Context, explorer *Explore
 	sqlExplorer.Explorer = explorer
 	sqlExplorer.mu = new(sync.Mutex)
 
-	sqlExplorer.client, err = cloudsql.NewService(ctx)
+	sqlExplorer.client, err = cloudsql.NewService(ctx, explorer.clientOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create cloudsql service: %w", err)
 	}
 
-	sqlExplorer.cache.SetDynamicIfNotExists(ctx, "sql_instances_average_cpu", func(ctx context.Context) (any, error) {
-		return sqlExplorer.ListSQLInstanceCPUAverage(cloudcarbonexporter.WrapCtx(ctx))
-	}, 5*time.Minute)
-
 	return nil
 }
 
-func (sqlExplorer *CloudSQLExplorer) collectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact) error {
-	ctx.IncrCalls()
-	return sqlExplorer.client.Instances.List(sqlExplorer.ProjectID).Context(ctx).Pages(ctx, func(instancesList *cloudsql.InstancesListResponse) error {
-		for _, instance := range instancesList.Items {
-			var energy cloudcarbonexporter.Energy
+func (sqlExplorer *CloudSQLExplorer) collectImpacts(ctx cloudcarbonexporter.Context, project *Project, impacts chan *cloudcarbonexporter.Impact) error {
+	err := sqlExplorer.cache.SetDynamicIfNotExists(ctx, "sql_instances_average_cpu/"+project.ID, func(ctx context.Context) (any, error) {
+		return sqlExplorer.ListSQLInstanceCPUAverage(cloudcarbonexporter.WrapCtx(ctx), project.ID)
+	}, sqlExplorer.CacheTTL)
+	if err != nil {
+		return fmt.Errorf("failed to set sql instances average cpu cache: %w", err)
+	}
 
+	ctx.IncrCalls("sqladmin/v1:ListInstances")
+	return sqlExplorer.client.Instances.List(project.ID).Context(ctx).Pages(ctx, func(instancesList *cloudsql.InstancesListResponse) error {
+		for _, instance := range instancesList.Items {
+			if !sqlExplorer.exploresRegion(instance.Region) {
+				continue
+			}
 			machineTypeName := strings.TrimPrefix(instance.Settings.Tier, "db-")
 			machineType := sqlExplorer.machineTypes.Get(machineTypeName)
 			if machineType.Name == "unknown" {
 				return fmt.Errorf("unknown sql machine type: %s", machineTypeName)
 			}
 
-			cpuUsage, err := sqlExplorer.GetCloudSQLInstanceAverageCPUUsage(ctx, instance.Name)
+			cpuUsage, err := sqlExplorer.GetCloudSQLInstanceAverageCPUUsage(ctx, project.ID, instance.Name)
 			if err != nil {
 				return fmt.Errorf("failed to get cloudsql intance cpu usage: %w", err)
 			}
 
+			processor := primitives.LookupProcessorByName(machineType.CPUPlatform)
+
+			impact := &cloudcarbonexporter.Impact{
+				Inputs: cloudcarbonexporter.ImpactInputs{
+					InstanceType: instance.Settings.Tier,
+					VCPU:         machineType.VCPU,
+					MemoryGB:     machineType.Memory,
+					Processor:    processor.Inputs(),
+					CPUAverage:   cpuUsage,
+				},
+				Labels: cloudcarbonexporter.MergeLabels(map[string]string{
+					"kind":          "sql/Instance",
+					"instance_name": instance.Name,
+					"zone":          instance.GceZone,
+					"region":        instance.Region,
+					"location":      instance.Region,
+				}, project.Labels()),
+				Tags: instance.Settings.UserLabels,
+			}
+
 			// CPU
-			energy += primitives.LookupProcessorByName(machineType.CPUPlatform).EstimateCPUEnergy(machineType.VCPU, cpuUsage)
-			cpuEmbodied := primitives.EstimateCPUEmbodiedEmissions(machineType.VCPU)
+			impact.AddComponent(cloudcarbonexporter.ComponentCPU,
+				processor.EstimateCPUEnergy(machineType.VCPU, cpuUsage),
+				primitives.EstimateCPUEmbodiedEmissions(machineType.VCPU))
 
 			// Memory
-			energy += primitives.EstimateMemoryEnergy(machineType.Memory)
-			memoryEmbodied := primitives.EstimateMemoryEmbodiedEmissions(machineType.Memory)
+			impact.AddComponent(cloudcarbonexporter.ComponentMemory,
+				primitives.EstimateMemoryEnergy(machineType.Memory),
+				primitives.EstimateMemoryEmbodiedEmissions(machineType.Memory))
 
 			// Disk
 			diskEnergy := cloud.EstimateSSDBlockStorageEnergy(float64(instance.Settings.DataDiskSizeGb))
@@ -68,33 +94,20 @@ func (sqlExplorer *CloudSQLExplorer) collectImpacts(ctx cloudcarbonexporter.Cont
 				diskEnergy = cloud.EstimateHDDBlockStorageEnergy(float64(instance.Settings.DataDiskSizeGb))
 				diskEmbodied = primitives.EstimateEmbodiedHDDEmissions(float64(instance.Settings.DataDiskSizeGb))
 			}
-			energy += diskEnergy
-
-			impacts <- &cloudcarbonexporter.Impact{
-				Energy:            cloudcarbonexporter.Energy(energy),
-				EmbodiedEmissions: cloudcarbonexporter.CombineEmissionsOverTime(cpuEmbodied, memoryEmbodied, diskEmbodied),
-				Labels: cloudcarbonexporter.MergeLabels(
-					map[string]string{
-						"kind":          "sql/Instance",
-						"instance_name": instance.Name,
-						"zone":          instance.GceZone,
-						"region":        instance.Region,
-						"location":      instance.Region,
-					},
-					instance.Settings.UserLabels,
-				),
-			}
+			impact.AddComponent(cloudcarbonexporter.ComponentStorage, diskEnergy, diskEmbodied)
+
+			impacts <- impact
 		}
 		return nil
 	})
 }
 
-func (sqlExplorer *CloudSQLExplorer) GetCloudSQLInstanceAverageCPUUsage(ctx context.Context, instanceName string) (float64, error) {
+func (sqlExplorer *CloudSQLExplorer) GetCloudSQLInstanceAverageCPUUsage(ctx context.Context, projectID string, instanceName string) (float64, error) {
 	// locking mutex prevents monitoring requests sent in parallel
 	sqlExplorer.mu.Lock()
 	defer sqlExplorer.mu.Unlock()
 
-	entry, err := sqlExplorer.cache.Get(ctx, "sql_instances_average_cpu")
+	entry, err := sqlExplorer.cache.Get(ctx, "sql_instances_average_cpu/"+projectID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get explorer cloudsql instance average cpu cache: %w", err)
 	}
@@ -110,17 +123,17 @@ func (sqlExplorer *CloudSQLExplorer) GetCloudSQLInstanceAverageCPUUsage(ctx cont
 	return instanceAverageCPU * 100, nil
 }
 
-// ListSQLInstanceCPUAverage returns the 10 minutes average cpu for all sql instances in the region
-func (sqlExplorer *CloudSQLExplorer) ListSQLInstanceCPUAverage(ctx cloudcarbonexporter.Context) (map[string]float64, error) {
+// ListSQLInstanceCPUAverage returns the 10 minutes average cpu for all sql instances in the project
+func (sqlExplorer *CloudSQLExplorer) ListSQLInstanceCPUAverage(ctx cloudcarbonexporter.Context, projectID string) (map[string]float64, error) {
 	promqlExpression := `avg by (database_id)(avg_over_time(cloudsql_googleapis_com:database_cpu_utilization{monitored_resource="cloudsql_database"}[5m]))`
 	period := 10 * time.Minute
 
-	instanceList, err := sqlExplorer.query(ctx, promqlExpression, "database_id", period)
+	instanceList, err := sqlExplorer.query(ctx, projectID, promqlExpression, "database_id", period)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for cloudsql instance monitoring data: %w", err)
 	}
 
-	ctx.IncrCalls()
+	ctx.IncrCalls("monitoring/v1:QueryRange")
 
 	return instanceList, nil
 }
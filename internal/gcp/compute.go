@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -27,44 +29,42 @@ func (instanceExplorer *InstancesExplorer) init(ctx context.Context, explorer *E
 	instanceExplorer.Explorer = explorer
 	instanceExplorer.mu = new(sync.Mutex)
 
-	instanceExplorer.client, err = compute.NewInstancesRESTClient(ctx)
+	instanceExplorer.client, err = compute.NewInstancesRESTClient(ctx, explorer.clientOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create compute instances rest client: %w", err)
 	}
 
-	explorer.cache.SetDynamicIfNotExists(ctx, "instances_average_cpu", func(ctx context.Context) (any, error) {
-		return instanceExplorer.ListInstanceCPUAverage(cloudcarbonexporter.WrapCtx(ctx))
-	}, 5*time.Minute)
-
 	return nil
 }
 
-func (instanceExplorer *InstancesExplorer) collectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact) error {
-	discoveryMap, err := instanceExplorer.GetCachedDiscoveryMap(ctx)
+func (instanceExplorer *InstancesExplorer) collectImpacts(ctx cloudcarbonexporter.Context, project *Project, impacts chan *cloudcarbonexporter.Impact) error {
+	err := instanceExplorer.cache.SetDynamicIfNotExists(ctx, "instances_average_cpu/"+project.ID, func(ctx context.Context) (any, error) {
+		return instanceExplorer.ListInstanceCPUAverage(cloudcarbonexporter.WrapCtx(ctx), project.ID)
+	}, instanceExplorer.CacheTTL)
 	if err != nil {
-		return fmt.Errorf("failed to get cached discovery map: %w", err)
+		return fmt.Errorf("failed to set instances average cpu cache: %w", err)
 	}
 
 	errg := new(errgroup.Group)
 
-	for _, zone := range discoveryMap["zones"] {
+	for _, zone := range project.Assets["zones"] {
 		errg.Go(func() error {
-			return instanceExplorer.collectZoneImpacts(ctx, zone, impacts)
+			return instanceExplorer.collectZoneImpacts(ctx, project, zone, impacts)
 		})
 	}
 	return errg.Wait()
 }
 
-func (instanceExplorer *InstancesExplorer) collectZoneImpacts(ctx cloudcarbonexporter.Context, zone string, impacts chan *cloudcarbonexporter.Impact) error {
+func (instanceExplorer *InstancesExplorer) collectZoneImpacts(ctx cloudcarbonexporter.Context, project *Project, zone string, impacts chan *cloudcarbonexporter.Impact) error {
 	instancesIter := instanceExplorer.client.List(ctx, &computepb.ListInstancesRequest{
-		Project: instanceExplorer.ProjectID,
+		Project: project.ID,
 		Zone:    zone,
 	})
 
 	for {
 		instance, err := instancesIter.Next()
 		if err == iterator.Done {
-			ctx.IncrCalls()
+			ctx.IncrCalls("compute/apiv1:ListInstances")
 			break
 		}
 		if err != nil {
@@ -80,55 +80,78 @@ func (instanceExplorer *InstancesExplorer) collectZoneImpacts(ctx cloudcarbonexp
 			continue
 		}
 
-		cpuUsage, err := instanceExplorer.GetInstanceAverageCPULoad(ctx, instanceName)
+		cpuUsage, err := instanceExplorer.GetInstanceAverageCPULoad(ctx, project.ID, instanceName)
 		if err != nil {
 			return err
 		}
 
+		impact := &cloudcarbonexporter.Impact{
+			Inputs: cloudcarbonexporter.ImpactInputs{
+				InstanceType: machineType.Name,
+				VCPU:         machineType.VCPU,
+				MemoryGB:     machineType.Memory,
+				Processor:    processor.Inputs(),
+				CPUAverage:   cpuUsage,
+			},
+			Labels: cloudcarbonexporter.MergeLabels(map[string]string{
+				"kind":          "compute/Instance",
+				"instance_name": instanceName,
+				"zone":          lastURLPathFragment(instance.GetZone()),
+				"region":        instanceExplorer.gcpZones.GetRegion(lastURLPathFragment(instance.GetZone())),
+				"location":      instanceExplorer.gcpZones.GetRegion(lastURLPathFragment(instance.GetZone())),
+			}, project.Labels()),
+			Tags: instance.Labels,
+		}
+
 		// CPU
-		energy := processor.EstimateCPUEnergy(machineType.VCPU, cpuUsage)
-		cpuEmbodied := primitives.EstimateCPUEmbodiedEmissions(machineType.VCPU)
+		impact.AddComponent(cloudcarbonexporter.ComponentCPU,
+			processor.EstimateCPUEnergy(machineType.VCPU, cpuUsage),
+			primitives.EstimateCPUEmbodiedEmissions(machineType.VCPU))
 
 		// Memory
-		energy += primitives.EstimateMemoryEnergy(machineType.Memory)
-		memoryEmbodied := primitives.EstimateMemoryEmbodiedEmissions(machineType.Memory)
+		impact.AddComponent(cloudcarbonexporter.ComponentMemory,
+			primitives.EstimateMemoryEnergy(machineType.Memory),
+			primitives.EstimateMemoryEmbodiedEmissions(machineType.Memory))
 
 		// Disk
-		diskEmbodied := cloudcarbonexporter.ZeroEmissions
 		for _, disk := range instance.Disks {
 			// Physical disks (SCRATCH) are directly attached to the instance
 			// https://cloud.google.com/compute/docs/disks/local-ssd
 			if *disk.Type == "SCRATCH" {
-				energy += primitives.EstimateLocalSSDEnergy(1)
-				diskEmbodied = cloudcarbonexporter.CombineEmissionsOverTime(diskEmbodied, primitives.EstimateEmbodiedSSDEmissions(375))
+				impact.AddComponent(cloudcarbonexporter.ComponentLocalDisk,
+					primitives.EstimateLocalSSDEnergy(1),
+					primitives.EstimateEmbodiedSSDEmissions(375))
 			}
 		}
 
-		impacts <- &cloudcarbonexporter.Impact{
-			Energy:            cloudcarbonexporter.Energy(energy),
-			EmbodiedEmissions: cloudcarbonexporter.CombineEmissionsOverTime(cpuEmbodied, memoryEmbodied, diskEmbodied),
-			Labels: cloudcarbonexporter.MergeLabels(
-				map[string]string{
-					"kind":          "compute/Instance",
-					"instance_name": instanceName,
-					"zone":          lastURLPathFragment(instance.GetZone()),
-					"region":        instanceExplorer.gcpZones.GetRegion(lastURLPathFragment(instance.GetZone())),
-					"location":      instanceExplorer.gcpZones.GetRegion(lastURLPathFragment(instance.GetZone())),
-				},
-				instance.Labels,
-			),
+		// GPU, attached to the instance or part of the accelerator optimized machine type
+		accelerators := make(map[string]float64)
+		for _, accelerator := range instance.GetGuestAccelerators() {
+			accelerators[acceleratorModel(lastURLPathFragment(accelerator.GetAcceleratorType()))] += float64(accelerator.GetAcceleratorCount())
+		}
+		if len(accelerators) == 0 && machineType.GPU > 0 {
+			accelerators[machineType.GPUType] = machineType.GPU
 		}
+		for model, count := range accelerators {
+			gpu := primitives.LookupGPUByName(model)
+			impact.Inputs.GPU = gpu.Inputs(count, primitives.GPUAverageUsage)
+			impact.AddComponent(cloudcarbonexporter.ComponentGPU,
+				gpu.EstimateGPUEnergy(count, primitives.GPUAverageUsage),
+				primitives.EstimateGPUEmbodiedEmissions(count))
+		}
+
+		impacts <- impact
 	}
 
 	return nil
 }
 
-func (instanceExplorer *InstancesExplorer) GetInstanceAverageCPULoad(ctx context.Context, instanceName string) (float64, error) {
+func (instanceExplorer *InstancesExplorer) GetInstanceAverageCPULoad(ctx context.Context, projectID string, instanceName string) (float64, error) {
 	// locking mutex prevents monitoring requests sent in parallel
 	instanceExplorer.mu.Lock()
 	defer instanceExplorer.mu.Unlock()
 
-	entry, err := instanceExplorer.cache.Get(ctx, "instances_average_cpu")
+	entry, err := instanceExplorer.cache.Get(ctx, "instances_average_cpu/"+projectID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get explorer instance average cpu cache: %w", err)
 	}
@@ -144,17 +167,17 @@ func (instanceExplorer *InstancesExplorer) GetInstanceAverageCPULoad(ctx context
 	return instanceAverageCPU * 100, nil
 }
 
-// ListInstanceCPUAverage returns the 10 minutes average cpu for all instances in the region
-func (explorer *InstancesExplorer) ListInstanceCPUAverage(ctx cloudcarbonexporter.Context) (map[string]float64, error) {
+// ListInstanceCPUAverage returns the 10 minutes average cpu for all instances in the project
+func (explorer *InstancesExplorer) ListInstanceCPUAverage(ctx cloudcarbonexporter.Context, projectID string) (map[string]float64, error) {
 	promqlExpression := `avg by (instance_name)(rate(compute_googleapis_com:instance_cpu_usage_time{monitored_resource="gce_instance"}[5m]))`
 	period := 10 * time.Minute
 
-	instanceList, err := explorer.query(ctx, promqlExpression, "instance_name", period)
+	instanceList, err := explorer.query(ctx, projectID, promqlExpression, "instance_name", period)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for instance monitoring data: %w", err)
 	}
 
-	ctx.IncrCalls()
+	ctx.IncrCalls("monitoring/v1:QueryRange")
 
 	return instanceList, nil
 }
@@ -166,7 +189,7 @@ type DisksExplorer struct {
 
 func (disksExplorer *DisksExplorer) init(ctx context.Context, explorer *Explorer) (err error) {
 	disksExplorer.Explorer = explorer
-	disksExplorer.client, err = compute.NewDisksRESTClient(ctx)
+	disksExplorer.client, err = compute.NewDisksRESTClient(ctx, explorer.clientOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create disks rest client: %w", err)
 	}
@@ -174,32 +197,27 @@ func (disksExplorer *DisksExplorer) init(ctx context.Context, explorer *Explorer
 	return nil
 }
 
-func (disksExplorer *DisksExplorer) collectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact) error {
-	discoveryMap, err := disksExplorer.GetCachedDiscoveryMap(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get cached discovery map: %w", err)
-	}
-
+func (disksExplorer *DisksExplorer) collectImpacts(ctx cloudcarbonexporter.Context, project *Project, impacts chan *cloudcarbonexporter.Impact) error {
 	errg := new(errgroup.Group)
 
-	for _, zone := range discoveryMap["zones"] {
+	for _, zone := range project.Assets["zones"] {
 		errg.Go(func() error {
-			return disksExplorer.collectZoneImpacts(ctx, zone, impacts)
+			return disksExplorer.collectZoneImpacts(ctx, project, zone, impacts)
 		})
 	}
 	return errg.Wait()
 }
 
-func (disksExplorer *DisksExplorer) collectZoneImpacts(ctx cloudcarbonexporter.Context, zone string, impacts chan *cloudcarbonexporter.Impact) error {
+func (disksExplorer *DisksExplorer) collectZoneImpacts(ctx cloudcarbonexporter.Context, project *Project, zone string, impacts chan *cloudcarbonexporter.Impact) error {
 	disksIter := disksExplorer.client.List(ctx, &computepb.ListDisksRequest{
-		Project: disksExplorer.ProjectID,
+		Project: project.ID,
 		Zone:    zone,
 	})
 
 	for {
 		disk, err := disksIter.Next()
 		if err == iterator.Done {
-			ctx.IncrCalls()
+			ctx.IncrCalls("compute/apiv1:ListDisks")
 			break
 		}
 		if err != nil {
@@ -227,19 +245,18 @@ func (disksExplorer *DisksExplorer) collectZoneImpacts(ctx cloudcarbonexporter.C
 		energy = energy * cloudcarbonexporter.Energy(replicas)
 		embodied.Emissions = embodied.Emissions * cloudcarbonexporter.Emissions(replicas)
 
-		impacts <- &cloudcarbonexporter.Impact{
-			Energy:            cloudcarbonexporter.Energy(energy),
-			EmbodiedEmissions: embodied,
-			Labels: cloudcarbonexporter.MergeLabels(
-				map[string]string{
-					"kind":      "compute/Disk",
-					"disk_name": diskName,
-					"zone":      lastURLPathFragment(disk.GetZone()),
-					"location":  disksExplorer.gcpZones.GetRegion(lastURLPathFragment(disk.GetZone())),
-				},
-				disk.Labels,
-			),
+		impact := &cloudcarbonexporter.Impact{
+			Labels: cloudcarbonexporter.MergeLabels(map[string]string{
+				"kind":      "compute/Disk",
+				"disk_name": diskName,
+				"zone":      lastURLPathFragment(disk.GetZone()),
+				"location":  disksExplorer.gcpZones.GetRegion(lastURLPathFragment(disk.GetZone())),
+			}, project.Labels()),
+			Tags: disk.Labels,
 		}
+		impact.AddComponent(cloudcarbonexporter.ComponentStorage, energy, embodied)
+
+		impacts <- impact
 	}
 
 	return nil
@@ -252,43 +269,38 @@ type RegionDisksExplorer struct {
 
 func (regionDisksExplorer *RegionDisksExplorer) init(ctx context.Context, explorer *Explorer) (err error) {
 	regionDisksExplorer.Explorer = explorer
-	regionDisksExplorer.client, err = compute.NewRegionDisksRESTClient(ctx)
+	regionDisksExplorer.client, err = compute.NewRegionDisksRESTClient(ctx, explorer.clientOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create region disk rest client: %w", err)
 	}
 	return nil
 }
 
-func (regionDisksExplorer *RegionDisksExplorer) collectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact) (err error) {
-	discoveryMap, err := regionDisksExplorer.GetCachedDiscoveryMap(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get cached discovery map: %w", err)
-	}
-
+func (regionDisksExplorer *RegionDisksExplorer) collectImpacts(ctx cloudcarbonexporter.Context, project *Project, impacts chan *cloudcarbonexporter.Impact) (err error) {
 	errg := new(errgroup.Group)
 
-	for _, region := range discoveryMap["regions"] {
+	for _, region := range project.Assets["regions"] {
 		errg.Go(func() error {
-			return regionDisksExplorer.collectRegionImpacts(ctx, region, impacts)
+			return regionDisksExplorer.collectRegionImpacts(ctx, project, region, impacts)
 		})
 	}
 	return errg.Wait()
 }
 
-func (regionDisksExplorer *RegionDisksExplorer) collectRegionImpacts(ctx cloudcarbonexporter.Context, region string, impacts chan *cloudcarbonexporter.Impact) error {
+func (regionDisksExplorer *RegionDisksExplorer) collectRegionImpacts(ctx cloudcarbonexporter.Context, project *Project, region string, impacts chan *cloudcarbonexporter.Impact) error {
 	if region == "global" {
 		return nil
 	}
 
 	regionDisksIter := regionDisksExplorer.client.List(ctx, &computepb.ListRegionDisksRequest{
-		Project: regionDisksExplorer.ProjectID,
+		Project: project.ID,
 		Region:  region,
 	})
 
 	for {
 		disk, err := regionDisksIter.Next()
 		if err == iterator.Done {
-			ctx.IncrCalls()
+			ctx.IncrCalls("compute/apiv1:ListRegionDisks")
 			break
 		}
 		if err != nil {
@@ -315,19 +327,27 @@ func (regionDisksExplorer *RegionDisksExplorer) collectRegionImpacts(ctx cloudca
 		energy = energy * cloudcarbonexporter.Energy(replicas)
 		embodied.Emissions = embodied.Emissions * cloudcarbonexporter.Emissions(replicas)
 
-		impacts <- &cloudcarbonexporter.Impact{
-			Energy:            cloudcarbonexporter.Energy(energy),
-			EmbodiedEmissions: embodied,
-			Labels: cloudcarbonexporter.MergeLabels(
-				map[string]string{
-					"kind":      "compute/RegionDisk",
-					"disk_name": diskName,
-					"location":  region,
-				},
-				disk.Labels,
-			),
+		impact := &cloudcarbonexporter.Impact{
+			Labels: cloudcarbonexporter.MergeLabels(map[string]string{
+				"kind":      "compute/RegionDisk",
+				"disk_name": diskName,
+				"location":  region,
+			}, project.Labels()),
+			Tags: disk.Labels,
 		}
+		impact.AddComponent(cloudcarbonexporter.ComponentStorage, energy, embodied)
+
+		impacts <- impact
 	}
 
 	return nil
 }
+
+// acceleratorModel returns the gpu model name of a gcp accelerator type. For example,
+// nvidia-tesla-t4 becomes NVIDIA T4 and nvidia-a100-80gb becomes NVIDIA A100 80GB.
+func acceleratorModel(acceleratorType string) string {
+	fragments := strings.Split(strings.ToUpper(acceleratorType), "-")
+	return strings.Join(slices.DeleteFunc(fragments, func(fragment string) bool {
+		return fragment == "TESLA"
+	}), " ")
+}
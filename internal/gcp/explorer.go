@@ -2,12 +2,16 @@ package gcp
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"net/url"
 	"reflect"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	asset "cloud.google.com/go/asset/apiv1"
@@ -23,6 +27,8 @@ import (
 
 	"google.golang.org/api/iterator"
 	"google.golang.org/api/monitoring/v1"
+	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
 type Asset string
@@ -30,25 +36,67 @@ type Asset string
 type AssetsDiscoveryMap map[Asset][]string
 
 type SubExplorer interface {
-	collectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact) error
+	collectImpacts(ctx cloudcarbonexporter.Context, project *Project, impacts chan *cloudcarbonexporter.Impact) error
 	init(ctx context.Context, explorer *Explorer) error
 }
 
 type Explorer struct {
-	monitoringClient   *monitoring.Service
-	ProjectID          string
+	monitoringClient *monitoring.Service
+	// ProjectID is the project explored when Scope is not set. Otherwise, it is only used
+	// to list zones and defaults to the first project discovered in the scope.
+	ProjectID string
+	// Scope restricts the discovery to projects/<id>, folders/<id> or organizations/<id>
+	Scope string
+	// CredentialsFile is the service account key or credentials configuration used by all
+	// clients. Application Default Credentials are used if empty.
+	CredentialsFile string
+	// Regions restricts the exploration to the regions. Global resources are always explored.
+	Regions []string
+	// Services restricts the exploration to the supported services. All supported services
+	// are explored if empty.
+	Services []string
+	// IntensityOverrides replaces the grid carbon intensity (gCO2eq/kWh) of the locations
+	IntensityOverrides map[string]float64
+	// IntensityProviders are the time-varying grid carbon intensity providers, tried in order
+	// after the overrides and before the static intensity map
+	IntensityProviders []cloudcarbonexporter.IntensityProvider
+	// CarbonFreeEnergy replaces the carbon-free energy share (0 to 1) of the locations used
+	// for market-based emissions, Google CFE by default
+	CarbonFreeEnergy map[string]float64
+	// CacheTTL is how long discovery and monitoring data are kept in cache before being
+	// queried again
+	CacheTTL time.Duration
+	// Transport sends the requests of all clients if not nil, e.g. to record their
+	// exchanges. Clients then use the REST apis instead of grpc.
+	Transport http.RoundTripper
+	// Offline is true if Transport serves recorded exchanges. Requests are not authenticated.
+	Offline bool
+
+	httpClient         *http.Client
 	cache              *cache.Memory
 	gcpZones           Zones
 	carbonIntensityMap carbon.IntensityMap
+	wue                carbon.WaterMap
+	gridWaterIntensity carbon.WaterMap
+	carbonFreeEnergy   carbon.CarbonFreeEnergyMap
+	pue                primitives.PUEModel
+	locations          *atomic.Pointer[carbon.LocationModel]
 
 	machineTypes machinetypes.MachineTypes
 
 	subExplorers map[Asset]SubExplorer
+	readiness    *cloudcarbonexporter.Readiness
 }
 
 func NewExplorer() *Explorer {
-	return &Explorer{
+	explorer := &Explorer{
+		CacheTTL:           5 * time.Minute,
+		pue:                primitives.NewPUEModel("gcp"),
 		carbonIntensityMap: carbon.NewGCPCarbonIntensityMap(),
+		wue:                carbon.NewGCPWUEMap(),
+		gridWaterIntensity: carbon.NewGridWaterIntensityMap(),
+		carbonFreeEnergy:   carbon.NewGCPCarbonFreeEnergyMap(),
+		locations:          new(atomic.Pointer[carbon.LocationModel]),
 		machineTypes:       machinetypes.MustLoad(),
 		subExplorers: map[Asset]SubExplorer{
 			"compute.googleapis.com/Instance":   new(InstancesExplorer),
@@ -58,6 +106,75 @@ func NewExplorer() *Explorer {
 			"sqladmin.googleapis.com/Instance":  new(CloudSQLExplorer),
 		},
 	}
+
+	explorer.readiness = explorer.newReadiness()
+
+	return explorer
+}
+
+type ExplorerOption func(*Explorer)
+
+// WithPUEModel sets the power usage effectiveness of the datacenters by location and month
+func WithPUEModel(model primitives.PUEModel) ExplorerOption {
+	return func(e *Explorer) {
+		e.pue = model
+	}
+}
+
+func (explorer *Explorer) Configure(opts ...ExplorerOption) *Explorer {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(explorer)
+		}
+	}
+
+	return explorer
+}
+
+// newReadiness returns the readiness checks of the sub explorers and discovery
+func (explorer *Explorer) newReadiness() *cloudcarbonexporter.Readiness {
+	return cloudcarbonexporter.NewReadiness(append(explorer.SupportedServices(), "zones", "monitoring", "discovery")...)
+}
+
+// credentialsOptions returns the credentials options of the gcp api clients
+func (explorer *Explorer) credentialsOptions() []option.ClientOption {
+	if explorer.CredentialsFile == "" {
+		return nil
+	}
+	return []option.ClientOption{option.WithCredentialsFile(explorer.CredentialsFile)}
+}
+
+// clientOptions returns the options of all gcp api clients
+func (explorer *Explorer) clientOptions() []option.ClientOption {
+	if explorer.httpClient != nil {
+		return []option.ClientOption{option.WithHTTPClient(explorer.httpClient)}
+	}
+	return explorer.credentialsOptions()
+}
+
+// initHTTPClient creates the http client of all gcp api clients sending their requests
+// through the transport. The requests are authenticated unless the explorer is offline.
+func (explorer *Explorer) initHTTPClient(ctx context.Context) error {
+	if explorer.Transport == nil {
+		return nil
+	}
+	if explorer.Offline {
+		explorer.httpClient = &http.Client{Transport: explorer.Transport}
+		return nil
+	}
+
+	opts := append(explorer.credentialsOptions(), option.WithScopes("https://www.googleapis.com/auth/cloud-platform"))
+	transport, err := htransport.NewTransport(ctx, explorer.Transport, opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create authenticated transport: %w", err)
+	}
+	explorer.httpClient = &http.Client{Transport: transport}
+	return nil
+}
+
+// exploresRegion returns true if the region is not filtered out by the configured regions
+func (explorer *Explorer) exploresRegion(region string) bool {
+	return len(explorer.Regions) == 0 || region == "global" || slices.Contains(explorer.Regions, region)
 }
 
 func (explorer *Explorer) SupportedServices() []string {
@@ -74,32 +191,83 @@ func (explorer *Explorer) Tags() map[string]string {
 	}
 }
 
+// newLocationModel returns the factors of the explored locations
+func (explorer *Explorer) newLocationModel() *carbon.LocationModel {
+	return &carbon.LocationModel{
+		PUE: explorer.pue,
+		Intensity: carbon.NewExplorerIntensityProvider(
+			carbon.NewStaticIntensityProvider("gcp-2023", explorer.carbonIntensityMap),
+			explorer.IntensityOverrides,
+			explorer.IntensityProviders...,
+		),
+		CarbonFreeEnergy:   explorer.carbonFreeEnergy.WithOverrides(explorer.CarbonFreeEnergy),
+		WUE:                explorer.wue,
+		GridWaterIntensity: explorer.gridWaterIntensity,
+	}
+}
+
+// UpdateFactors replaces the pue, carbon intensity overrides and carbon-free energy of the
+// explorer. Its clients and cache are kept.
+func (explorer *Explorer) UpdateFactors(settings *cloudcarbonexporter.ExplorerSettings) {
+	setFactors(explorer, settings)
+	explorer.locations.Store(explorer.newLocationModel())
+}
+
 func (explorer *Explorer) Init(ctx context.Context) (err error) {
-	if explorer.ProjectID == "" {
-		return fmt.Errorf("project id is not set")
+	if explorer.ProjectID == "" && explorer.Scope == "" {
+		return fmt.Errorf("project id or scope is not set")
+	}
+	if explorer.Scope != "" {
+		if err := ValidateScope(explorer.Scope); err != nil {
+			return err
+		}
+	}
+
+	if len(explorer.Services) > 0 {
+		for asset := range explorer.subExplorers {
+			if !slices.Contains(explorer.Services, string(asset)) {
+				delete(explorer.subExplorers, asset)
+			}
+		}
+		explorer.readiness = explorer.newReadiness()
 	}
+	explorer.locations.Store(explorer.newLocationModel())
 
-	explorer.cache = cache.NewMemory(ctx, 5*time.Minute)
+	if err := explorer.initHTTPClient(ctx); err != nil {
+		return err
+	}
+	// grpc exchanges do not go through the http transport
+	newAssetClient := asset.NewClient
+	if explorer.httpClient != nil {
+		newAssetClient = asset.NewRESTClient
+	}
+
+	explorer.cache = cache.NewMemory(ctx, explorer.CacheTTL)
 	errg := new(errgroup.Group)
 	errg.Go(func() error {
-		assets, err := asset.NewClient(ctx)
+		assets, err := newAssetClient(ctx, explorer.clientOptions()...)
 		if err != nil {
 			return fmt.Errorf("failed to create asset inventory client: %w", err)
 		}
 
-		return explorer.cache.SetDynamicIfNotExists(ctx, "discovery_map", explorer.discoveryMapCacheValue(assets))
+		return explorer.cache.SetDynamicIfNotExists(ctx, "discovery", explorer.discoveryCacheValue(assets))
 	})
 
-	errg.Go(func() error {
-		err := explorer.loadZones(ctx)
-		if err != nil {
-			return fmt.Errorf("failed to load zones: %w", err)
-		}
-		return nil
-	})
+	// without project, zones are loaded from the first project discovered in the scope
+	if explorer.ProjectID != "" {
+		errg.Go(func() error {
+			err := explorer.loadZones(ctx, explorer.ProjectID)
+			explorer.readiness.Set("zones", err)
+			if err != nil {
+				return fmt.Errorf("failed to load zones: %w", err)
+			}
+			return nil
+		})
+	}
 
 	errg.Go(func() error {
-		explorer.monitoringClient, err = monitoring.NewService(ctx)
+		explorer.monitoringClient, err = monitoring.NewService(ctx, explorer.clientOptions()...)
+		explorer.readiness.Set("monitoring", err)
 		if err != nil {
 			return fmt.Errorf("failed to initialize gcp monitoring client: %w", err)
 		}
@@ -110,6 +278,7 @@ func (explorer *Explorer) Init(ctx context.Context) (err error) {
 	for service, subExplorer := range explorer.subExplorers {
 		errg.Go(func() error {
 			err := subExplorer.init(ctx, explorer)
+			explorer.readiness.Set(string(service), err)
 			if err != nil {
 				return fmt.Errorf("failed to initialize subexplorer (%s): %w", service, err)
 			}
@@ -121,13 +290,14 @@ func (explorer *Explorer) Init(ctx context.Context) (err error) {
 	return errg.Wait()
 }
 
-func (explorer *Explorer) loadZones(ctx context.Context) error {
+func (explorer *Explorer) loadZones(ctx context.Context, projectID string) error {
 	slog.Info("loading zones and regions infos")
-	zonesClient, err := compute.NewZonesRESTClient(ctx)
+	zonesClient, err := compute.NewZonesRESTClient(ctx, explorer.clientOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to initialize zone rest client: %w", err)
 	}
-	it := zonesClient.List(ctx, &computepb.ListZonesRequest{Project: explorer.ProjectID})
+	zones := make(Zones, 0)
+	it := zonesClient.List(ctx, &computepb.ListZonesRequest{Project: projectID})
 	for {
 		zone, err := it.Next()
 		if err == iterator.Done {
@@ -138,12 +308,17 @@ func (explorer *Explorer) loadZones(ctx context.Context) error {
 		}
 
 		region := lastURLPathFragment(*zone.Region)
-		explorer.gcpZones = append(explorer.gcpZones, Zone{
+		zones = append(zones, Zone{
 			Name:   *zone.Name,
 			Region: region,
 		})
 	}
 
+	if len(zones) == 0 {
+		return fmt.Errorf("no zone found in project %s", projectID)
+	}
+	explorer.gcpZones = zones
+
 	slog.Info("zones and regions successfully loaded", "zones", len(explorer.gcpZones))
 	return nil
 }
@@ -171,22 +346,26 @@ func fragmentURLPath(source string) []string {
 	return strings.Split(path, "/")
 }
 
-func (explorer *Explorer) GetCachedDiscoveryMap(ctx context.Context) (AssetsDiscoveryMap, error) {
-	v, err := explorer.cache.Get(ctx, "discovery_map")
+// GetCachedDiscovery returns the projects and assets discovered in the scope. The discovery
+// is populated on first call, which marks the explorer ready.
+func (explorer *Explorer) GetCachedDiscovery(ctx context.Context) (Discovery, error) {
+	v, err := explorer.cache.Get(ctx, "discovery")
+	explorer.readiness.Set("discovery", err)
 	if err != nil {
 		return nil, err
 	}
 
-	assets, ok := v.(AssetsDiscoveryMap)
+	discovery, ok := v.(Discovery)
 	if !ok {
-		return nil, fmt.Errorf("wrong cache entry type: %s, expected AssetsDiscoveryMap", reflect.TypeOf(v))
+		return nil, fmt.Errorf("wrong cache entry type: %s, expected Discovery", reflect.TypeOf(v))
 	}
 
-	return assets, nil
+	return discovery, nil
 }
 
 func (explorer *Explorer) CollectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact, errs chan error) {
 	rawImpacts := make(chan *cloudcarbonexporter.Impact)
+	collectedAt := time.Now()
 
 	wg := new(sync.WaitGroup)
 	wg.Add(1)
@@ -199,8 +378,12 @@ func (explorer *Explorer) CollectImpacts(ctx cloudcarbonexporter.Context, impact
 				slog.Warn("impact location not found, skipping impact. please consider raising a bug.", "labels", rawImpact.Labels)
 				continue
 			}
-			rawImpact.Energy = rawImpact.Energy * primitives.GoodPUE
-			rawImpact.EnergyEmissions = explorer.carbonIntensityMap.EnergyEmissions(rawImpact.Energy, location)
+			factors, err := explorer.locations.Load().Factors(ctx, location, collectedAt)
+			if err != nil {
+				slog.Warn("carbon intensity not found, skipping impact", "location", location, "err", err.Error())
+				continue
+			}
+			rawImpact.ApplyLocationFactors(factors)
 			impacts <- rawImpact
 		}
 	}()
@@ -215,41 +398,73 @@ func (explorer *Explorer) CollectImpacts(ctx cloudcarbonexporter.Context, impact
 }
 
 func (explorer *Explorer) collectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact, errs chan error) {
-	discoveryMap, err := explorer.GetCachedDiscoveryMap(ctx)
+	discovery, err := explorer.GetCachedDiscovery(ctx)
 	if err != nil {
-		errs <- fmt.Errorf("failed to get cached discovery map: %w", err)
+		errs <- fmt.Errorf("failed to get cached discovery: %w", err)
 		return
 	}
 
 	wg := new(sync.WaitGroup)
-	for _, assetName := range discoveryMap["types"] {
-		subExplorer, found := explorer.subExplorers[Asset(assetName)]
-		if !found {
-			slog.Debug("asset is not supported", "asset", assetName)
+	for asset, subExplorer := range explorer.subExplorers {
+		projects := discovery.ProjectsWith(asset)
+		if len(projects) == 0 {
+			slog.Debug("asset is not active in scope", "asset", asset)
 			continue
 		}
+
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			errs <- subExplorer.collectImpacts(ctx, impacts)
+			errs <- cloudcarbonexporter.ObserveSubExplorer(ctx, string(asset), "global", impacts, func(impacts chan *cloudcarbonexporter.Impact) error {
+				return collectProjectsImpacts(ctx, subExplorer, projects, impacts)
+			})
 		}()
-
 	}
 
 	wg.Wait()
 }
 
-func (explorer *Explorer) discoveryMapCacheValue(client *asset.Client) cache.DynamicValueFunc {
+// collectProjectsImpacts collects the sub explorer impacts in each project with bounded
+// concurrency. A failing project does not prevent the others from being collected.
+func collectProjectsImpacts(ctx cloudcarbonexporter.Context, subExplorer SubExplorer, projects []*Project, impacts chan *cloudcarbonexporter.Impact) error {
+	mu := new(sync.Mutex)
+	projectErrs := make([]error, 0)
+
+	errg := new(errgroup.Group)
+	errg.SetLimit(maxConcurrentProjects)
+	for _, project := range projects {
+		errg.Go(func() error {
+			if err := subExplorer.collectImpacts(ctx, project, impacts); err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				projectErrs = append(projectErrs, fmt.Errorf("project %s: %w", project.ID, err))
+			}
+			return nil
+		})
+	}
+	_ = errg.Wait()
+
+	return errors.Join(projectErrs...)
+}
+
+// scope returns the parent of the assets discovered
+func (explorer *Explorer) scope() string {
+	if explorer.Scope != "" {
+		return explorer.Scope
+	}
+	return "projects/" + explorer.ProjectID
+}
+
+func (explorer *Explorer) discoveryCacheValue(client *asset.Client) cache.DynamicValueFunc {
 	return cache.DynamicValueFunc(func(ctx context.Context) (any, error) {
 		start := time.Now()
 		req := &assetpb.ListAssetsRequest{
-			Parent:      fmt.Sprintf("projects/%s", explorer.ProjectID),
+			Parent:      explorer.scope(),
 			ContentType: assetpb.ContentType_RESOURCE,
 		}
 
-		assetTypes := make([]string, 0)
-		activeZones := make([]string, 0)
-		activeRegions := make([]string, 0)
+		assets := make([]*assetpb.Asset, 0)
+		firstProjectID := ""
 
 		it := client.ListAssets(ctx, req)
 		for {
@@ -262,25 +477,29 @@ func (explorer *Explorer) discoveryMapCacheValue(client *asset.Client) cache.Dyn
 				return nil, &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to list assets inventory resources: %w", err), Operation: "asset/apiv1:ListAssets"}
 			}
 
-			assetTypes = append(assetTypes, asset.AssetType)
-			if explorer.gcpZones.IsValidZone(asset.Resource.Location) {
-				activeZones = append(activeZones, asset.Resource.Location)
+			assets = append(assets, asset)
+			if asset.AssetType == projectAssetType && firstProjectID == "" {
+				firstProjectID = asset.GetResource().GetData().GetFields()["projectId"].GetStringValue()
 			}
-			activeRegions = append(activeRegions, explorer.gcpZones.GetRegion(asset.Resource.Location))
+		}
 
+		// zones are required to tell zones and regions apart in assets locations
+		if len(explorer.gcpZones) == 0 {
+			if firstProjectID == "" {
+				return nil, fmt.Errorf("no project found in scope %s to load zones from", explorer.scope())
+			}
+			err := explorer.loadZones(ctx, firstProjectID)
+			explorer.readiness.Set("zones", err)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load zones: %w", err)
+			}
 		}
 
-		assetTypes = distinct(assetTypes)
-		activeZones = distinct(activeZones)
-		activeRegions = distinct(activeRegions)
+		discovery := explorer.newDiscovery(assets)
 
-		slog.Debug("assets listed", "projectID", explorer.ProjectID, "duration_ms", time.Since(start))
+		slog.Debug("assets listed", "scope", explorer.scope(), "assets", len(assets), "projects", len(discovery), "duration_ms", time.Since(start))
 
-		return AssetsDiscoveryMap{
-			"types":   assetTypes,
-			"zones":   activeZones,
-			"regions": activeRegions,
-		}, nil
+		return discovery, nil
 	})
 }
 
@@ -302,6 +521,21 @@ func (explorer *Explorer) Close() error {
 	return nil
 }
 
+// IsReady returns true once zones, clients and sub explorers are initialized and the
+// assets discovery map is populated
 func (explorer *Explorer) IsReady() bool {
-	return true
+	return explorer.readiness.IsReady()
+}
+
+// ReadinessChecks returns the state of the explorer initialization and assets discovery
+func (explorer *Explorer) ReadinessChecks() []cloudcarbonexporter.ReadinessCheck {
+	return explorer.readiness.Checks()
+}
+
+// CacheStats returns the lookups and refreshes counts of the explorer cache
+func (explorer *Explorer) CacheStats() cloudcarbonexporter.CacheStats {
+	if explorer.cache == nil {
+		return cloudcarbonexporter.CacheStats{}
+	}
+	return explorer.cache.Stats()
 }
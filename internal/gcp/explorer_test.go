@@ -4,6 +4,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/replay"
 )
 
 func TestURLFragments(t *testing.T) {
@@ -13,3 +15,66 @@ func TestURLFragments(t *testing.T) {
 	assert.Equal(t, "", lastURLPathFragment("http://test.com/"))
 	assert.Equal(t, "bob", lastURLPathFragment("http://test.com/bob"))
 }
+
+func TestAcceleratorModel(t *testing.T) {
+	assert.Equal(t, "NVIDIA T4", acceleratorModel("nvidia-tesla-t4"))
+	assert.Equal(t, "NVIDIA A100 80GB", acceleratorModel("nvidia-a100-80gb"))
+	assert.Equal(t, "NVIDIA L4", acceleratorModel("nvidia-l4"))
+}
+
+func TestCollectImpactsReplay(t *testing.T) {
+	player, err := replay.Load("testdata/europe-west1.jsonl")
+	assert.NoError(t, err)
+
+	explorer, err := newProviderExplorer(t.Context(), &cloudcarbonexporter.ExplorerSettings{
+		Regions:   []string{"europe-west1"},
+		Services:  []string{"compute.googleapis.com/Instance", "sqladmin.googleapis.com/Instance"},
+		Transport: player,
+		Offline:   true,
+	}, &Config{ProjectID: "demo-project"})
+	assert.NoError(t, err)
+	assert.NoError(t, explorer.Init(t.Context()))
+	assert.False(t, explorer.IsReady(), "assets are discovered on first collect")
+
+	// collecting twice replays the same exchanges
+	for range 2 {
+		impacts := make(chan *cloudcarbonexporter.Impact)
+		errs := make(chan error)
+		go func() {
+			defer close(impacts)
+			defer close(errs)
+			explorer.CollectImpacts(cloudcarbonexporter.WrapCtx(t.Context()), impacts, errs)
+		}()
+
+		go func() {
+			for err := range errs {
+				assert.NoError(t, err)
+			}
+		}()
+
+		cpuAverages := make(map[string]float64)
+		gpus := make(map[string]*cloudcarbonexporter.GPUInputs)
+		for impact := range impacts {
+			assert.Equal(t, "europe-west1", impact.Labels["location"])
+			assert.Equal(t, "demo-project", impact.Labels["project_id"])
+			assert.Equal(t, "gcp-2023", impact.Inputs.CarbonIntensitySource)
+			assert.Greater(t, float64(impact.Energy), 0.0)
+			switch impact.Labels["kind"] {
+			case "compute/Instance":
+				cpuAverages[impact.Labels["instance_name"]] = impact.Inputs.CPUAverage
+				if impact.Inputs.GPU != nil {
+					assert.Contains(t, impact.Components, cloudcarbonexporter.ComponentGPU)
+					gpus[impact.Labels["instance_name"]] = impact.Inputs.GPU
+				}
+			case "sql/Instance":
+				assert.Equal(t, "europe-west1-c", impact.Labels["zone"])
+				cpuAverages[impact.Labels["instance_name"]] = impact.Inputs.CPUAverage
+			}
+		}
+		assert.Equal(t, map[string]float64{"web-1": 42.5, "web-2": 12.5, "db-1": 30}, cpuAverages)
+		assert.Equal(t, map[string]*cloudcarbonexporter.GPUInputs{
+			"web-2": {Name: "NVIDIA L4", TDP: 72, Count: 1, Usage: 50},
+		}, gpus, "g2 machine types have a L4 gpu")
+		assert.True(t, explorer.IsReady())
+	}
+}
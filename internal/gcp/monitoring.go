@@ -14,9 +14,9 @@ import (
 	"google.golang.org/api/monitoring/v1"
 )
 
-// query the monitoring api and returns standardized cloud carbon metric
-func (explorer *Explorer) query(ctx context.Context, promql string, resourceName string, resolution time.Duration) (map[string]float64, error) {
-	body, err := explorer.monitoringClient.Projects.Location.Prometheus.Api.V1.QueryRange("projects/"+explorer.ProjectID, "global", &monitoring.QueryRangeRequest{
+// query the monitoring api of the project and returns standardized cloud carbon metric
+func (explorer *Explorer) query(ctx context.Context, projectID string, promql string, resourceName string, resolution time.Duration) (map[string]float64, error) {
+	body, err := explorer.monitoringClient.Projects.Location.Prometheus.Api.V1.QueryRange("projects/"+projectID, "global", &monitoring.QueryRangeRequest{
 		Start: time.Now().Add(-resolution).Format(time.RFC3339),
 		End:   time.Now().Format(time.RFC3339),
 		Step:  resolution.String(),
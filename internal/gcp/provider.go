@@ -0,0 +1,76 @@
+package gcp
+
+import (
+	"context"
+	"maps"
+	"slices"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
+)
+
+func init() {
+	cloudcarbonexporter.RegisterExplorerProvider(cloudcarbonexporter.ExplorerProvider{
+		Name:              "gcp",
+		SupportedServices: slices.Sorted(slices.Values(NewExplorer().SupportedServices())),
+		Locations:         slices.Sorted(maps.Keys(NewExplorer().carbonIntensityMap)),
+		NewConfig:         func() cloudcarbonexporter.ProviderConfig { return new(Config) },
+		New:               newProviderExplorer,
+	})
+}
+
+// Config is the gcp section of an explorer configuration
+type Config struct {
+	// ProjectID is the project explored when Scope is not set
+	ProjectID string `yaml:"project_id"`
+	// Scope is the organization or folder whose projects are all explored
+	Scope string `yaml:"scope"`
+}
+
+// Validate implements the cloudcarbonexporter.ProviderConfig interface
+func (config *Config) Validate(settings *cloudcarbonexporter.ExplorerSettings) []*cloudcarbonexporter.FieldError {
+	errs := make([]*cloudcarbonexporter.FieldError, 0)
+	if config.ProjectID == "" && config.Scope == "" {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"gcp"}, Msg: "project_id or scope must be set"})
+	}
+	if config.Scope != "" {
+		if err := ValidateScope(config.Scope); err != nil {
+			errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"gcp", "scope"}, Msg: err.Error()})
+		}
+	}
+	if settings.Credentials.Profile != "" {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"credentials", "profile"}, Msg: "is not supported by gcp explorers"})
+	}
+	if settings.Credentials.AccessKeyEnv != "" || settings.Credentials.SecretKeyEnv != "" {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"credentials"}, Msg: "access and secret key environment variables are not supported by gcp explorers"})
+	}
+	return errs
+}
+
+// newProviderExplorer creates an explorer from the shared settings and gcp configuration
+func newProviderExplorer(ctx context.Context, settings *cloudcarbonexporter.ExplorerSettings, providerConfig cloudcarbonexporter.ProviderConfig) (cloudcarbonexporter.Explorer, error) {
+	config := providerConfig.(*Config)
+
+	explorer := NewExplorer()
+	setFactors(explorer, settings)
+	explorer.ProjectID = config.ProjectID
+	explorer.Scope = config.Scope
+	explorer.CredentialsFile = settings.Credentials.File
+	explorer.Regions = settings.Regions
+	explorer.Services = settings.Services
+	explorer.IntensityProviders = settings.IntensityProviders
+	explorer.Transport = settings.Transport
+	explorer.Offline = settings.Offline
+	if settings.CacheTTL != 0 {
+		explorer.CacheTTL = settings.CacheTTL
+	}
+
+	return explorer, nil
+}
+
+// setFactors sets the emission factors of the explorer from the settings
+func setFactors(explorer *Explorer, settings *cloudcarbonexporter.ExplorerSettings) {
+	explorer.Configure(WithPUEModel(primitives.NewPUEModel("gcp").WithOverrides(settings.PUE, settings.LocationPUE, settings.MonthlyPUE)))
+	explorer.IntensityOverrides = settings.Intensity
+	explorer.CarbonFreeEnergy = settings.CarbonFreeEnergy
+}
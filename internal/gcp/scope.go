@@ -0,0 +1,147 @@
+package gcp
+
+import (
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"cloud.google.com/go/asset/apiv1/assetpb"
+)
+
+// maxConcurrentProjects bounds the number of projects explored at the same time by a sub
+// explorer
+const maxConcurrentProjects = 10
+
+const (
+	projectAssetType = "cloudresourcemanager.googleapis.com/Project"
+	folderAssetType  = "cloudresourcemanager.googleapis.com/Folder"
+)
+
+// ValidateScope returns an error if the scope is not formatted as projects/<id>,
+// folders/<id> or organizations/<id>
+func ValidateScope(scope string) error {
+	kind, id, found := strings.Cut(scope, "/")
+	if !found || id == "" || strings.Contains(id, "/") {
+		return fmt.Errorf("invalid scope %q: expected projects/<id>, folders/<id> or organizations/<id>", scope)
+	}
+
+	switch kind {
+	case "projects", "folders", "organizations":
+		return nil
+	default:
+		return fmt.Errorf("invalid scope %q: expected projects/<id>, folders/<id> or organizations/<id>", scope)
+	}
+}
+
+// Project is a project discovered in the explorer scope along with its active assets
+type Project struct {
+	ID string
+	// FolderPath is the path of the folders containing the project, from the organization
+	// down to the project parent, e.g. /engineering/data. Empty if the project is not in a folder.
+	FolderPath string
+	Assets     AssetsDiscoveryMap
+}
+
+// Labels returns the labels set on the impacts of the project resources
+func (project *Project) Labels() map[string]string {
+	return map[string]string{
+		"project_id":  project.ID,
+		"folder_path": project.FolderPath,
+	}
+}
+
+// Discovery holds the projects discovered in the explorer scope by id
+type Discovery map[string]*Project
+
+// ProjectsWith returns the projects where the asset type is active, sorted by id
+func (discovery Discovery) ProjectsWith(asset Asset) []*Project {
+	projects := make([]*Project, 0)
+	for _, project := range discovery {
+		if slices.Contains(project.Assets["types"], string(asset)) {
+			projects = append(projects, project)
+		}
+	}
+	slices.SortFunc(projects, func(a, b *Project) int { return strings.Compare(a.ID, b.ID) })
+	return projects
+}
+
+// newDiscovery groups the assets listed in the explorer scope by project. Projects and
+// folders display names are resolved from the cloudresourcemanager assets of the scope,
+// ids are used when they are not part of the scope.
+func (explorer *Explorer) newDiscovery(assets []*assetpb.Asset) Discovery {
+	projectIDs := make(map[string]string)
+	folderNames := make(map[string]string)
+	for _, asset := range assets {
+		fields := asset.GetResource().GetData().GetFields()
+		switch asset.GetAssetType() {
+		case projectAssetType:
+			projectIDs["projects/"+fields["projectNumber"].GetStringValue()] = fields["projectId"].GetStringValue()
+		case folderAssetType:
+			folderNames[strings.TrimPrefix(asset.GetName(), "//cloudresourcemanager.googleapis.com/")] = fields["displayName"].GetStringValue()
+		}
+	}
+
+	discovery := make(Discovery)
+	for _, asset := range assets {
+		ancestors := asset.GetAncestors()
+		if len(ancestors) == 0 || !strings.HasPrefix(ancestors[0], "projects/") {
+			continue
+		}
+
+		projectID, found := projectIDs[ancestors[0]]
+		switch {
+		case found && projectID != "":
+		case explorer.Scope == "" || strings.HasPrefix(explorer.Scope, "projects/"):
+			projectID = explorer.ProjectID
+		default:
+			slog.Debug("project id not found in scope, using project number", "project", ancestors[0])
+			projectID = strings.TrimPrefix(ancestors[0], "projects/")
+		}
+
+		project, found := discovery[projectID]
+		if !found {
+			project = &Project{
+				ID:         projectID,
+				FolderPath: folderPath(ancestors, folderNames),
+				Assets:     AssetsDiscoveryMap{"types": {}, "zones": {}, "regions": {}},
+			}
+			discovery[projectID] = project
+		}
+
+		location := asset.GetResource().GetLocation()
+		if !explorer.exploresRegion(explorer.gcpZones.GetRegion(location)) {
+			continue
+		}
+		project.Assets["types"] = append(project.Assets["types"], asset.GetAssetType())
+		if explorer.gcpZones.IsValidZone(location) {
+			project.Assets["zones"] = append(project.Assets["zones"], location)
+		}
+		project.Assets["regions"] = append(project.Assets["regions"], explorer.gcpZones.GetRegion(location))
+	}
+
+	for _, project := range discovery {
+		for key := range project.Assets {
+			project.Assets[key] = distinct(project.Assets[key])
+		}
+	}
+
+	return discovery
+}
+
+// folderPath returns the path of the folders in the asset ancestors. Ancestors are ordered
+// from the asset up to the organization.
+func folderPath(ancestors []string, folderNames map[string]string) string {
+	path := ""
+	for _, ancestor := range slices.Backward(ancestors) {
+		if !strings.HasPrefix(ancestor, "folders/") {
+			continue
+		}
+		name, found := folderNames[ancestor]
+		if !found || name == "" {
+			name = strings.TrimPrefix(ancestor, "folders/")
+		}
+		path += "/" + name
+	}
+	return path
+}
@@ -0,0 +1,95 @@
+package gcp
+
+import (
+	"testing"
+
+	"cloud.google.com/go/asset/apiv1/assetpb"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestValidateScope(t *testing.T) {
+	assert.NoError(t, ValidateScope("projects/myproject"))
+	assert.NoError(t, ValidateScope("folders/123"))
+	assert.NoError(t, ValidateScope("organizations/456"))
+
+	assert.Error(t, ValidateScope("myproject"))
+	assert.Error(t, ValidateScope("folders/"))
+	assert.Error(t, ValidateScope("billingAccounts/123"))
+	assert.Error(t, ValidateScope("folders/123/projects/456"))
+}
+
+func TestFolderPath(t *testing.T) {
+	folderNames := map[string]string{"folders/1": "engineering", "folders/2": "data"}
+
+	assert.Equal(t, "/engineering/data", folderPath([]string{"projects/10", "folders/2", "folders/1", "organizations/100"}, folderNames))
+	assert.Equal(t, "/engineering/3", folderPath([]string{"projects/10", "folders/3", "folders/1", "organizations/100"}, folderNames))
+	assert.Equal(t, "", folderPath([]string{"projects/10", "organizations/100"}, folderNames))
+}
+
+func newTestAsset(t *testing.T, name, assetType, location string, ancestors []string, data map[string]any) *assetpb.Asset {
+	fields, err := structpb.NewStruct(data)
+	assert.NoError(t, err)
+
+	return &assetpb.Asset{
+		Name:      name,
+		AssetType: assetType,
+		Ancestors: ancestors,
+		Resource: &assetpb.Resource{
+			Location: location,
+			Data:     fields,
+		},
+	}
+}
+
+func TestNewDiscovery(t *testing.T) {
+	explorer := &Explorer{
+		Scope: "organizations/100",
+		gcpZones: Zones{
+			{Name: "europe-west1-b", Region: "europe-west1"},
+			{Name: "us-central1-a", Region: "us-central1"},
+		},
+	}
+
+	dataAncestors := []string{"projects/10", "folders/2", "folders/1", "organizations/100"}
+	webAncestors := []string{"projects/20", "organizations/100"}
+
+	discovery := explorer.newDiscovery([]*assetpb.Asset{
+		newTestAsset(t, "//cloudresourcemanager.googleapis.com/folders/1", folderAssetType, "global", []string{"folders/1", "organizations/100"}, map[string]any{"displayName": "engineering"}),
+		newTestAsset(t, "//cloudresourcemanager.googleapis.com/folders/2", folderAssetType, "global", []string{"folders/2", "folders/1", "organizations/100"}, map[string]any{"displayName": "data"}),
+		newTestAsset(t, "//cloudresourcemanager.googleapis.com/projects/10", projectAssetType, "global", dataAncestors, map[string]any{"projectNumber": "10", "projectId": "data-prod"}),
+		newTestAsset(t, "//compute.googleapis.com/projects/data-prod/zones/europe-west1-b/instances/a", "compute.googleapis.com/Instance", "europe-west1-b", dataAncestors, nil),
+		newTestAsset(t, "//compute.googleapis.com/projects/data-prod/zones/europe-west1-b/instances/b", "compute.googleapis.com/Instance", "europe-west1-b", dataAncestors, nil),
+		newTestAsset(t, "//storage.googleapis.com/bucket", "storage.googleapis.com/Bucket", "us-central1", webAncestors, nil),
+	})
+
+	assert.Len(t, discovery, 2)
+
+	dataProject := discovery["data-prod"]
+	assert.Equal(t, "/engineering/data", dataProject.FolderPath)
+	assert.Equal(t, []string{"europe-west1-b"}, dataProject.Assets["zones"])
+	assert.Contains(t, dataProject.Assets["types"], "compute.googleapis.com/Instance")
+	assert.Equal(t, map[string]string{"project_id": "data-prod", "folder_path": "/engineering/data"}, dataProject.Labels())
+
+	// project id is not part of the scope assets, the project number is used
+	webProject := discovery["20"]
+	assert.Equal(t, "", webProject.FolderPath)
+	assert.Equal(t, []string{"us-central1"}, webProject.Assets["regions"])
+	assert.Empty(t, webProject.Assets["zones"])
+
+	instancesProjects := discovery.ProjectsWith(Asset("compute.googleapis.com/Instance"))
+	assert.Len(t, instancesProjects, 1)
+	assert.Equal(t, "data-prod", instancesProjects[0].ID)
+	assert.Empty(t, discovery.ProjectsWith(Asset("sqladmin.googleapis.com/Instance")))
+}
+
+func TestNewDiscoveryProjectScope(t *testing.T) {
+	explorer := &Explorer{ProjectID: "myproject"}
+
+	discovery := explorer.newDiscovery([]*assetpb.Asset{
+		newTestAsset(t, "//storage.googleapis.com/bucket", "storage.googleapis.com/Bucket", "eu", []string{"projects/10", "organizations/100"}, nil),
+	})
+
+	assert.Len(t, discovery, 1)
+	assert.Equal(t, "myproject", discovery["myproject"].ID)
+}
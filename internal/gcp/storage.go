@@ -24,25 +24,28 @@ func (bucketsExplorer *BucketsExplorer) init(ctx context.Context, explorer *Expl
 	bucketsExplorer.Explorer = explorer
 	bucketsExplorer.mu = new(sync.Mutex)
 
-	bucketsExplorer.client, err = storage.NewClient(ctx)
+	bucketsExplorer.client, err = storage.NewClient(ctx, explorer.clientOptions()...)
 	if err != nil {
 		return fmt.Errorf("failed to create buckets client: %w", err)
 	}
 
-	explorer.cache.SetDynamicIfNotExists(ctx, "buckets_size", func(ctx context.Context) (any, error) {
-		return bucketsExplorer.ListBucketSize(cloudcarbonexporter.WrapCtx(ctx))
-	}, 6*time.Hour)
-
 	return nil
 }
 
-func (bucketsExplorer *BucketsExplorer) collectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact) error {
-	bucketsIter := bucketsExplorer.client.Buckets(ctx, bucketsExplorer.ProjectID)
+func (bucketsExplorer *BucketsExplorer) collectImpacts(ctx cloudcarbonexporter.Context, project *Project, impacts chan *cloudcarbonexporter.Impact) error {
+	err := bucketsExplorer.cache.SetDynamicIfNotExists(ctx, "buckets_size/"+project.ID, func(ctx context.Context) (any, error) {
+		return bucketsExplorer.ListBucketSize(cloudcarbonexporter.WrapCtx(ctx), project.ID)
+	}, 6*time.Hour)
+	if err != nil {
+		return fmt.Errorf("failed to set buckets size cache: %w", err)
+	}
+
+	bucketsIter := bucketsExplorer.client.Buckets(ctx, project.ID)
 
 	for {
 		bucket, err := bucketsIter.Next()
 		if err == iterator.Done {
-			ctx.IncrCalls()
+			ctx.IncrCalls("storage:ListBuckets")
 			break
 		}
 
@@ -50,26 +53,29 @@ func (bucketsExplorer *BucketsExplorer) collectImpacts(ctx cloudcarbonexporter.C
 			return fmt.Errorf("failed to iterate on next bucket: %w", err)
 		}
 
+		if !bucketsExplorer.exploresRegion(strings.ToLower(bucket.Location)) {
+			continue
+		}
+
 		bucketName := bucket.Name
-		bucketSize, err := bucketsExplorer.GetBucketSize(ctx, bucketName)
+		bucketSize, err := bucketsExplorer.GetBucketSize(ctx, project.ID, bucketName)
 		if err != nil {
 			return err
 		}
 
-		energy := cloud.EstimateObjectStorageEnergy(bytesToGigabytes(bucketSize))
-
-		impacts <- &cloudcarbonexporter.Impact{
-			Energy:            energy,
-			EmbodiedEmissions: cloud.EstimateObjectStorageEmbodiedEmissions(bucketSize),
-			Labels: cloudcarbonexporter.MergeLabels(
-				map[string]string{
-					"kind":        "storage/Bucket",
-					"location":    strings.ToLower(bucket.Location),
-					"bucket_name": bucketName,
-				},
-				bucket.Labels,
-			),
+		impact := &cloudcarbonexporter.Impact{
+			Labels: cloudcarbonexporter.MergeLabels(map[string]string{
+				"kind":        "storage/Bucket",
+				"location":    strings.ToLower(bucket.Location),
+				"bucket_name": bucketName,
+			}, project.Labels()),
+			Tags: bucket.Labels,
 		}
+		impact.AddComponent(cloudcarbonexporter.ComponentStorage,
+			cloud.EstimateObjectStorageEnergy(bytesToGigabytes(bucketSize)),
+			cloud.EstimateObjectStorageEmbodiedEmissions(bucketSize))
+
+		impacts <- impact
 	}
 
 	return nil
@@ -80,12 +86,12 @@ func bytesToGigabytes(bytes float64) float64 {
 	return bytes / bytesPerGiga
 }
 
-func (explorer *BucketsExplorer) GetBucketSize(ctx context.Context, bucketName string) (float64, error) {
+func (explorer *BucketsExplorer) GetBucketSize(ctx context.Context, projectID string, bucketName string) (float64, error) {
 	// locking mutex prevents monitoring requests sent in parallel
 	explorer.mu.Lock()
 	defer explorer.mu.Unlock()
 
-	entry, err := explorer.cache.Get(ctx, "buckets_size")
+	entry, err := explorer.cache.Get(ctx, "buckets_size/"+projectID)
 	if err != nil {
 		return 0, fmt.Errorf("failed to get explorer cache bucket size: %w", err)
 	}
@@ -101,15 +107,15 @@ func (explorer *BucketsExplorer) GetBucketSize(ctx context.Context, bucketName s
 	return bucketSize, nil
 }
 
-func (explorer *BucketsExplorer) ListBucketSize(ctx cloudcarbonexporter.Context) (map[string]float64, error) {
+func (explorer *BucketsExplorer) ListBucketSize(ctx cloudcarbonexporter.Context, projectID string) (map[string]float64, error) {
 	promqlExpression := `sum by (bucket_name)(avg_over_time(storage_googleapis_com:storage_v2_total_bytes{monitored_resource="gcs_bucket"}[5m]))`
 	resolution := 10 * time.Minute
 
-	bucketList, err := explorer.query(ctx, promqlExpression, "bucket_name", resolution)
+	bucketList, err := explorer.query(ctx, projectID, promqlExpression, "bucket_name", resolution)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query for bucket monitoring data: %w", err)
 	}
 
-	ctx.IncrCalls()
+	ctx.IncrCalls("monitoring/v1:QueryRange")
 	return bucketList, nil
 }
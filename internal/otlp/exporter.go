@@ -0,0 +1,331 @@
+package otlp
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	resourcepb "go.opentelemetry.io/proto/otlp/resource/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protocol used to send metrics to the OTLP receiver
+type Protocol string
+
+const (
+	ProtocolGRPC         Protocol = "grpc"
+	ProtocolHTTPProtobuf Protocol = "http/protobuf"
+)
+
+const scopeName = "github.com/superdango/cloud-carbon-exporter"
+
+// Exporter pushes metrics to an OTLP receiver. It implements the cloudcarbonexporter.Sink
+// interface.
+type Exporter struct {
+	endpoint   string
+	protocol   Protocol
+	headers    map[string]string
+	insecure   bool
+	httpClient *http.Client
+	grpcConn   *grpc.ClientConn
+	grpcClient collectormetrics.MetricsServiceClient
+}
+
+type ExporterOption func(*Exporter)
+
+// WithEndpoint sets the receiver endpoint: host:port for grpc, an url for http/protobuf.
+// When the url has no path, metrics are sent to /v1/metrics.
+func WithEndpoint(endpoint string) ExporterOption {
+	return func(e *Exporter) {
+		e.endpoint = endpoint
+	}
+}
+
+// WithProtocol sets the protocol used to send metrics (grpc by default)
+func WithProtocol(protocol Protocol) ExporterOption {
+	return func(e *Exporter) {
+		e.protocol = protocol
+	}
+}
+
+// WithHeaders sets headers (or grpc metadata) sent with each export request
+func WithHeaders(headers map[string]string) ExporterOption {
+	return func(e *Exporter) {
+		e.headers = headers
+	}
+}
+
+// WithInsecure disables transport security for grpc connections
+func WithInsecure(insecure bool) ExporterOption {
+	return func(e *Exporter) {
+		e.insecure = insecure
+	}
+}
+
+// WithHTTPClient sets the client used by the http/protobuf protocol
+func WithHTTPClient(client *http.Client) ExporterOption {
+	return func(e *Exporter) {
+		e.httpClient = client
+	}
+}
+
+// NewExporter returns a new OTLP exporter
+func NewExporter() *Exporter {
+	return &Exporter{
+		endpoint:   "localhost:4317",
+		protocol:   ProtocolGRPC,
+		headers:    make(map[string]string),
+		httpClient: http.DefaultClient,
+	}
+}
+
+func (exporter *Exporter) Configure(opts ...ExporterOption) *Exporter {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(exporter)
+		}
+	}
+
+	return exporter
+}
+
+// Init prepares the connection to the receiver
+func (exporter *Exporter) Init(ctx context.Context) (err error) {
+	switch exporter.protocol {
+	case ProtocolGRPC:
+		transportCredentials := credentials.NewTLS(nil)
+		if exporter.insecure {
+			transportCredentials = insecure.NewCredentials()
+		}
+
+		exporter.grpcConn, err = grpc.NewClient(exporter.endpoint, grpc.WithTransportCredentials(transportCredentials))
+		if err != nil {
+			return fmt.Errorf("failed to create otlp grpc client: %w", err)
+		}
+		exporter.grpcClient = collectormetrics.NewMetricsServiceClient(exporter.grpcConn)
+		return nil
+
+	case ProtocolHTTPProtobuf:
+		u, err := url.Parse(exporter.endpoint)
+		if err != nil {
+			return fmt.Errorf("invalid otlp http endpoint: %w", err)
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/v1/metrics"
+		}
+		exporter.endpoint = u.String()
+		return nil
+
+	default:
+		return fmt.Errorf("otlp protocol %s is not supported", exporter.protocol)
+	}
+}
+
+// Push converts and sends metrics to the receiver
+func (exporter *Exporter) Push(ctx context.Context, metrics []*cloudcarbonexporter.Metric) error {
+	request := NewExportRequest(metrics, time.Now())
+
+	switch exporter.protocol {
+	case ProtocolGRPC:
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(exporter.headers))
+		response, err := exporter.grpcClient.Export(ctx, request)
+		if err != nil {
+			return fmt.Errorf("failed to export metrics over grpc: %w", err)
+		}
+		return partialSuccessErr(response)
+
+	case ProtocolHTTPProtobuf:
+		return exporter.pushHTTP(ctx, request)
+
+	default:
+		return fmt.Errorf("otlp protocol %s is not supported", exporter.protocol)
+	}
+}
+
+func (exporter *Exporter) pushHTTP(ctx context.Context, request *collectormetrics.ExportMetricsServiceRequest) error {
+	body, err := proto.Marshal(request)
+	if err != nil {
+		return fmt.Errorf("failed to marshal export request: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, exporter.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create export http request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	for k, v := range exporter.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := exporter.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to export metrics over http: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read export response: %w", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("otlp receiver returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+
+	response := new(collectormetrics.ExportMetricsServiceResponse)
+	if err := proto.Unmarshal(respBody, response); err != nil {
+		return fmt.Errorf("failed to decode export response: %w", err)
+	}
+
+	return partialSuccessErr(response)
+}
+
+func partialSuccessErr(response *collectormetrics.ExportMetricsServiceResponse) error {
+	partialSuccess := response.GetPartialSuccess()
+	if partialSuccess.GetRejectedDataPoints() > 0 {
+		return fmt.Errorf("otlp receiver rejected %d data points: %s", partialSuccess.GetRejectedDataPoints(), partialSuccess.GetErrorMessage())
+	}
+	return nil
+}
+
+// Close closes the connection to the receiver
+func (exporter *Exporter) Close() error {
+	if exporter.grpcConn != nil {
+		return exporter.grpcConn.Close()
+	}
+	return nil
+}
+
+// NewExportRequest converts metrics into an OTLP export request. Metrics are grouped in
+// one resource per explorer. The explorer label becomes a resource attribute while all
+// other labels are set as data point attributes.
+func NewExportRequest(metrics []*cloudcarbonexporter.Metric, now time.Time) *collectormetrics.ExportMetricsServiceRequest {
+	explorers := make([]string, 0)
+	byExplorer := make(map[string][]*cloudcarbonexporter.Metric)
+	for _, metric := range metrics {
+		if metric == nil {
+			continue
+		}
+		explorer := metric.Labels["explorer"]
+		if _, found := byExplorer[explorer]; !found {
+			explorers = append(explorers, explorer)
+		}
+		byExplorer[explorer] = append(byExplorer[explorer], metric)
+	}
+
+	request := &collectormetrics.ExportMetricsServiceRequest{}
+	for _, explorer := range explorers {
+		resourceAttributes := []*commonpb.KeyValue{stringAttribute("service.name", "cloud-carbon-exporter")}
+		if explorer != "" {
+			resourceAttributes = append(resourceAttributes, stringAttribute("explorer", explorer))
+		}
+
+		request.ResourceMetrics = append(request.ResourceMetrics, &metricspb.ResourceMetrics{
+			Resource: &resourcepb.Resource{Attributes: resourceAttributes},
+			ScopeMetrics: []*metricspb.ScopeMetrics{
+				{
+					Scope:   &commonpb.InstrumentationScope{Name: scopeName},
+					Metrics: convertMetrics(byExplorer[explorer], now),
+				},
+			},
+		})
+	}
+
+	return request
+}
+
+// convertMetrics groups metrics by family and converts them into OTLP gauges or sums
+func convertMetrics(metrics []*cloudcarbonexporter.Metric, now time.Time) []*metricspb.Metric {
+	names := make([]string, 0)
+	families := make(map[string]*metricspb.Metric)
+
+	for _, metric := range metrics {
+		otlpMetric, found := families[metric.Name]
+		if !found {
+			family := cloudcarbonexporter.LookupMetricFamily(metric.Name)
+			otlpMetric = &metricspb.Metric{
+				Name:        metric.Name,
+				Description: family.Help,
+				Unit:        family.Unit,
+			}
+
+			switch family.Type {
+			case cloudcarbonexporter.CounterType:
+				otlpMetric.Name = strings.TrimSuffix(metric.Name, "_total")
+				otlpMetric.Data = &metricspb.Metric_Sum{Sum: &metricspb.Sum{
+					AggregationTemporality: metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE,
+					IsMonotonic:            true,
+				}}
+			default:
+				otlpMetric.Data = &metricspb.Metric_Gauge{Gauge: &metricspb.Gauge{}}
+			}
+
+			names = append(names, metric.Name)
+			families[metric.Name] = otlpMetric
+		}
+
+		dataPoint := &metricspb.NumberDataPoint{
+			Attributes:   dataPointAttributes(metric.Labels),
+			TimeUnixNano: uint64(now.UnixNano()),
+			Value:        &metricspb.NumberDataPoint_AsDouble{AsDouble: metric.Value},
+		}
+		if !metric.Created.IsZero() {
+			dataPoint.StartTimeUnixNano = uint64(metric.Created.UnixNano())
+		}
+
+		switch data := otlpMetric.Data.(type) {
+		case *metricspb.Metric_Sum:
+			data.Sum.DataPoints = append(data.Sum.DataPoints, dataPoint)
+		case *metricspb.Metric_Gauge:
+			data.Gauge.DataPoints = append(data.Gauge.DataPoints, dataPoint)
+		}
+	}
+
+	otlpMetrics := make([]*metricspb.Metric, len(names))
+	for i, name := range names {
+		otlpMetrics[i] = families[name]
+	}
+
+	return otlpMetrics
+}
+
+// dataPointAttributes returns labels, except the explorer, sorted by name
+func dataPointAttributes(labels map[string]string) []*commonpb.KeyValue {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		if name == "explorer" {
+			continue
+		}
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	attributes := make([]*commonpb.KeyValue, len(names))
+	for i, name := range names {
+		attributes[i] = stringAttribute(name, labels[name])
+	}
+
+	return attributes
+}
+
+func stringAttribute(key, value string) *commonpb.KeyValue {
+	return &commonpb.KeyValue{
+		Key:   key,
+		Value: &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value}},
+	}
+}
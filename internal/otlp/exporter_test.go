@@ -0,0 +1,118 @@
+package otlp_test
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/otlp"
+
+	collectormetrics "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+)
+
+type receiver struct {
+	collectormetrics.UnimplementedMetricsServiceServer
+	requests chan *collectormetrics.ExportMetricsServiceRequest
+	tokens   chan []string
+}
+
+func (r *receiver) Export(ctx context.Context, req *collectormetrics.ExportMetricsServiceRequest) (*collectormetrics.ExportMetricsServiceResponse, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	r.tokens <- md.Get("authorization")
+	r.requests <- req
+	return &collectormetrics.ExportMetricsServiceResponse{}, nil
+}
+
+func testMetrics() []*cloudcarbonexporter.Metric {
+	created := time.Unix(1000, 0)
+	return []*cloudcarbonexporter.Metric{
+		{Name: "estimated_watts", Labels: map[string]string{"explorer": "aws", "kind": "ec2/instance"}, Value: 12},
+		{Name: "estimated_energy_joules_total", Labels: map[string]string{"explorer": "aws", "kind": "ec2/instance"}, Value: 120, Created: created},
+		{Name: "estimated_watts", Labels: map[string]string{"explorer": "gcp", "kind": "compute/Instance"}, Value: 3},
+	}
+}
+
+func assertRequest(t *testing.T, req *collectormetrics.ExportMetricsServiceRequest) {
+	assert.Len(t, req.ResourceMetrics, 2)
+
+	aws := req.ResourceMetrics[0]
+	assert.Equal(t, "explorer", aws.Resource.Attributes[1].Key)
+	assert.Equal(t, "aws", aws.Resource.Attributes[1].Value.GetStringValue())
+
+	metrics := aws.ScopeMetrics[0].Metrics
+	assert.Len(t, metrics, 2)
+	assert.Equal(t, "estimated_watts", metrics[0].Name)
+	assert.Equal(t, "watts", metrics[0].Unit)
+	gauge := metrics[0].GetGauge()
+	assert.Equal(t, 12.0, gauge.DataPoints[0].GetAsDouble())
+	assert.Equal(t, "kind", gauge.DataPoints[0].Attributes[0].Key)
+	assert.Len(t, gauge.DataPoints[0].Attributes, 1)
+
+	assert.Equal(t, "estimated_energy_joules", metrics[1].Name)
+	sum := metrics[1].GetSum()
+	assert.True(t, sum.IsMonotonic)
+	assert.Equal(t, metricspb.AggregationTemporality_AGGREGATION_TEMPORALITY_CUMULATIVE, sum.AggregationTemporality)
+	assert.Equal(t, uint64(time.Unix(1000, 0).UnixNano()), sum.DataPoints[0].StartTimeUnixNano)
+}
+
+func TestExporterGRPC(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+
+	r := &receiver{
+		requests: make(chan *collectormetrics.ExportMetricsServiceRequest, 1),
+		tokens:   make(chan []string, 1),
+	}
+	server := grpc.NewServer()
+	collectormetrics.RegisterMetricsServiceServer(server, r)
+	go server.Serve(listener)
+	defer server.Stop()
+
+	exporter := otlp.NewExporter().Configure(
+		otlp.WithEndpoint(listener.Addr().String()),
+		otlp.WithInsecure(true),
+		otlp.WithHeaders(map[string]string{"authorization": "Bearer token"}),
+	)
+	assert.NoError(t, exporter.Init(t.Context()))
+	defer exporter.Close()
+
+	assert.NoError(t, exporter.Push(t.Context(), testMetrics()))
+	assert.Equal(t, []string{"Bearer token"}, <-r.tokens)
+	assertRequest(t, <-r.requests)
+}
+
+func TestExporterHTTP(t *testing.T) {
+	requests := make(chan *collectormetrics.ExportMetricsServiceRequest, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/metrics", r.URL.Path)
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "secret", r.Header.Get("X-Api-Key"))
+
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		req := new(collectormetrics.ExportMetricsServiceRequest)
+		assert.NoError(t, proto.Unmarshal(body, req))
+		requests <- req
+	}))
+	defer server.Close()
+
+	exporter := otlp.NewExporter().Configure(
+		otlp.WithEndpoint(server.URL),
+		otlp.WithProtocol(otlp.ProtocolHTTPProtobuf),
+		otlp.WithHeaders(map[string]string{"X-Api-Key": "secret"}),
+	)
+	assert.NoError(t, exporter.Init(t.Context()))
+
+	assert.NoError(t, exporter.Push(t.Context(), testMetrics()))
+	assertRequest(t, <-requests)
+}
@@ -0,0 +1,231 @@
+package remotewrite
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/golang/snappy"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// Writer pushes metrics to a Prometheus remote write endpoint. It implements the
+// cloudcarbonexporter.Sink interface.
+type Writer struct {
+	url          string
+	headers      map[string]string
+	maxRetries   int
+	retryBackoff time.Duration
+	httpClient   *http.Client
+}
+
+type WriterOption func(*Writer)
+
+// WithURL sets the remote write endpoint url
+func WithURL(url string) WriterOption {
+	return func(w *Writer) {
+		w.url = url
+	}
+}
+
+// WithHeaders sets headers sent with each remote write request
+func WithHeaders(headers map[string]string) WriterOption {
+	return func(w *Writer) {
+		w.headers = headers
+	}
+}
+
+// WithRetries sets the number of retries of failed requests and the linear backoff step
+// between two attempts.
+func WithRetries(maxRetries int, backoff time.Duration) WriterOption {
+	return func(w *Writer) {
+		w.maxRetries = maxRetries
+		w.retryBackoff = backoff
+	}
+}
+
+// WithHTTPClient sets the http client used to send requests
+func WithHTTPClient(client *http.Client) WriterOption {
+	return func(w *Writer) {
+		w.httpClient = client
+	}
+}
+
+// NewWriter returns a new remote write Writer
+func NewWriter() *Writer {
+	return &Writer{
+		headers:      make(map[string]string),
+		maxRetries:   3,
+		retryBackoff: 2 * time.Second,
+		httpClient:   http.DefaultClient,
+	}
+}
+
+func (writer *Writer) Configure(opts ...WriterOption) *Writer {
+	for _, opt := range opts {
+		if opt != nil {
+			opt(writer)
+		}
+	}
+
+	return writer
+}
+
+// Push encodes metrics as a snappy compressed remote write request and sends it. Network
+// errors, server errors and throttled requests are retried.
+func (writer *Writer) Push(ctx context.Context, metrics []*cloudcarbonexporter.Metric) error {
+	if writer.url == "" {
+		return fmt.Errorf("remote write url is not set")
+	}
+
+	body := snappy.Encode(nil, EncodeWriteRequest(metrics, time.Now()))
+
+	var err error
+	for attempt := 0; attempt <= writer.maxRetries; attempt++ {
+		if attempt > 0 {
+			slog.Debug("retrying remote write request", "attempt", attempt, "err", err.Error())
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("remote write aborted: %w", err)
+			case <-time.After(writer.retryBackoff * time.Duration(attempt)):
+			}
+		}
+
+		var retryable bool
+		retryable, err = writer.send(ctx, body)
+		if err == nil {
+			return nil
+		}
+
+		if !retryable {
+			break
+		}
+	}
+
+	return err
+}
+
+// send sends a single remote write request and returns if the request can be retried when
+// it fails.
+func (writer *Writer) send(ctx context.Context, body []byte) (retryable bool, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, writer.url, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create remote write request: %w", err)
+	}
+
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("User-Agent", "cloud-carbon-exporter")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	for k, v := range writer.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := writer.httpClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("failed to send remote write request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode <= 299 {
+		return false, nil
+	}
+
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+	err = fmt.Errorf("remote write endpoint returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+
+	return resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests, err
+}
+
+// EncodeWriteRequest encodes metrics as a prometheus.WriteRequest protobuf message.
+// https://github.com/prometheus/prometheus/blob/main/prompb/remote.proto
+func EncodeWriteRequest(metrics []*cloudcarbonexporter.Metric, now time.Time) []byte {
+	var request []byte
+	families := make([]string, 0)
+
+	for _, metric := range metrics {
+		if metric == nil {
+			continue
+		}
+		if !slices.Contains(families, metric.Name) {
+			families = append(families, metric.Name)
+		}
+
+		request = protowire.AppendTag(request, 1, protowire.BytesType)
+		request = protowire.AppendBytes(request, encodeTimeSeries(metric, now))
+	}
+
+	for _, name := range families {
+		request = protowire.AppendTag(request, 3, protowire.BytesType)
+		request = protowire.AppendBytes(request, encodeMetadata(cloudcarbonexporter.LookupMetricFamily(name)))
+	}
+
+	return request
+}
+
+// encodeTimeSeries encodes a metric as a prometheus.TimeSeries with a single sample
+func encodeTimeSeries(metric *cloudcarbonexporter.Metric, now time.Time) []byte {
+	sanitized := metric.Clone()
+	labels := sanitized.SanitizeLabels().Labels
+	labels["__name__"] = metric.Name
+
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	var timeSeries []byte
+	for _, name := range names {
+		var label []byte
+		label = protowire.AppendTag(label, 1, protowire.BytesType)
+		label = protowire.AppendString(label, name)
+		label = protowire.AppendTag(label, 2, protowire.BytesType)
+		label = protowire.AppendString(label, labels[name])
+
+		timeSeries = protowire.AppendTag(timeSeries, 1, protowire.BytesType)
+		timeSeries = protowire.AppendBytes(timeSeries, label)
+	}
+
+	var sample []byte
+	sample = protowire.AppendTag(sample, 1, protowire.Fixed64Type)
+	sample = protowire.AppendFixed64(sample, math.Float64bits(metric.Value))
+	sample = protowire.AppendTag(sample, 2, protowire.VarintType)
+	sample = protowire.AppendVarint(sample, uint64(now.UnixMilli()))
+
+	timeSeries = protowire.AppendTag(timeSeries, 2, protowire.BytesType)
+	timeSeries = protowire.AppendBytes(timeSeries, sample)
+
+	return timeSeries
+}
+
+// encodeMetadata encodes a metric family as a prometheus.MetricMetadata
+func encodeMetadata(family cloudcarbonexporter.MetricFamily) []byte {
+	metricType := uint64(0) // UNKNOWN
+	switch family.Type {
+	case cloudcarbonexporter.CounterType:
+		metricType = 1
+	case cloudcarbonexporter.GaugeType:
+		metricType = 2
+	}
+
+	var metadata []byte
+	metadata = protowire.AppendTag(metadata, 1, protowire.VarintType)
+	metadata = protowire.AppendVarint(metadata, metricType)
+	metadata = protowire.AppendTag(metadata, 2, protowire.BytesType)
+	metadata = protowire.AppendString(metadata, family.Name)
+	metadata = protowire.AppendTag(metadata, 4, protowire.BytesType)
+	metadata = protowire.AppendString(metadata, family.Help)
+	metadata = protowire.AppendTag(metadata, 5, protowire.BytesType)
+	metadata = protowire.AppendString(metadata, family.Unit)
+
+	return metadata
+}
@@ -0,0 +1,143 @@
+package remotewrite_test
+
+import (
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/remotewrite"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+type sample struct {
+	labels    map[string]string
+	value     float64
+	timestamp int64
+}
+
+// decodeFields returns the raw bytes of each field of a protobuf message, by field number
+func decodeFields(t *testing.T, b []byte) map[protowire.Number][][]byte {
+	fields := make(map[protowire.Number][][]byte)
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		assert.GreaterOrEqual(t, n, 0)
+		b = b[n:]
+
+		var value []byte
+		switch typ {
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(b)
+			value, b = v, b[n:]
+		case protowire.VarintType:
+			_, n := protowire.ConsumeVarint(b)
+			value, b = b[:n], b[n:]
+		case protowire.Fixed64Type:
+			value, b = b[:8], b[8:]
+		default:
+			t.Fatalf("unexpected wire type %d", typ)
+		}
+		fields[num] = append(fields[num], value)
+	}
+	return fields
+}
+
+func decodeSamples(t *testing.T, request []byte) []sample {
+	samples := make([]sample, 0)
+	for _, timeSeries := range decodeFields(t, request)[1] {
+		fields := decodeFields(t, timeSeries)
+		s := sample{labels: make(map[string]string)}
+		for _, label := range fields[1] {
+			labelFields := decodeFields(t, label)
+			s.labels[string(labelFields[1][0])] = string(labelFields[2][0])
+		}
+		sampleFields := decodeFields(t, fields[2][0])
+		bits, _ := protowire.ConsumeFixed64(sampleFields[1][0])
+		s.value = math.Float64frombits(bits)
+		ts, _ := protowire.ConsumeVarint(sampleFields[2][0])
+		s.timestamp = int64(ts)
+		samples = append(samples, s)
+	}
+	return samples
+}
+
+func TestEncodeWriteRequest(t *testing.T) {
+	now := time.UnixMilli(1700000000000)
+	request := remotewrite.EncodeWriteRequest([]*cloudcarbonexporter.Metric{
+		{Name: "estimated_watts", Labels: map[string]string{"kind": "ec2/instance", "tag.env": "prod"}, Value: 12.5},
+		{Name: "estimated_watts", Labels: map[string]string{"kind": "s3/bucket"}, Value: 1},
+	}, now)
+
+	samples := decodeSamples(t, request)
+	assert.Len(t, samples, 2)
+	assert.Equal(t, map[string]string{"__name__": "estimated_watts", "kind": "ec2/instance", "tag_env": "prod"}, samples[0].labels)
+	assert.Equal(t, 12.5, samples[0].value)
+	assert.Equal(t, int64(1700000000000), samples[0].timestamp)
+
+	metadata := decodeFields(t, request)[3]
+	assert.Len(t, metadata, 1)
+	assert.Equal(t, "estimated_watts", string(decodeFields(t, metadata[0])[2][0]))
+}
+
+func TestWriterPush(t *testing.T) {
+	calls := new(atomic.Int32)
+	received := make(chan []sample, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		assert.Equal(t, "snappy", r.Header.Get("Content-Encoding"))
+		assert.Equal(t, "application/x-protobuf", r.Header.Get("Content-Type"))
+		assert.Equal(t, "0.1.0", r.Header.Get("X-Prometheus-Remote-Write-Version"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+
+		compressed, err := io.ReadAll(r.Body)
+		assert.NoError(t, err)
+		body, err := snappy.Decode(nil, compressed)
+		assert.NoError(t, err)
+
+		received <- decodeSamples(t, body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	writer := remotewrite.NewWriter().Configure(
+		remotewrite.WithURL(server.URL),
+		remotewrite.WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+		remotewrite.WithRetries(2, time.Millisecond),
+	)
+
+	err := writer.Push(t.Context(), []*cloudcarbonexporter.Metric{
+		{Name: "estimated_watts", Labels: map[string]string{"kind": "ec2/instance"}, Value: 3},
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Len(t, <-received, 1)
+}
+
+func TestWriterPushClientError(t *testing.T) {
+	calls := new(atomic.Int32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		http.Error(w, "out of order sample", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	writer := remotewrite.NewWriter().Configure(
+		remotewrite.WithURL(server.URL),
+		remotewrite.WithRetries(3, time.Millisecond),
+	)
+
+	err := writer.Push(t.Context(), []*cloudcarbonexporter.Metric{{Name: "estimated_watts", Value: 1}})
+	assert.ErrorContains(t, err, "out of order sample")
+	assert.Equal(t, int32(1), calls.Load())
+}
@@ -0,0 +1,115 @@
+package replay
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+)
+
+// Player is an http.RoundTripper serving recorded exchanges. A request is served the first
+// exchange not served yet with the same method, url and body, ignoring dates and times.
+// Otherwise, it is served the next exchange of the same api operation in the recorded
+// order. Exchanges are served again once they have all been served, so explorers can
+// collect more than once.
+type Player struct {
+	exchanges []*Exchange
+	served    []bool
+	mu        *sync.Mutex
+}
+
+// NewPlayer returns a Player serving the exchanges
+func NewPlayer(exchanges ...*Exchange) *Player {
+	return &Player{
+		exchanges: exchanges,
+		served:    make([]bool, len(exchanges)),
+		mu:        new(sync.Mutex),
+	}
+}
+
+// Load returns a Player serving the exchanges of the fixture file at path
+func Load(path string) (*Player, error) {
+	exchanges, err := LoadFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlayer(exchanges...), nil
+}
+
+// RoundTrip implements the http.RoundTripper interface. It fails if no exchange matches the
+// request api operation.
+func (player *Player) RoundTrip(req *http.Request) (*http.Response, error) {
+	body := []byte{}
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if body, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+	}
+
+	exchange := player.match(req, string(body))
+	if exchange == nil {
+		return nil, fmt.Errorf("no recorded exchange for %s %s", req.Method, req.URL.Redacted())
+	}
+
+	respBody, err := decodeBody(exchange.Response.Body, exchange.Response.Base64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode recorded response body: %w", err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", exchange.Response.StatusCode, http.StatusText(exchange.Response.StatusCode)),
+		StatusCode:    exchange.Response.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        toHeader(exchange.Response.Header),
+		Body:          io.NopCloser(bytes.NewReader(respBody)),
+		ContentLength: int64(len(respBody)),
+		Request:       req,
+	}, nil
+}
+
+// match returns the exchange served to the request and marks it as served
+func (player *Player) match(req *http.Request, body string) *Exchange {
+	operation, exact := keys(req.Method, req.URL.String(), req.Header, body)
+
+	player.mu.Lock()
+	defer player.mu.Unlock()
+
+	exactMatch, operationMatch := -1, -1
+	for _, servedAgain := range []bool{false, true} {
+		for i, exchange := range player.exchanges {
+			if player.served[i] != servedAgain {
+				continue
+			}
+			recordedBody := exchange.Request.Body
+			if exchange.Request.Base64 {
+				recordedBody = ""
+			}
+			recordedOperation, recordedExact := keys(exchange.Request.Method, exchange.Request.URL, toHeader(exchange.Request.Header), recordedBody)
+			if recordedExact == exact && !exchange.Request.Base64 {
+				exactMatch = i
+				break
+			}
+			if recordedOperation == operation && operationMatch < 0 {
+				operationMatch = i
+			}
+		}
+		if exactMatch >= 0 || operationMatch >= 0 {
+			break
+		}
+	}
+
+	i := exactMatch
+	if i < 0 {
+		i = operationMatch
+	}
+	if i < 0 {
+		return nil
+	}
+	player.served[i] = true
+	return player.exchanges[i]
+}
@@ -0,0 +1,91 @@
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"sync"
+)
+
+// Recorder is an http.RoundTripper appending the exchanges sent through it to a fixture
+// file. Exchanges failing at the transport level are not recorded.
+type Recorder struct {
+	path string
+	next http.RoundTripper
+	mu   *sync.Mutex
+}
+
+// NewRecorder returns a Recorder sending requests through next, http.DefaultTransport if
+// nil, and appending exchanges to the fixture file at path
+func NewRecorder(path string, next http.RoundTripper) *Recorder {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &Recorder{
+		path: path,
+		next: next,
+		mu:   new(sync.Mutex),
+	}
+}
+
+// RoundTrip implements the http.RoundTripper interface
+func (recorder *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	reqBody := []byte{}
+	if req.Body != nil && req.Body != http.NoBody {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body.Close()
+
+		req = req.Clone(req.Context())
+		req.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	resp, err := recorder.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+
+	if err := recorder.record(req, reqBody, resp, respBody); err != nil {
+		slog.Warn("failed to record api exchange", "url", req.URL.Redacted(), "err", err.Error())
+	}
+
+	return resp, nil
+}
+
+// record appends the exchange to the fixture file
+func (recorder *Recorder) record(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) error {
+	exchange, err := newExchange(req, reqBody, resp, respBody)
+	if err != nil {
+		return err
+	}
+	line, err := json.Marshal(exchange)
+	if err != nil {
+		return fmt.Errorf("failed to encode exchange: %w", err)
+	}
+
+	recorder.mu.Lock()
+	defer recorder.mu.Unlock()
+
+	f, err := os.OpenFile(recorder.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open fixture: %w", err)
+	}
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write fixture: %w", err)
+	}
+	return f.Close()
+}
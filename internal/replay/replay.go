@@ -0,0 +1,233 @@
+// Package replay records the http exchanges of the cloud api clients into fixture files and
+// serves them back, so explorers run offline and reproducibly in tests.
+//
+// Fixture files hold one exchange per line, in json. Secrets are redacted before they are
+// written: only the request headers identifying the api operation are kept and credentials
+// found in bodies and urls are replaced.
+package replay
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// Exchange is a recorded http request and its response
+type Exchange struct {
+	Request  Request  `json:"request"`
+	Response Response `json:"response"`
+}
+
+// Request is a recorded http request
+type Request struct {
+	Method string            `json:"method"`
+	URL    string            `json:"url"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+	// Base64 is true if the body is not valid utf-8 and has been base64 encoded
+	Base64 bool `json:"base64,omitempty"`
+}
+
+// Response is a recorded http response
+type Response struct {
+	StatusCode int               `json:"status_code"`
+	Header     map[string]string `json:"header,omitempty"`
+	Body       string            `json:"body,omitempty"`
+	// Base64 is true if the body is not valid utf-8 and has been base64 encoded
+	Base64 bool `json:"base64,omitempty"`
+}
+
+// requestHeaders are the request headers kept in fixtures. They identify the api operation
+// along with the method, url and body.
+var requestHeaders = []string{"Content-Type", "X-Amz-Target"}
+
+// responseHeaders are the response headers kept in fixtures. They are read by the clients
+// to decode responses and errors.
+var responseHeaders = []string{"Content-Type", "X-Amzn-Errortype", "X-Amzn-Query-Error", "X-Amz-Bucket-Region"}
+
+// secrets match the credentials found in bodies and urls: form and query parameters,
+// json fields and xml elements
+var secrets = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\b(access_token|id_token|refresh_token|client_secret|assertion|subject_token|private_key|secret_key|X-Amz-Security-Token|X-Amz-Signature)=[^&\s"]*`),
+	regexp.MustCompile(`(?i)"(access_token|id_token|refresh_token|client_secret|private_key|private_key_id|secret_key|AccessKeyId|SecretAccessKey|SessionToken)"\s*:\s*"[^"]*"`),
+	regexp.MustCompile(`<(AccessKeyId|SecretAccessKey|SessionToken)>[^<]*</(AccessKeyId|SecretAccessKey|SessionToken)>`),
+}
+
+// redacted replaces secrets
+const redacted = "REDACTED"
+
+// redact replaces the secrets of s
+func redact(s string) string {
+	s = secrets[0].ReplaceAllString(s, "$1="+redacted)
+	s = secrets[1].ReplaceAllString(s, `"$1":"`+redacted+`"`)
+	s = secrets[2].ReplaceAllString(s, "<$1>"+redacted+"</$2>")
+	return s
+}
+
+// volatile matches dates and times, which change between a recording and its replay
+var volatile = regexp.MustCompile(`\d{4}-\d{2}-\d{2}([T ]\d{2}:\d{2}(:\d{2}(\.\d+)?)?(Z|[+-]\d{2}:?\d{2})?)?`)
+
+// keys return the keys matching a request with the recorded exchanges. The operation key
+// identifies the api operation: method, host, path and aws operation. The exact key adds
+// the query and body, without their dates and times.
+func keys(method string, rawURL string, header http.Header, body string) (operation string, exact string) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL, method + " " + rawURL + " " + body
+	}
+
+	isForm := strings.HasPrefix(header.Get("Content-Type"), "application/x-www-form-urlencoded")
+	action := header.Get("X-Amz-Target")
+	if isForm {
+		if form, err := url.ParseQuery(body); err == nil && form.Has("Action") {
+			action = form.Get("Action")
+		}
+	}
+
+	operation = method + " " + u.Host + u.EscapedPath() + " " + action
+	if isForm {
+		body = normalizeValues(body)
+	}
+	return operation, operation + " " + normalizeValues(u.RawQuery) + " " + volatile.ReplaceAllString(body, "{time}")
+}
+
+// normalizeValues sorts url encoded values and replaces their dates and times
+func normalizeValues(encoded string) string {
+	values, err := url.ParseQuery(encoded)
+	if err != nil {
+		return volatile.ReplaceAllString(encoded, "{time}")
+	}
+	for key := range values {
+		for i, value := range values[key] {
+			values[key][i] = volatile.ReplaceAllString(value, "{time}")
+		}
+	}
+	return values.Encode()
+}
+
+// encodeBody returns the body as a string, base64 encoded if it is not valid utf-8
+func encodeBody(body []byte) (string, bool) {
+	if utf8.Valid(body) {
+		return string(body), false
+	}
+	return base64.StdEncoding.EncodeToString(body), true
+}
+
+// decodeBody returns the bytes of a recorded body
+func decodeBody(body string, isBase64 bool) ([]byte, error) {
+	if isBase64 {
+		return base64.StdEncoding.DecodeString(body)
+	}
+	return []byte(body), nil
+}
+
+// keepHeaders returns the values of the header names found in header
+func keepHeaders(header http.Header, names []string) map[string]string {
+	kept := make(map[string]string)
+	for _, name := range names {
+		if value := header.Get(name); value != "" {
+			kept[name] = value
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// toHeader converts recorded headers to an http.Header
+func toHeader(recorded map[string]string) http.Header {
+	header := make(http.Header, len(recorded))
+	for name, value := range recorded {
+		header.Set(name, value)
+	}
+	return header
+}
+
+// newExchange returns the redacted exchange of the request and response bodies. Gzip
+// encoded responses are recorded decoded.
+func newExchange(req *http.Request, reqBody []byte, resp *http.Response, respBody []byte) (*Exchange, error) {
+	respHeader := keepHeaders(resp.Header, responseHeaders)
+	if strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") && len(respBody) > 0 {
+		reader, err := gzip.NewReader(bytes.NewReader(respBody))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode gzip response: %w", err)
+		}
+		if respBody, err = io.ReadAll(reader); err != nil {
+			return nil, fmt.Errorf("failed to decode gzip response: %w", err)
+		}
+	}
+
+	exchange := &Exchange{
+		Request: Request{
+			Method: req.Method,
+			URL:    redact(req.URL.String()),
+			Header: keepHeaders(req.Header, requestHeaders),
+		},
+		Response: Response{
+			StatusCode: resp.StatusCode,
+			Header:     respHeader,
+		},
+	}
+	exchange.Request.Body, exchange.Request.Base64 = encodeBody(reqBody)
+	exchange.Response.Body, exchange.Response.Base64 = encodeBody(respBody)
+	if !exchange.Request.Base64 {
+		exchange.Request.Body = redact(exchange.Request.Body)
+	}
+	if !exchange.Response.Base64 {
+		exchange.Response.Body = redact(exchange.Response.Body)
+	}
+
+	return exchange, nil
+}
+
+// LoadFixture reads the exchanges of a fixture file
+func LoadFixture(path string) ([]*Exchange, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixture: %w", err)
+	}
+	defer f.Close()
+
+	exchanges := make([]*Exchange, 0)
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		exchange := new(Exchange)
+		if err := json.Unmarshal(scanner.Bytes(), exchange); err != nil {
+			return nil, fmt.Errorf("failed to decode fixture %s line %d: %w", path, line, err)
+		}
+		exchanges = append(exchanges, exchange)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fixture %s: %w", path, err)
+	}
+
+	return exchanges, nil
+}
+
+// FixtureName returns the fixture file name of an explorer
+func FixtureName(explorer string) string {
+	return strings.Map(func(r rune) rune {
+		if slices.Contains([]rune{'/', '\\', ':', ' '}, r) {
+			return '_'
+		}
+		return r
+	}, explorer) + ".jsonl"
+}
@@ -0,0 +1,114 @@
+package replay
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		form, _ := url.ParseQuery(string(body))
+		switch form.Get("Action") {
+		case "AssumeRole":
+			w.Header().Set("Content-Type", "text/xml")
+			io.WriteString(w, "<Credentials><AccessKeyId>ASIA123</AccessKeyId><SecretAccessKey>s3cr3t</SecretAccessKey><SessionToken>t0k3n</SessionToken></Credentials>")
+		case "GetMetricData":
+			w.Header().Set("Content-Type", "text/xml")
+			w.Header().Set("Content-Encoding", "gzip")
+			writer := gzip.NewWriter(w)
+			io.WriteString(writer, "<Values>"+form.Get("MetricDataQueries.member.1.Id")+"</Values>")
+			writer.Close()
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("Set-Cookie", "session=1")
+			io.WriteString(w, `{"access_token": "ya29.secret", "items": ["a"]}`)
+		}
+	}))
+	defer server.Close()
+
+	fixture := filepath.Join(t.TempDir(), "aws.jsonl")
+	client := &http.Client{Transport: NewRecorder(fixture, nil)}
+
+	post := func(client *http.Client, form url.Values) (string, error) {
+		req, _ := http.NewRequest(http.MethodPost, server.URL+"/", strings.NewReader(form.Encode()))
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		req.Header.Set("Authorization", "AWS4-HMAC-SHA256 Credential=ASIA123")
+		req.Header.Set("Accept-Encoding", "gzip")
+		resp, err := client.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.Header.Get("Content-Encoding") == "gzip" {
+			reader, _ := gzip.NewReader(resp.Body)
+			body, _ := io.ReadAll(reader)
+			return string(body), nil
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body), nil
+	}
+	metricData := func(id string, start string) url.Values {
+		return url.Values{"Action": {"GetMetricData"}, "MetricDataQueries.member.1.Id": {id}, "StartTime": {start}}
+	}
+
+	body, err := post(client, url.Values{"Action": {"AssumeRole"}})
+	assert.NoError(t, err)
+	assert.Contains(t, body, "s3cr3t", "live responses are not redacted")
+	_, err = post(client, metricData("m1", "2025-06-01T10:00:00Z"))
+	assert.NoError(t, err)
+	_, err = post(client, metricData("m2", "2025-06-01T10:00:00Z"))
+	assert.NoError(t, err)
+	resp, err := client.Get(server.URL + "/v1/items?access_token=ya29.secret&filter=a")
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	exchanges, err := LoadFixture(fixture)
+	assert.NoError(t, err)
+	assert.Len(t, exchanges, 4)
+	assert.Equal(t, "<Credentials><AccessKeyId>REDACTED</AccessKeyId><SecretAccessKey>REDACTED</SecretAccessKey><SessionToken>REDACTED</SessionToken></Credentials>", exchanges[0].Response.Body)
+	assert.Equal(t, map[string]string{"Content-Type": "application/x-www-form-urlencoded"}, exchanges[0].Request.Header, "authorization must not be recorded")
+	assert.Equal(t, "<Values>m1</Values>", exchanges[1].Response.Body, "gzip responses are recorded decoded")
+	assert.Equal(t, server.URL+"/v1/items?access_token=REDACTED&filter=a", exchanges[3].Request.URL)
+	assert.Equal(t, `{"access_token":"REDACTED", "items": ["a"]}`, exchanges[3].Response.Body)
+	assert.Equal(t, map[string]string{"Content-Type": "application/json"}, exchanges[3].Response.Header)
+
+	server.Close()
+	player, err := Load(fixture)
+	assert.NoError(t, err)
+	client = &http.Client{Transport: player}
+
+	// dates and times are ignored, requests are matched by their other values
+	body, err = post(client, metricData("m2", "2025-07-14T08:30:00Z"))
+	assert.NoError(t, err)
+	assert.Equal(t, "<Values>m2</Values>", body)
+	body, err = post(client, metricData("m1", "2025-07-14T08:30:00Z"))
+	assert.NoError(t, err)
+	assert.Equal(t, "<Values>m1</Values>", body)
+
+	// unmatched requests of a recorded operation are served in the recorded order
+	body, err = post(client, metricData("m3", "2025-07-14T08:30:00Z"))
+	assert.NoError(t, err)
+	assert.Equal(t, "<Values>m1</Values>", body)
+
+	resp, err = client.Get(server.URL + "/v1/items?access_token=other&filter=a")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+
+	_, err = client.Get(server.URL + "/v2/items")
+	assert.ErrorContains(t, err, "no recorded exchange for GET")
+}
+
+func TestFixtureName(t *testing.T) {
+	assert.Equal(t, "aws-prod.jsonl", FixtureName("aws-prod"))
+	assert.Equal(t, "gcp_folders_1.jsonl", FixtureName("gcp/folders 1"))
+}
@@ -2,10 +2,14 @@ package scw
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"net/http"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/scaleway/scaleway-sdk-go/api/instance/v1"
 	"github.com/scaleway/scaleway-sdk-go/scw"
@@ -34,16 +38,59 @@ func WithRegions(regions ...string) ExplorerOption {
 	}
 }
 
+// WithPUEModel sets the power usage effectiveness of the datacenters by location and month
+func WithPUEModel(model primitives.PUEModel) ExplorerOption {
+	return func(e *Explorer) {
+		e.pue = model
+	}
+}
+
+// WithIntensityOverrides replaces the grid carbon intensity (gCO2eq/kWh) of the locations
+func WithIntensityOverrides(overrides map[string]float64) ExplorerOption {
+	return func(e *Explorer) {
+		e.intensityOverrides = overrides
+	}
+}
+
+// WithIntensityProviders sets the time-varying grid carbon intensity providers, tried in
+// order before the static carbon intensity map
+func WithIntensityProviders(providers ...cloudcarbonexporter.IntensityProvider) ExplorerOption {
+	return func(e *Explorer) {
+		e.intensityProviders = providers
+	}
+}
+
+// WithCarbonFreeEnergy sets the carbon-free energy share (0 to 1) of the locations used for
+// market-based emissions. Other locations have none.
+func WithCarbonFreeEnergy(cfe map[string]float64) ExplorerOption {
+	return func(e *Explorer) {
+		e.carbonFreeEnergy = carbon.CarbonFreeEnergyMap(nil).WithOverrides(cfe)
+	}
+}
+
 type Explorer struct {
 	client             *scw.Client
 	regions            []scw.Region
+	pue                primitives.PUEModel
 	carbonIntensityMap carbon.IntensityMap
+	wue                carbon.WaterMap
+	gridWaterIntensity carbon.WaterMap
+	intensityOverrides map[string]float64
+	intensityProviders []cloudcarbonexporter.IntensityProvider
+	locations          *atomic.Pointer[carbon.LocationModel]
+	carbonFreeEnergy   carbon.CarbonFreeEnergyMap
+	readiness          *cloudcarbonexporter.Readiness
 }
 
 func NewExplorer() *Explorer {
 	return &Explorer{
 		regions:            scw.AllRegions,
+		pue:                primitives.NewPUEModel("scw"),
+		locations:          new(atomic.Pointer[carbon.LocationModel]),
 		carbonIntensityMap: carbon.NewScalewayCloudCarbonFootprintIntensityMap(),
+		wue:                carbon.NewScalewayWUEMap(),
+		gridWaterIntensity: carbon.NewGridWaterIntensityMap(),
+		readiness:          cloudcarbonexporter.NewReadiness("client", "credentials"),
 	}
 }
 
@@ -54,10 +101,37 @@ func (explorer *Explorer) Configure(opts ...ExplorerOption) *Explorer {
 	return explorer
 }
 
+// newLocationModel returns the factors of the explored locations
+func (explorer *Explorer) newLocationModel() *carbon.LocationModel {
+	return &carbon.LocationModel{
+		PUE: explorer.pue,
+		Intensity: carbon.NewExplorerIntensityProvider(
+			carbon.NewStaticIntensityProvider("ccf-scaleway", explorer.carbonIntensityMap),
+			explorer.intensityOverrides,
+			explorer.intensityProviders...,
+		),
+		CarbonFreeEnergy:   explorer.carbonFreeEnergy,
+		WUE:                explorer.wue,
+		GridWaterIntensity: explorer.gridWaterIntensity,
+	}
+}
+
+// UpdateFactors replaces the pue, carbon intensity overrides and carbon-free energy of the
+// explorer. Its client is kept.
+func (explorer *Explorer) UpdateFactors(settings *cloudcarbonexporter.ExplorerSettings) {
+	explorer.Configure(factorsOptions(settings)...)
+	explorer.locations.Store(explorer.newLocationModel())
+}
+
 func (explorer *Explorer) Init(ctx context.Context) (err error) {
 	if explorer.client == nil {
-		return fmt.Errorf("scaleway client is required")
+		err := fmt.Errorf("scaleway client is required")
+		explorer.readiness.Set("client", err)
+		return err
 	}
+	explorer.readiness.Set("client", nil)
+	explorer.locations.Store(explorer.newLocationModel())
+	explorer.readiness.Set("credentials", fmt.Errorf("credentials not validated yet by a successful api call"))
 
 	return nil
 }
@@ -73,65 +147,120 @@ func (explorer *Explorer) Tags() map[string]string {
 }
 
 func (explorer *Explorer) CollectImpacts(ctx cloudcarbonexporter.Context, impacts chan *cloudcarbonexporter.Impact, errs chan error) {
-	wg := new(sync.WaitGroup)
-
 	rawImpacts := make(chan *cloudcarbonexporter.Impact)
-	defer close(rawImpacts)
+	collectedAt := time.Now()
+
+	forwarder := new(sync.WaitGroup)
+	forwarder.Add(1)
 
 	go func() {
+		defer forwarder.Done()
 		for rawImpact := range rawImpacts {
 			location, found := rawImpact.Labels["location"]
 			if !found {
 				slog.Warn("impact location not found, skipping impact. please consider raising a bug.", "labels", rawImpact.Labels)
 				continue
 			}
-			rawImpact.Energy = rawImpact.Energy * primitives.GoodPUE
-			rawImpact.EnergyEmissions = explorer.carbonIntensityMap.EnergyEmissions(rawImpact.Energy, location)
+			factors, err := explorer.locations.Load().Factors(ctx, location, collectedAt)
+			if err != nil {
+				slog.Warn("carbon intensity not found, skipping impact", "location", location, "err", err.Error())
+				continue
+			}
+			rawImpact.ApplyLocationFactors(factors)
 			impacts <- rawImpact
 		}
 	}()
 
+	wg := new(sync.WaitGroup)
 	for _, region := range explorer.regions {
 		region := region
 		wg.Add(1)
 		go func() {
 			defer wg.Done()
-			errs <- explorer.findRegionalInstances(ctx, region, rawImpacts)
+			errs <- cloudcarbonexporter.ObserveSubExplorer(ctx, "instance", region.String(), rawImpacts, func(impacts chan *cloudcarbonexporter.Impact) error {
+				return explorer.findRegionalInstances(ctx, region, impacts)
+			})
 		}()
 	}
 	wg.Wait()
 
+	close(rawImpacts)
+	forwarder.Wait()
 }
 
-func (explorer *Explorer) IsReady() bool { return true }
+// IsReady returns true once the credentials have been validated by a successful api call
+func (explorer *Explorer) IsReady() bool { return explorer.readiness.IsReady() }
+
+// ReadinessChecks returns the state of the client and its credentials
+func (explorer *Explorer) ReadinessChecks() []cloudcarbonexporter.ReadinessCheck {
+	return explorer.readiness.Checks()
+}
 
 func (explorer *Explorer) Close() error { return nil }
 
-func (explorer *Explorer) findRegionalInstances(ctx context.Context, region scw.Region, impacts chan *cloudcarbonexporter.Impact) error {
+func (explorer *Explorer) findRegionalInstances(ctx cloudcarbonexporter.Context, region scw.Region, impacts chan *cloudcarbonexporter.Impact) error {
 	api := instance.NewAPI(explorer.client)
 
+	ctx.IncrCalls("instance/v1:ListServers")
 	resp, err := api.ListServers(&instance.ListServersRequest{Zone: scw.ZonePlWaw1}, scw.WithContext(ctx), scw.WithAllPages(), scw.WithZones(region.GetZones()...))
 	if err != nil {
+		if isAuthenticationErr(err) {
+			explorer.readiness.Set("credentials", err)
+		}
 		return &cloudcarbonexporter.ExplorerErr{Err: fmt.Errorf("failed to list %s region servers: %w", region, err), Operation: "instance/v1:ListServers"}
 	}
+	explorer.readiness.Set("credentials", nil)
 
 	for _, server := range resp.Servers {
 		processor := primitives.LookupProcessorByName("TODO")
-		energy := processor.EstimateCPUEnergy(1, 0)
-		energy += primitives.EstimateMemoryEnergy(4)
 
-		impacts <- &cloudcarbonexporter.Impact{
-			Energy: energy,
+		impact := &cloudcarbonexporter.Impact{
+			Inputs: cloudcarbonexporter.ImpactInputs{
+				InstanceType: server.CommercialType,
+				VCPU:         1,
+				MemoryGB:     4,
+				Processor:    processor.Inputs(),
+			},
 			Labels: map[string]string{
 				"name":          server.Name,
+				"location":      string(region),
 				"region":        string(region),
 				"project":       server.Project,
 				"instance_name": server.Name,
-				"tags":          strings.Join(server.Tags, ","),
 			},
+			Tags: parseTags(server.Tags),
 		}
+		impact.AddComponent(cloudcarbonexporter.ComponentCPU, processor.EstimateCPUEnergy(1, 0), cloudcarbonexporter.ZeroEmissions)
+		impact.AddComponent(cloudcarbonexporter.ComponentMemory, primitives.EstimateMemoryEnergy(4), cloudcarbonexporter.ZeroEmissions)
+
+		impacts <- impact
 	}
 
 	return nil
 
 }
+
+// parseTags converts scaleway tags into key values. Tags formatted as key=value are split,
+// other tags are set to "true".
+func parseTags(list []string) map[string]string {
+	tags := make(map[string]string, len(list))
+	for _, tag := range list {
+		key, value, found := strings.Cut(tag, "=")
+		if !found {
+			value = "true"
+		}
+		tags[key] = value
+	}
+	return tags
+}
+
+// isAuthenticationErr returns true if the api rejected the client credentials
+func isAuthenticationErr(err error) bool {
+	var deniedErr *scw.DeniedAuthenticationError
+	var permissionsErr *scw.PermissionsDeniedError
+	var responseErr *scw.ResponseError
+	if errors.As(err, &deniedErr) || errors.As(err, &permissionsErr) {
+		return true
+	}
+	return errors.As(err, &responseErr) && (responseErr.StatusCode == http.StatusUnauthorized || responseErr.StatusCode == http.StatusForbidden)
+}
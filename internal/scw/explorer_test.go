@@ -0,0 +1,50 @@
+package scw
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/replay"
+)
+
+func TestCollectImpactsReplay(t *testing.T) {
+	player, err := replay.Load("testdata/fr-par.jsonl")
+	assert.NoError(t, err)
+
+	explorer, err := newProviderExplorer(t.Context(), &cloudcarbonexporter.ExplorerSettings{
+		Regions:   []string{"fr-par"},
+		Transport: player,
+		Offline:   true,
+	}, new(Config))
+	assert.NoError(t, err)
+	assert.NoError(t, explorer.Init(t.Context()))
+	assert.False(t, explorer.IsReady(), "credentials are validated by the first api call")
+
+	// collecting twice replays the same exchanges
+	for range 2 {
+		impacts := make(chan *cloudcarbonexporter.Impact)
+		errs := make(chan error)
+		go func() {
+			defer close(impacts)
+			defer close(errs)
+			explorer.CollectImpacts(cloudcarbonexporter.WrapCtx(t.Context()), impacts, errs)
+		}()
+
+		go func() {
+			for err := range errs {
+				assert.NoError(t, err)
+			}
+		}()
+
+		names := make([]string, 0)
+		for impact := range impacts {
+			assert.Equal(t, "fr-par", impact.Labels["location"])
+			assert.Equal(t, "ccf-scaleway", impact.Inputs.CarbonIntensitySource)
+			assert.Greater(t, float64(impact.Energy), 0.0)
+			names = append(names, impact.Labels["instance_name"])
+		}
+		assert.ElementsMatch(t, []string{"web-1", "web-2", "db-1"}, names)
+	}
+	assert.True(t, explorer.IsReady())
+}
@@ -0,0 +1,94 @@
+package scw
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"os"
+	"slices"
+
+	"github.com/scaleway/scaleway-sdk-go/scw"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
+)
+
+func init() {
+	cloudcarbonexporter.RegisterExplorerProvider(cloudcarbonexporter.ExplorerProvider{
+		Name:              "scw",
+		SupportedServices: NewExplorer().SupportedServices(),
+		Locations:         slices.Sorted(maps.Keys(NewExplorer().carbonIntensityMap)),
+		NewConfig:         func() cloudcarbonexporter.ProviderConfig { return new(Config) },
+		New:               newProviderExplorer,
+	})
+}
+
+// offlineAccessKey and offlineSecretKey are the placeholder credentials of offline
+// explorers, formatted as expected by the client
+const (
+	offlineAccessKey = "SCWOFFLINE0000000000"
+	offlineSecretKey = "00000000-0000-0000-0000-000000000000"
+)
+
+// Config is the scw section of an explorer configuration. Scaleway explorers have no
+// specific settings.
+type Config struct{}
+
+// Validate implements the cloudcarbonexporter.ProviderConfig interface
+func (config *Config) Validate(settings *cloudcarbonexporter.ExplorerSettings) []*cloudcarbonexporter.FieldError {
+	errs := make([]*cloudcarbonexporter.FieldError, 0)
+	if settings.Credentials.Profile != "" {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"credentials", "profile"}, Msg: "is not supported by scw explorers"})
+	}
+	if settings.Credentials.File != "" {
+		errs = append(errs, &cloudcarbonexporter.FieldError{Path: []string{"credentials", "file"}, Msg: "is not supported by scw explorers"})
+	}
+	return errs
+}
+
+// newProviderExplorer creates an explorer from the shared settings. The access and secret
+// keys are read from SCW_ACCESS_KEY and SCW_SECRET_KEY unless other environment variables
+// are configured. Offline explorers use placeholder keys.
+func newProviderExplorer(ctx context.Context, settings *cloudcarbonexporter.ExplorerSettings, providerConfig cloudcarbonexporter.ProviderConfig) (cloudcarbonexporter.Explorer, error) {
+	accessKeyEnv, secretKeyEnv := "SCW_ACCESS_KEY", "SCW_SECRET_KEY"
+	if settings.Credentials.AccessKeyEnv != "" {
+		accessKeyEnv = settings.Credentials.AccessKeyEnv
+	}
+	if settings.Credentials.SecretKeyEnv != "" {
+		secretKeyEnv = settings.Credentials.SecretKeyEnv
+	}
+
+	accessKey, secretKey := os.Getenv(accessKeyEnv), os.Getenv(secretKeyEnv)
+	if settings.Offline {
+		accessKey, secretKey = offlineAccessKey, offlineSecretKey
+	}
+
+	clientOpts := []scw.ClientOption{scw.WithAuth(accessKey, secretKey)}
+	if settings.Transport != nil {
+		clientOpts = append(clientOpts, scw.WithHTTPClient(&http.Client{Transport: settings.Transport}))
+	}
+	client, err := scw.NewClient(clientOpts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load scaleway client: %w", err)
+	}
+
+	opts := []ExplorerOption{
+		WithClient(client),
+		WithIntensityProviders(settings.IntensityProviders...),
+	}
+	opts = append(opts, factorsOptions(settings)...)
+	if len(settings.Regions) > 0 {
+		opts = append(opts, WithRegions(settings.Regions...))
+	}
+
+	return NewExplorer().Configure(opts...), nil
+}
+
+// factorsOptions returns the options setting the emission factors of the explorer
+func factorsOptions(settings *cloudcarbonexporter.ExplorerSettings) []ExplorerOption {
+	return []ExplorerOption{
+		WithIntensityOverrides(settings.Intensity),
+		WithCarbonFreeEnergy(settings.CarbonFreeEnergy),
+		WithPUEModel(primitives.NewPUEModel("scw").WithOverrides(settings.PUE, settings.LocationPUE, settings.MonthlyPUE)),
+	}
+}
@@ -0,0 +1,114 @@
+package cloudcarbonexporter
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// ReservedLabels are set by the exporter and can never be overwritten by cloud tags
+var ReservedLabels = []string{"explorer", "kind", "location", "component", "method", "scope"}
+
+// LabelPolicy turns the cloud tags of an impact into labels. It is applied to all explorers
+// so tags are exposed consistently whatever the cloud provider.
+type LabelPolicy struct {
+	// TagPrefix is prepended to tag keys
+	TagPrefix string
+	// Allow lists the tag keys exposed as labels. All tags are allowed when empty.
+	Allow []*regexp.Regexp
+	// Deny lists the tag keys never exposed as labels, even if allowed.
+	Deny []*regexp.Regexp
+	// Renames maps a label name, tag prefix included, to a new name.
+	Renames map[string]string
+}
+
+// DefaultLabelPolicy exposes all tags prefixed with tag_
+func DefaultLabelPolicy() *LabelPolicy {
+	return &LabelPolicy{
+		TagPrefix: "tag_",
+		Renames:   make(map[string]string),
+	}
+}
+
+// CompileTagPatterns compiles regular expressions matching whole tag keys
+func CompileTagPatterns(patterns ...string) ([]*regexp.Regexp, error) {
+	compiled := make([]*regexp.Regexp, 0, len(patterns))
+	for _, pattern := range patterns {
+		re, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile tag pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// ParseRenames parses renames formatted as old=new
+func ParseRenames(renames ...string) (map[string]string, error) {
+	parsed := make(map[string]string, len(renames))
+	for _, rename := range renames {
+		from, to, found := strings.Cut(rename, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !found || from == "" || to == "" {
+			return nil, fmt.Errorf("invalid label rename %q: expected old=new", rename)
+		}
+		if slices.Contains(ReservedLabels, to) {
+			return nil, fmt.Errorf("invalid label rename %q: %s is a reserved label", rename, to)
+		}
+		parsed[from] = to
+	}
+	return parsed, nil
+}
+
+// Labels returns the impact labels completed with its tags. Built-in labels set by the
+// explorer and reserved labels are never overwritten.
+func (policy *LabelPolicy) Labels(impact *Impact) map[string]string {
+	labels := make(map[string]string, len(impact.Labels)+len(impact.Tags))
+	for name, value := range impact.Labels {
+		if value == "" {
+			continue
+		}
+		labels[policy.rename(name)] = value
+	}
+
+	keys := make([]string, 0, len(impact.Tags))
+	for key := range impact.Tags {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	for _, key := range keys {
+		value := impact.Tags[key]
+		if value == "" || !policy.allowed(key) {
+			continue
+		}
+
+		name := policy.rename(policy.TagPrefix + key)
+		if _, found := labels[name]; found || slices.Contains(ReservedLabels, name) {
+			slog.Debug("tag conflicts with an existing label, skipping", "tag", key, "label", name)
+			continue
+		}
+		labels[name] = value
+	}
+
+	return labels
+}
+
+func (policy *LabelPolicy) allowed(key string) bool {
+	if len(policy.Allow) > 0 && !slices.ContainsFunc(policy.Allow, func(re *regexp.Regexp) bool { return re.MatchString(key) }) {
+		return false
+	}
+	return !slices.ContainsFunc(policy.Deny, func(re *regexp.Regexp) bool { return re.MatchString(key) })
+}
+
+func (policy *LabelPolicy) rename(name string) string {
+	if slices.Contains(ReservedLabels, name) {
+		return name
+	}
+	if renamed, found := policy.Renames[name]; found {
+		return renamed
+	}
+	return name
+}
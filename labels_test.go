@@ -0,0 +1,55 @@
+package cloudcarbonexporter_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+)
+
+func TestLabelPolicy(t *testing.T) {
+	impact := &cloudcarbonexporter.Impact{
+		Labels: map[string]string{"kind": "compute/Instance", "location": "europe-west1", "zone": ""},
+		Tags: map[string]string{
+			"team":     "data",
+			"env":      "prod",
+			"owner":    "jane",
+			"empty":    "",
+			"location": "paris",
+		},
+	}
+
+	assert.Equal(t, map[string]string{
+		"kind":         "compute/Instance",
+		"location":     "europe-west1",
+		"tag_team":     "data",
+		"tag_env":      "prod",
+		"tag_owner":    "jane",
+		"tag_location": "paris",
+	}, cloudcarbonexporter.DefaultLabelPolicy().Labels(impact))
+
+	allow, err := cloudcarbonexporter.CompileTagPatterns("team|env|location")
+	assert.NoError(t, err)
+	deny, err := cloudcarbonexporter.CompileTagPatterns("en.")
+	assert.NoError(t, err)
+	renames, err := cloudcarbonexporter.ParseRenames("team=squad")
+	assert.NoError(t, err)
+
+	policy := &cloudcarbonexporter.LabelPolicy{
+		Allow:   allow,
+		Deny:    deny,
+		Renames: renames,
+	}
+
+	// unprefixed tags can't overwrite built-in labels
+	assert.Equal(t, map[string]string{
+		"kind":     "compute/Instance",
+		"location": "europe-west1",
+		"squad":    "data",
+	}, policy.Labels(impact))
+
+	_, err = cloudcarbonexporter.ParseRenames("location=kind")
+	assert.Error(t, err)
+	_, err = cloudcarbonexporter.CompileTagPatterns("(")
+	assert.Error(t, err)
+}
@@ -0,0 +1,30 @@
+package carbon
+
+import (
+	"maps"
+	"strings"
+)
+
+// CarbonFreeEnergyMap regroups by location the share of carbon-free energy (0 to 1) matched
+// by the provider energy purchases, used for market-based emissions
+type CarbonFreeEnergyMap map[string]float64
+
+// Fraction returns the carbon-free energy share of the longest map location prefixing the
+// location. Unknown locations have no carbon-free energy.
+func (cfe CarbonFreeEnergyMap) Fraction(location string) float64 {
+	fraction, _ := IntensityMap(cfe).lookup(location)
+	return fraction
+}
+
+// WithOverrides returns a copy of the map where the share of the overridden locations is
+// replaced. Locations are matched by prefix, like the map ones.
+func (cfe CarbonFreeEnergyMap) WithOverrides(overrides map[string]float64) CarbonFreeEnergyMap {
+	overridden := maps.Clone(cfe)
+	if overridden == nil {
+		overridden = make(CarbonFreeEnergyMap, len(overrides))
+	}
+	for location, fraction := range overrides {
+		overridden[strings.ToLower(location)] = fraction
+	}
+	return overridden
+}
@@ -0,0 +1,22 @@
+package carbon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGCPCarbonFreeEnergyMap(t *testing.T) {
+	cfe := NewGCPCarbonFreeEnergyMap()
+
+	assert.Equal(t, 0.82, cfe.Fraction("europe-west1-b"))
+	assert.Equal(t, 0.0, cfe.Fraction("mars-north1"), "unknown locations have no carbon-free energy")
+	assert.Greater(t, cfe.Fraction("eur4"), 0.0, "multi-regions average their regions")
+
+	overridden := cfe.WithOverrides(map[string]float64{"Europe-West1": 1, "europe-west9": 0})
+	assert.Equal(t, 1.0, overridden.Fraction("europe-west1-b"))
+	assert.Equal(t, 0.0, overridden.Fraction("europe-west9-a"))
+	assert.Equal(t, 0.82, cfe.Fraction("europe-west1-b"), "overrides do not change the map")
+
+	assert.Equal(t, 0.5, CarbonFreeEnergyMap(nil).WithOverrides(map[string]float64{"eu-west-1": 0.5}).Fraction("eu-west-1"))
+}
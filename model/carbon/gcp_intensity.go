@@ -11,10 +11,28 @@ import (
 //go:embed data/gcp_region_carbon_info_2023.csv
 var carboninfo embed.FS
 
+// gcpCarbonInfo columns
+const (
+	gcpCFEColumn       = 2
+	gcpIntensityColumn = 3
+)
+
 // NewGCPCarbonIntensityMap loads and parse official carbon data provided by GCP
 // https://github.com/GoogleCloudPlatform/region-carbon-info
 // Should be updated each year.
 func NewGCPCarbonIntensityMap() IntensityMap {
+	return loadGCPCarbonInfo(gcpIntensityColumn)
+}
+
+// NewGCPCarbonFreeEnergyMap loads the hourly carbon-free energy percentage (Google CFE) of
+// the GCP regions from the same dataset as NewGCPCarbonIntensityMap
+func NewGCPCarbonFreeEnergyMap() CarbonFreeEnergyMap {
+	return CarbonFreeEnergyMap(loadGCPCarbonInfo(gcpCFEColumn))
+}
+
+// loadGCPCarbonInfo returns the values of a column of the GCP carbon data by location,
+// along with the averages of the multi-regions
+func loadGCPCarbonInfo(column int) IntensityMap {
 	f, err := carboninfo.Open("data/gcp_region_carbon_info_2023.csv")
 	must.NoError(err)
 
@@ -31,9 +49,7 @@ func NewGCPCarbonIntensityMap() IntensityMap {
 		}
 		must.Assert(len(location) == 4, "csv line must be 4 fields length")
 		region := location[0]
-		co2eqbykwh := strToFloat64(location[3])
-
-		intensity[region] = co2eqbykwh
+		intensity[region] = strToFloat64(location[column])
 	}
 
 	intensity["emea"] = intensity.Average("eu", "me", "af")
@@ -2,11 +2,12 @@ package carbon
 
 import (
 	"log/slog"
+	"maps"
 	"strings"
-	"time"
 
 	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
 	"github.com/superdango/cloud-carbon-exporter/internal/must"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
 )
 
 // IntensityMap regroups carbon intensity by location
@@ -39,35 +40,52 @@ func hasOnePrefix(s string, prefixes ...string) bool {
 	return false
 }
 
-func (intensity IntensityMap) EmissionsPerKWh(location string) cloudcarbonexporter.Emissions {
+// lookup returns the intensity of the longest map location prefixing the location on a
+// boundary, see primitives.HasLocationPrefix
+func (intensity IntensityMap) lookup(location string) (float64, bool) {
 	location = strings.ToLower(location)
 	locationsize := 0
 	locationIntensity := 0.0
 
 	for l, carbonIntensity := range intensity {
-		if strings.HasPrefix(location, l) {
+		if primitives.HasLocationPrefix(location, l) {
 			if len(l) > locationsize {
 				locationsize = len(l)
 				locationIntensity = carbonIntensity
 			}
 		}
 	}
-	if locationIntensity == 0.0 {
-		slog.Debug("location co2 intensity not found, assuming global intensity", "location", location)
-		var found bool
-		locationIntensity, found = intensity["global"]
+	return locationIntensity, locationIntensity != 0.0
+}
+
+func (intensity IntensityMap) EmissionsPerKWh(location string) cloudcarbonexporter.Emissions {
+	return cloudcarbonexporter.Emissions(intensity.resolve(location))
+}
+
+// resolve returns the value of the longest map location prefixing the location, or the
+// global one if none does
+func (intensity IntensityMap) resolve(location string) float64 {
+	value, found := intensity.lookup(location)
+	if !found {
+		slog.Debug("location coefficient not found, assuming global coefficient", "location", location)
+		value, found = intensity["global"]
 		must.Assert(found, "global coefficient not set")
 	}
+	return value
+}
 
-	return cloudcarbonexporter.Emissions(locationIntensity)
+// WithOverrides returns a copy of the intensity map where the intensity of the overridden
+// locations is replaced. Locations are matched by prefix, like the map ones.
+func (intensity IntensityMap) WithOverrides(overrides map[string]float64) IntensityMap {
+	overridden := maps.Clone(intensity)
+	for location, carbonIntensity := range overrides {
+		overridden[strings.ToLower(location)] = carbonIntensity
+	}
+	return overridden
 }
 
 // EnergyEmissions takes an energy value as input and return its carbon emission equivalent using
 // the source location label.
 func (intensityMap IntensityMap) EnergyEmissions(energy cloudcarbonexporter.Energy, location string) (emissions cloudcarbonexporter.EmissionsOverTime) {
-	kW := float64(energy / 1000)
-	return cloudcarbonexporter.EmissionsOverTime{
-		Emissions: cloudcarbonexporter.Emissions(kW) * intensityMap.EmissionsPerKWh(location),
-		During:    1 * time.Hour,
-	}
+	return cloudcarbonexporter.EnergyEmissions(energy, intensityMap.EmissionsPerKWh(location))
 }
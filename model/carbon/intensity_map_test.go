@@ -33,3 +33,17 @@ func TestCO2Compute(t *testing.T) {
 	// this scenario emits 24kgCO2eq
 	assert.Equal(t, 24, int(testMap.EnergyEmissions(1000, "global").KgCO2eq_day()))
 }
+
+func TestIntensityMapWithOverrides(t *testing.T) {
+	testMap := IntensityMap{
+		"global":       2,
+		"europe-west1": 1,
+	}
+
+	overridden := testMap.WithOverrides(map[string]float64{"Europe-West1": 0.5, "us-east1": 3})
+
+	assert.Equal(t, cloudcarbonexporter.Emissions(0.5), overridden.EmissionsPerKWh("europe-west1-b"))
+	assert.Equal(t, cloudcarbonexporter.Emissions(3), overridden.EmissionsPerKWh("us-east1"))
+	assert.Equal(t, cloudcarbonexporter.Emissions(2), overridden.EmissionsPerKWh("europe-west10-a"), "europe-west1 does not prefix europe-west10")
+	assert.Equal(t, cloudcarbonexporter.Emissions(1), testMap.EmissionsPerKWh("europe-west1"), "source map must not be modified")
+}
@@ -0,0 +1,164 @@
+package carbon
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+)
+
+// OverrideSource is the source of the overridden intensities
+const OverrideSource = "override"
+
+// StaticIntensityProvider returns the yearly average intensity of an intensity map. The
+// global intensity is returned for unknown locations.
+type StaticIntensityProvider struct {
+	source       string
+	intensityMap IntensityMap
+}
+
+// NewStaticIntensityProvider returns a provider of the map intensities labelled with source
+func NewStaticIntensityProvider(source string, intensityMap IntensityMap) *StaticIntensityProvider {
+	return &StaticIntensityProvider{
+		source:       source,
+		intensityMap: intensityMap,
+	}
+}
+
+// Intensity implements the cloudcarbonexporter.IntensityProvider interface
+func (provider *StaticIntensityProvider) Intensity(ctx context.Context, location string, at time.Time) (cloudcarbonexporter.Intensity, error) {
+	return cloudcarbonexporter.Intensity{
+		PerKWh: provider.intensityMap.EmissionsPerKWh(location),
+		Source: provider.source,
+	}, nil
+}
+
+// OverrideIntensityProvider returns the configured intensity of the overridden locations,
+// matched by prefix like the intensity maps ones
+type OverrideIntensityProvider struct {
+	overrides IntensityMap
+}
+
+// NewOverrideIntensityProvider returns a provider of the overridden intensities
+func NewOverrideIntensityProvider(overrides map[string]float64) *OverrideIntensityProvider {
+	provider := &OverrideIntensityProvider{overrides: make(IntensityMap, len(overrides))}
+	for location, carbonIntensity := range overrides {
+		provider.overrides[strings.ToLower(location)] = carbonIntensity
+	}
+	return provider
+}
+
+// Intensity implements the cloudcarbonexporter.IntensityProvider interface
+func (provider *OverrideIntensityProvider) Intensity(ctx context.Context, location string, at time.Time) (cloudcarbonexporter.Intensity, error) {
+	carbonIntensity, found := provider.overrides.lookup(location)
+	if !found {
+		return cloudcarbonexporter.Intensity{}, cloudcarbonexporter.ErrIntensityNotFound
+	}
+	return cloudcarbonexporter.Intensity{
+		PerKWh: cloudcarbonexporter.Emissions(carbonIntensity),
+		Source: OverrideSource,
+	}, nil
+}
+
+// FallbackIntensityProvider returns the intensity of the first of its providers returning
+// one
+type FallbackIntensityProvider struct {
+	providers []cloudcarbonexporter.IntensityProvider
+}
+
+// NewFallbackIntensityProvider returns a provider trying the providers in order
+func NewFallbackIntensityProvider(providers ...cloudcarbonexporter.IntensityProvider) *FallbackIntensityProvider {
+	return &FallbackIntensityProvider{providers: providers}
+}
+
+// Intensity implements the cloudcarbonexporter.IntensityProvider interface. It returns the
+// error of the last provider if none returned an intensity.
+func (provider *FallbackIntensityProvider) Intensity(ctx context.Context, location string, at time.Time) (cloudcarbonexporter.Intensity, error) {
+	err := cloudcarbonexporter.ErrIntensityNotFound
+	for _, fallback := range provider.providers {
+		var intensity cloudcarbonexporter.Intensity
+		intensity, err = fallback.Intensity(ctx, location, at)
+		if err == nil {
+			return intensity, nil
+		}
+		if !errors.Is(err, cloudcarbonexporter.ErrIntensityNotFound) {
+			slog.Debug("failed to get carbon intensity, falling back to the next provider", "location", location, "err", err.Error())
+		}
+	}
+	return cloudcarbonexporter.Intensity{}, err
+}
+
+// NewExplorerIntensityProvider returns the intensity provider of an explorer. The intensity of
+// the overridden locations is returned first, then the one of the time-varying providers in
+// order and the one of the cloud provider static map last.
+func NewExplorerIntensityProvider(static *StaticIntensityProvider, overrides map[string]float64, providers ...cloudcarbonexporter.IntensityProvider) *FallbackIntensityProvider {
+	chain := make([]cloudcarbonexporter.IntensityProvider, 0, len(providers)+2)
+	if len(overrides) > 0 {
+		chain = append(chain, NewOverrideIntensityProvider(overrides))
+	}
+	chain = append(chain, providers...)
+	chain = append(chain, static)
+	return NewFallbackIntensityProvider(chain...)
+}
+
+// cachedIntensity is an intensity, or the error returned instead, kept in cache
+type cachedIntensity struct {
+	intensity cloudcarbonexporter.Intensity
+	err       error
+	expiresAt time.Time
+}
+
+// CachedIntensityProvider keeps the intensities and errors of a provider in cache, by
+// location and time truncated to the cache ttl, so a slow or rate limited provider is
+// queried once per location and period.
+type CachedIntensityProvider struct {
+	provider cloudcarbonexporter.IntensityProvider
+	ttl      time.Duration
+	entries  map[string]*cachedIntensity
+	mu       *sync.Mutex
+	now      func() time.Time
+}
+
+// NewCachedIntensityProvider returns a provider caching the provider intensities during ttl
+func NewCachedIntensityProvider(provider cloudcarbonexporter.IntensityProvider, ttl time.Duration) *CachedIntensityProvider {
+	return &CachedIntensityProvider{
+		provider: provider,
+		ttl:      ttl,
+		entries:  make(map[string]*cachedIntensity),
+		mu:       new(sync.Mutex),
+		now:      time.Now,
+	}
+}
+
+// Intensity implements the cloudcarbonexporter.IntensityProvider interface
+func (provider *CachedIntensityProvider) Intensity(ctx context.Context, location string, at time.Time) (cloudcarbonexporter.Intensity, error) {
+	key := location + "@" + at.Truncate(provider.ttl).UTC().Format(time.RFC3339)
+
+	provider.mu.Lock()
+	entry, found := provider.entries[key]
+	provider.mu.Unlock()
+	if found && provider.now().Before(entry.expiresAt) {
+		return entry.intensity, entry.err
+	}
+
+	intensity, err := provider.provider.Intensity(ctx, location, at)
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return intensity, err
+	}
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	now := provider.now()
+	for key, entry := range provider.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(provider.entries, key)
+		}
+	}
+	provider.entries[key] = &cachedIntensity{intensity: intensity, err: err, expiresAt: now.Add(provider.ttl)}
+
+	return intensity, err
+}
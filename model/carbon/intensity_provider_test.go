@@ -0,0 +1,96 @@
+package carbon
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+)
+
+// liveIntensityProvider returns an intensity varying with the hour in the locations it knows
+type liveIntensityProvider struct {
+	locations []string
+	calls     int
+	err       error
+}
+
+func (provider *liveIntensityProvider) Intensity(ctx context.Context, location string, at time.Time) (cloudcarbonexporter.Intensity, error) {
+	provider.calls++
+	if provider.err != nil {
+		return cloudcarbonexporter.Intensity{}, provider.err
+	}
+	for _, known := range provider.locations {
+		if known == location {
+			return cloudcarbonexporter.Intensity{
+				PerKWh:    cloudcarbonexporter.Emissions(100 + at.Hour()),
+				Source:    "live",
+				Timestamp: at.Truncate(time.Hour),
+			}, nil
+		}
+	}
+	return cloudcarbonexporter.Intensity{}, cloudcarbonexporter.ErrIntensityNotFound
+}
+
+func TestExplorerIntensityProvider(t *testing.T) {
+	at := time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC)
+	static := NewStaticIntensityProvider("static", IntensityMap{"europe-west1": 1, "us-east1": 2, "global": 3})
+	provider := NewExplorerIntensityProvider(static, map[string]float64{"Europe-West1": 0.5}, &liveIntensityProvider{locations: []string{"europe-west1", "us-east1"}})
+
+	intensity, err := provider.Intensity(t.Context(), "europe-west1-b", at)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Intensity{PerKWh: 0.5, Source: OverrideSource}, intensity, "overrides come first")
+
+	intensity, err = provider.Intensity(t.Context(), "us-east1", at)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Intensity{PerKWh: 110, Source: "live", Timestamp: time.Date(2025, 6, 1, 10, 0, 0, 0, time.UTC)}, intensity)
+
+	intensity, err = provider.Intensity(t.Context(), "asia-east1", at)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Intensity{PerKWh: 3, Source: "static"}, intensity, "static map falls back to global")
+
+	failing := NewExplorerIntensityProvider(static, nil, &liveIntensityProvider{err: errors.New("rate limited")})
+	intensity, err = failing.Intensity(t.Context(), "us-east1", at)
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.Intensity{PerKWh: 2, Source: "static"}, intensity)
+
+	_, err = NewFallbackIntensityProvider(&liveIntensityProvider{}).Intensity(t.Context(), "us-east1", at)
+	assert.ErrorIs(t, err, cloudcarbonexporter.ErrIntensityNotFound)
+}
+
+func TestCachedIntensityProvider(t *testing.T) {
+	now := time.Date(2025, 6, 1, 10, 30, 0, 0, time.UTC)
+	live := &liveIntensityProvider{locations: []string{"us-east1"}}
+	cached := NewCachedIntensityProvider(live, time.Hour)
+	cached.now = func() time.Time { return now }
+
+	first, err := cached.Intensity(t.Context(), "us-east1", now)
+	assert.NoError(t, err)
+	second, err := cached.Intensity(t.Context(), "us-east1", now.Add(10*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, live.calls)
+
+	_, err = cached.Intensity(t.Context(), "us-east1", now.Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 2, live.calls, "other periods are cached apart")
+
+	_, err = cached.Intensity(t.Context(), "asia-east1", now)
+	assert.ErrorIs(t, err, cloudcarbonexporter.ErrIntensityNotFound)
+	_, err = cached.Intensity(t.Context(), "asia-east1", now)
+	assert.ErrorIs(t, err, cloudcarbonexporter.ErrIntensityNotFound)
+	assert.Equal(t, 3, live.calls, "errors are cached")
+
+	now = now.Add(time.Hour)
+	_, err = cached.Intensity(t.Context(), "us-east1", now.Add(-50*time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, 4, live.calls, "expired entries are queried again")
+	assert.Len(t, cached.entries, 1, "expired entries are evicted")
+
+	live.err = context.Canceled
+	_, err = cached.Intensity(t.Context(), "eu-west1", now)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NotContains(t, cached.entries, "eu-west1@2025-06-01T11:00:00Z", "canceled queries are not cached")
+}
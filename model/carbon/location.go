@@ -0,0 +1,37 @@
+package carbon
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
+)
+
+// LocationModel resolves the factors of the datacenters and of the grid at the locations of
+// a provider
+type LocationModel struct {
+	PUE                primitives.PUEModel
+	Intensity          cloudcarbonexporter.IntensityProvider
+	CarbonFreeEnergy   CarbonFreeEnergyMap
+	WUE                WaterMap
+	GridWaterIntensity WaterMap
+}
+
+// Factors returns the factors of the location at time t. It fails if the grid carbon
+// intensity of the location is not found.
+func (model *LocationModel) Factors(ctx context.Context, location string, t time.Time) (cloudcarbonexporter.LocationFactors, error) {
+	intensity, err := model.Intensity.Intensity(ctx, location, t)
+	if err != nil {
+		return cloudcarbonexporter.LocationFactors{}, fmt.Errorf("failed to get %s carbon intensity: %w", location, err)
+	}
+
+	return cloudcarbonexporter.LocationFactors{
+		PUE:                model.PUE.PUE(location, t),
+		Intensity:          intensity,
+		CarbonFreeEnergy:   model.CarbonFreeEnergy.Fraction(location),
+		WUE:                model.WUE.LitresPerKWh(location),
+		GridWaterIntensity: model.GridWaterIntensity.LitresPerKWh(location),
+	}, nil
+}
@@ -0,0 +1,33 @@
+package carbon
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/model/primitives"
+)
+
+func TestLocationModel(t *testing.T) {
+	model := &LocationModel{
+		PUE:                primitives.NewPUEModel("scw"),
+		Intensity:          NewOverrideIntensityProvider(map[string]float64{"fr-par": 32}),
+		CarbonFreeEnergy:   CarbonFreeEnergyMap{"fr-par": 0.9},
+		WUE:                NewScalewayWUEMap(),
+		GridWaterIntensity: NewGridWaterIntensityMap(),
+	}
+
+	factors, err := model.Factors(t.Context(), "fr-par-2", time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, cloudcarbonexporter.LocationFactors{
+		PUE:                1.16,
+		Intensity:          cloudcarbonexporter.Intensity{PerKWh: 32, Source: "override"},
+		CarbonFreeEnergy:   0.9,
+		WUE:                1.8,
+		GridWaterIntensity: 1.5,
+	}, factors)
+
+	_, err = model.Factors(t.Context(), "mars-north1", time.Now())
+	assert.ErrorIs(t, err, cloudcarbonexporter.ErrIntensityNotFound)
+}
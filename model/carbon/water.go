@@ -0,0 +1,125 @@
+package carbon
+
+// WaterMap regroups by location a water consumption factor in litres per kWh: the water
+// usage effectiveness (WUE) of the provider datacenters, or the water intensity of the grid
+// supplying them. Locations are resolved like the carbon intensity ones.
+type WaterMap map[string]float64
+
+// LitresPerKWh returns the factor of the longest map location prefixing the location, or
+// the global one if none does
+func (water WaterMap) LitresPerKWh(location string) float64 {
+	return IntensityMap(water).resolve(location)
+}
+
+// industryWUE is the average water usage effectiveness of datacenters, used for providers
+// not publishing their own. Source: United States Data Center Energy Usage Report, LBNL 2016
+const industryWUE = 1.8
+
+// NewAWSWUEMap returns the water usage effectiveness of the AWS datacenters. AWS only
+// publishes its fleet WUE, in its 2023 sustainability report.
+func NewAWSWUEMap() WaterMap {
+	return WaterMap{"global": 0.18}
+}
+
+// NewGCPWUEMap returns the water usage effectiveness of the Google datacenters, estimated
+// from the water consumption and the electricity consumption of the datacenters published
+// in the Google 2024 environmental report.
+func NewGCPWUEMap() WaterMap {
+	return WaterMap{"global": 0.96}
+}
+
+// NewScalewayWUEMap returns the water usage effectiveness of the Scaleway datacenters.
+// Scaleway does not publish it, so the industry average is used.
+func NewScalewayWUEMap() WaterMap {
+	return WaterMap{"global": industryWUE}
+}
+
+// NewGridWaterIntensityMap returns rough estimates of the water consumed to generate 1 kWh
+// on the grid of each provider region, from the share of thermal and nuclear generation of
+// the grid: their cooling evaporates about 2 litres per kWh while wind, solar and
+// hydropower consume almost none. Reservoirs evaporation is not accounted for.
+func NewGridWaterIntensityMap() WaterMap {
+	gridWaterIntensityMap := WaterMap{
+		// aws
+		"af-south-1":     1.8,
+		"ap-east-1":      1.9,
+		"ap-northeast-1": 1.4,
+		"ap-northeast-2": 1.8,
+		"ap-northeast-3": 1.4,
+		"ap-south-1":     1.5,
+		"ap-south-2":     1.5,
+		"ap-southeast-1": 1.9,
+		"ap-southeast-2": 1.4,
+		"ap-southeast-3": 1.6,
+		"ap-southeast-4": 1.3,
+		"ca-central-1":   0.1,
+		"ca-west-1":      1.4,
+		"eu-central-1":   0.9,
+		"eu-central-2":   0.65,
+		"eu-north-1":     0.6,
+		"eu-south-1":     1.0,
+		"eu-south-2":     0.75,
+		"eu-west-1":      0.9,
+		"eu-west-2":      1.0,
+		"eu-west-3":      1.5,
+		"il-central-1":   1.8,
+		"me-central-1":   1.9,
+		"me-south-1":     2.0,
+		"sa-east-1":      0.3,
+		"us-east-1":      1.8,
+		"us-east-2":      1.8,
+		"us-west-1":      0.9,
+		"us-west-2":      0.3,
+
+		// gcp
+		"africa-south1":           1.8,
+		"asia-east1":              1.7,
+		"asia-east2":              1.9,
+		"asia-northeast1":         1.4,
+		"asia-northeast2":         1.4,
+		"asia-northeast3":         1.8,
+		"asia-south1":             1.5,
+		"asia-south2":             1.5,
+		"asia-southeast1":         1.9,
+		"asia-southeast2":         1.6,
+		"australia-southeast1":    1.4,
+		"australia-southeast2":    1.3,
+		"europe-central2":         1.4,
+		"europe-north1":           1.0,
+		"europe-southwest1":       0.75,
+		"europe-west1":            1.2,
+		"europe-west2":            1.0,
+		"europe-west3":            0.9,
+		"europe-west4":            1.0,
+		"europe-west6":            0.65,
+		"europe-west8":            1.0,
+		"europe-west9":            1.5,
+		"europe-west10":           0.9,
+		"europe-west12":           1.0,
+		"me-central1":             2.0,
+		"me-central2":             2.0,
+		"me-west1":                1.8,
+		"northamerica-northeast1": 0.1,
+		"northamerica-northeast2": 1.2,
+		"southamerica-east1":      0.3,
+		"southamerica-west1":      0.8,
+		"us-central1":             0.8,
+		"us-east1":                1.7,
+		"us-east4":                1.8,
+		"us-east5":                1.8,
+		"us-south1":               1.5,
+		"us-west1":                0.4,
+		"us-west2":                0.9,
+		"us-west3":                1.5,
+		"us-west4":                1.2,
+
+		// scaleway
+		"fr-par": 1.5,
+		"nl-ams": 1.0,
+		"pl-waw": 1.4,
+	}
+
+	gridWaterIntensityMap["global"] = IntensityMap(gridWaterIntensityMap).Average()
+
+	return gridWaterIntensityMap
+}
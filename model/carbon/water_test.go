@@ -0,0 +1,16 @@
+package carbon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWaterMap(t *testing.T) {
+	grid := NewGridWaterIntensityMap()
+	assert.Equal(t, 1.5, grid.LitresPerKWh("eu-west-3"))
+	assert.Equal(t, 0.9, grid.LitresPerKWh("europe-west10-a"), "longest prefix")
+	assert.Equal(t, 1.5, grid.LitresPerKWh("fr-par-2"))
+	assert.Equal(t, grid["global"], grid.LitresPerKWh("mars-north1"), "global fallback")
+	assert.Equal(t, 0.18, NewAWSWUEMap().LitresPerKWh("eu-west-3"))
+}
@@ -0,0 +1,87 @@
+package primitives
+
+import (
+	"log/slog"
+	"strings"
+
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+	"github.com/superdango/cloud-carbon-exporter/internal/must"
+)
+
+type GPU struct {
+	Name string
+	Tdp  float64
+}
+
+// Boards holding two GPUs (K520, K80, M60) are listed with half of the board TDP as
+// cloud providers count each GPU of the board.
+var gpus = []GPU{
+	{Name: "AMD Radeon Pro V520", Tdp: 225},
+	{Name: "NVIDIA A100", Tdp: 400},
+	{Name: "NVIDIA A10G", Tdp: 150},
+	{Name: "NVIDIA H100", Tdp: 700},
+	{Name: "NVIDIA H200", Tdp: 700},
+	{Name: "NVIDIA K520", Tdp: 112.5},
+	{Name: "NVIDIA K80", Tdp: 150},
+	{Name: "NVIDIA L4", Tdp: 72},
+	{Name: "NVIDIA L40S", Tdp: 350},
+	{Name: "NVIDIA M60", Tdp: 150},
+	{Name: "NVIDIA P100", Tdp: 250},
+	{Name: "NVIDIA P4", Tdp: 75},
+	{Name: "NVIDIA T4", Tdp: 70},
+	{Name: "NVIDIA T4G", Tdp: 70},
+	{Name: "NVIDIA V100", Tdp: 300},
+}
+
+// unknownGPU is used for accelerators missing from the database, with the TDP of a
+// common datacenter GPU
+var unknownGPU = GPU{Name: "unknown", Tdp: 300}
+
+// GPUAverageUsage is the usage assumed for all GPUs, in percent. Cloud providers do not
+// expose GPU usage without an agent running in the instance.
+const GPUAverageUsage = 50.0
+
+// gpuIdleRatio is the share of the TDP drawn by an idle datacenter GPU
+const gpuIdleRatio = 0.1
+
+// LookupGPUByName returns the GPU of the database matching the model name. Model names
+// may carry a variant suffix, like "NVIDIA A100 80GB".
+func LookupGPUByName(gpuName string) GPU {
+	for _, gpu := range gpus {
+		if gpuName == gpu.Name || strings.HasPrefix(gpuName, gpu.Name+" ") {
+			return gpu
+		}
+	}
+
+	slog.Debug("gpu not found, using a default tdp", "source", gpuName, "tdp", unknownGPU.Tdp)
+
+	return unknownGPU
+}
+
+// EstimateGPUEnergy returns the power drawn by count GPUs. It grows linearly from the
+// idle power to the TDP with the usage.
+func (gpu GPU) EstimateGPUEnergy(count float64, usage float64) (energy cloudcarbonexporter.Energy) {
+	must.Assert(count > 0, "gpu count must be greater than 0")
+	watts := gpu.Tdp * (gpuIdleRatio + (1-gpuIdleRatio)*usage/100)
+	return cloudcarbonexporter.Energy(watts * count)
+}
+
+// Inputs returns the gpus description attached to impacts inputs
+func (gpu GPU) Inputs(count float64, usage float64) *cloudcarbonexporter.GPUInputs {
+	return &cloudcarbonexporter.GPUInputs{
+		Name:  gpu.Name,
+		TDP:   gpu.Tdp,
+		Count: count,
+		Usage: usage,
+	}
+}
+
+// EstimateGPUEmbodiedEmissions returns the embodied emissions of count GPUs. NVIDIA
+// reports about 1.3 tCO2eq for the 8 GPUs HGX H100 baseboard, which is rounded to 160
+// kgCO2eq per GPU.
+func EstimateGPUEmbodiedEmissions(count float64) cloudcarbonexporter.EmissionsOverTime {
+	return cloudcarbonexporter.EmissionsOverTime{
+		During:    4 * YEAR,
+		Emissions: cloudcarbonexporter.Emissions(160_000 * count),
+	}
+}
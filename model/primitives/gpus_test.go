@@ -0,0 +1,29 @@
+package primitives
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	cloudcarbonexporter "github.com/superdango/cloud-carbon-exporter"
+)
+
+func TestLookupGPUByName(t *testing.T) {
+	assert.Equal(t, "NVIDIA A100", LookupGPUByName("NVIDIA A100 80GB").Name)
+	assert.Equal(t, "NVIDIA A10G", LookupGPUByName("NVIDIA A10G").Name)
+	assert.Equal(t, "NVIDIA L4", LookupGPUByName("NVIDIA L4").Name)
+	assert.Equal(t, "NVIDIA L40S", LookupGPUByName("NVIDIA L40S").Name)
+	assert.Equal(t, "NVIDIA H100", LookupGPUByName("NVIDIA H100 80GB Mega").Name)
+	assert.Equal(t, "unknown", LookupGPUByName("NVIDIA A1000").Name)
+}
+
+func TestEstimateGPUEnergy(t *testing.T) {
+	t4 := LookupGPUByName("NVIDIA T4")
+	assert.Equal(t, cloudcarbonexporter.Energy(7), t4.EstimateGPUEnergy(1, 0))
+	assert.Equal(t, cloudcarbonexporter.Energy(140), t4.EstimateGPUEnergy(2, 100))
+	assert.InDelta(t, 38.5, float64(t4.EstimateGPUEnergy(1, GPUAverageUsage)), 0.0000001)
+	assert.Panics(t, func() { t4.EstimateGPUEnergy(0, 50) })
+}
+
+func TestGPUEmbodiedEmissions(t *testing.T) {
+	assert.InDelta(t, 80, EstimateGPUEmbodiedEmissions(2).KgCO2eq_year(), 0.0000001)
+}
@@ -0,0 +1,20 @@
+package primitives
+
+import "strings"
+
+// HasLocationPrefix returns true if the lowercase location starts with the prefix on a
+// boundary: a prefix ending with a digit does not match a location continuing with another
+// digit, so that europe-west1 matches europe-west1-b but not europe-west10.
+func HasLocationPrefix(location string, prefix string) bool {
+	if !strings.HasPrefix(location, prefix) {
+		return false
+	}
+	if len(location) == len(prefix) || prefix == "" {
+		return true
+	}
+	return !isDigit(prefix[len(prefix)-1]) || !isDigit(location[len(prefix)])
+}
+
+func isDigit(c byte) bool {
+	return '0' <= c && c <= '9'
+}
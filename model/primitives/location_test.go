@@ -0,0 +1,19 @@
+package primitives
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHasLocationPrefix(t *testing.T) {
+	assert.True(t, HasLocationPrefix("europe-west1", "europe-west1"))
+	assert.True(t, HasLocationPrefix("europe-west1-b", "europe-west1"))
+	assert.True(t, HasLocationPrefix("eu-west-3a", "eu-west-3"))
+	assert.True(t, HasLocationPrefix("europe-west10", "europe-west"))
+	assert.True(t, HasLocationPrefix("eu-west-3", "eu-"))
+	assert.True(t, HasLocationPrefix("eu-west-3", ""))
+	assert.False(t, HasLocationPrefix("europe-west10", "europe-west1"))
+	assert.False(t, HasLocationPrefix("fr-par-12", "fr-par-1"))
+	assert.False(t, HasLocationPrefix("us-east1", "europe"))
+}
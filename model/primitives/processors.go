@@ -68,6 +68,17 @@ func (p Processor) EstimateCPUEnergy(activeThreads float64, usage float64) (ener
 	return cloudcarbonexporter.Energy(tdpToWatt(p.Tdp, usage) / p.Threads)
 }
 
+// Inputs returns the processor description attached to impacts inputs
+func (p Processor) Inputs() *cloudcarbonexporter.ProcessorInputs {
+	return &cloudcarbonexporter.ProcessorInputs{
+		Name:    p.Name,
+		Family:  p.Family,
+		TDP:     p.Tdp,
+		Cores:   p.Cores,
+		Threads: p.Threads,
+	}
+}
+
 func EstimateCPUEmbodiedEmissions(vcpu float64) (emissions cloudcarbonexporter.EmissionsOverTime) {
 	return cloudcarbonexporter.EmissionsOverTime{
 		During:    4 * YEAR,
@@ -1,4 +1,128 @@
 package primitives
 
+import (
+	"maps"
+	"strings"
+	"time"
+)
+
 // GoodPUE stand for a good datacenter power usage effictivenemt.
 const GoodPUE = 1.15
+
+// PUE is the power usage effectiveness of datacenters, with an optional monthly variation
+type PUE struct {
+	// Annual is the yearly average
+	Annual float64
+	// Monthly holds the PUE of each month, January first. Months without value use Annual.
+	Monthly [12]float64
+}
+
+// At returns the PUE of the month of t
+func (pue PUE) At(t time.Time) float64 {
+	if monthly := pue.Monthly[t.Month()-1]; monthly != 0 {
+		return monthly
+	}
+	return pue.Annual
+}
+
+// PUEModel holds the power usage effectiveness of the datacenters of a provider
+type PUEModel struct {
+	// Fleet is the PUE of the locations without their own
+	Fleet PUE
+	// Locations holds the PUE of the datacenters by lowercase location prefix
+	Locations map[string]PUE
+}
+
+// providerPUEModels holds the PUE published by the providers. GCP values are the trailing
+// twelve months PUE of the campus hosting each region, AWS ones are averaged by geography
+// and Scaleway ones are measured by datacenter.
+var providerPUEModels = map[string]PUEModel{
+	"aws": {
+		Fleet: PUE{Annual: 1.15},
+		Locations: map[string]PUE{
+			"ap-": {Annual: 1.28},
+			"ca-": {Annual: 1.14},
+			"eu-": {Annual: 1.12},
+			"sa-": {Annual: 1.14},
+			"us-": {Annual: 1.14},
+		},
+	},
+	"gcp": {
+		Fleet: PUE{Annual: 1.10},
+		Locations: map[string]PUE{
+			"asia-east1":         {Annual: 1.12},
+			"asia-southeast1":    {Annual: 1.13},
+			"europe-north1":      {Annual: 1.09},
+			"europe-west1":       {Annual: 1.08},
+			"europe-west4":       {Annual: 1.08},
+			"southamerica-west1": {Annual: 1.09},
+			"us-central1":        {Annual: 1.10},
+			"us-east1":           {Annual: 1.10},
+			"us-east4":           {Annual: 1.10},
+			"us-west1":           {Annual: 1.08},
+		},
+	},
+	"scw": {
+		Fleet: PUE{Annual: 1.35},
+		Locations: map[string]PUE{
+			"fr-par-1": {Annual: 1.32},
+			"fr-par-2": {Annual: 1.16},
+		},
+	},
+}
+
+// NewPUEModel returns the PUE model of a provider, with the GoodPUE fleet PUE if the
+// provider does not publish its own
+func NewPUEModel(provider string) PUEModel {
+	model, found := providerPUEModels[provider]
+	if !found {
+		return PUEModel{Fleet: PUE{Annual: GoodPUE}}
+	}
+	return PUEModel{Fleet: model.Fleet, Locations: maps.Clone(model.Locations)}
+}
+
+// WithOverrides returns a copy of the model where a non zero pue replaces the PUE of all
+// locations, and where the PUE of the locations and the PUE of each month of the locations
+// take precedence, by location prefix.
+func (model PUEModel) WithOverrides(pue float64, locations map[string]float64, monthly map[string][]float64) PUEModel {
+	overridden := PUEModel{Fleet: model.Fleet, Locations: make(map[string]PUE, len(model.Locations)+len(locations))}
+	if pue != 0 {
+		overridden.Fleet = PUE{Annual: pue}
+	} else {
+		maps.Copy(overridden.Locations, model.Locations)
+	}
+
+	for location, locationPUE := range locations {
+		overridden.Locations[strings.ToLower(location)] = PUE{Annual: locationPUE}
+	}
+	for location, months := range monthly {
+		location = strings.ToLower(location)
+		locationPUE, found := overridden.Locations[location]
+		if !found {
+			locationPUE = PUE{Annual: overridden.lookup(location).Annual}
+		}
+		copy(locationPUE.Monthly[:], months)
+		overridden.Locations[location] = locationPUE
+	}
+	return overridden
+}
+
+// PUE returns the PUE at time t of the longest model location prefixing the location, or
+// the fleet one if none does
+func (model PUEModel) PUE(location string, t time.Time) float64 {
+	return model.lookup(location).At(t)
+}
+
+// lookup returns the PUE of the longest model location prefixing the location, or the
+// fleet one if none does
+func (model PUEModel) lookup(location string) PUE {
+	location = strings.ToLower(location)
+	longest := -1
+	pue := model.Fleet
+	for prefix, locationPUE := range model.Locations {
+		if len(prefix) > longest && HasLocationPrefix(location, prefix) {
+			longest, pue = len(prefix), locationPUE
+		}
+	}
+	return pue
+}
@@ -0,0 +1,38 @@
+package primitives
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPUEModel(t *testing.T) {
+	january := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	july := time.Date(2025, 7, 15, 0, 0, 0, 0, time.UTC)
+
+	scw := NewPUEModel("scw")
+	assert.Equal(t, 1.16, scw.PUE("fr-par-2", july))
+	assert.Equal(t, 1.32, scw.PUE("FR-PAR-1", july))
+	assert.Equal(t, 1.35, scw.PUE("nl-ams-1", july))
+	assert.Equal(t, 1.08, NewPUEModel("gcp").PUE("europe-west1-b", july))
+	assert.Equal(t, 1.10, NewPUEModel("gcp").PUE("europe-west10-a", july), "europe-west1 does not prefix europe-west10")
+	assert.Equal(t, 1.10, NewPUEModel("gcp").PUE("europe-west12", july))
+	assert.Equal(t, GoodPUE, NewPUEModel("onprem").PUE("lyon", july))
+
+	overridden := scw.WithOverrides(0, map[string]float64{"nl-ams": 1.3}, map[string][]float64{
+		"fr-par-2": {1.1, 1.1, 1.1, 1.1, 1.1, 1.2, 1.25, 1.25, 1.2, 1.1, 1.1, 1.1},
+		"pl-waw":   {0, 0, 0, 0, 0, 0, 1.5},
+	})
+	assert.Equal(t, 1.1, overridden.PUE("fr-par-2", january))
+	assert.Equal(t, 1.25, overridden.PUE("fr-par-2", july))
+	assert.Equal(t, 1.32, overridden.PUE("fr-par-1", july))
+	assert.Equal(t, 1.3, overridden.PUE("nl-ams-1", july))
+	assert.Equal(t, 1.35, overridden.PUE("pl-waw-1", january), "months without value use the annual pue")
+	assert.Equal(t, 1.5, overridden.PUE("pl-waw-1", july))
+	assert.Equal(t, 1.16, scw.PUE("fr-par-2", july), "overrides do not change the model")
+
+	fleet := scw.WithOverrides(1.4, map[string]float64{"fr-par-2": 1.2}, nil)
+	assert.Equal(t, 1.4, fleet.PUE("fr-par-1", july), "pue replaces the pue of all locations")
+	assert.Equal(t, 1.2, fleet.PUE("fr-par-2", july))
+}
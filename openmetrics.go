@@ -2,29 +2,25 @@ package cloudcarbonexporter
 
 import (
 	"context"
-	"errors"
-	"fmt"
-	"io"
 	"log/slog"
 	"maps"
 	"net/http"
-	"slices"
-	"strings"
 	"sync/atomic"
 	"time"
-
-	"golang.org/x/sync/errgroup"
 )
 
 type Ctx struct {
 	context.Context
 	calls *atomic.Int64
+	stats *CollectStats
 }
 
 type Context interface {
 	context.Context
-	IncrCalls()
+	// IncrCalls counts a call to the cloud api operation
+	IncrCalls(operation string)
 	Calls() int
+	Stats() *CollectStats
 }
 
 func WrapCtx(ctx context.Context) Context {
@@ -36,167 +32,59 @@ func WrapCtx(ctx context.Context) Context {
 	return &Ctx{
 		Context: ctx,
 		calls:   new(atomic.Int64),
+		stats:   NewCollectStats(),
 	}
 }
 
-func (c *Ctx) IncrCalls() {
+func (c *Ctx) IncrCalls(operation string) {
 	c.calls.Add(1)
+	c.stats.IncrCalls(operation)
 }
 
 func (c *Ctx) Calls() int {
 	return int(c.calls.Load())
 }
 
+func (c *Ctx) Stats() *CollectStats {
+	return c.stats
+}
+
 // OpenMetricsHandler implements the http.Handler interface
 type OpenMetricsHandler struct {
-	defaultTimeout time.Duration
-	explorer       Explorer
-	explorerName   string
+	source SnapshotSource
 }
 
-// NewOpenMetricsHandler create a new OpenMetricsHandler
-func NewOpenMetricsHandler(explorerName string, explorer Explorer) *OpenMetricsHandler {
+// NewOpenMetricsHandler create a new OpenMetricsHandler serving the source last snapshot
+func NewOpenMetricsHandler(source SnapshotSource) *OpenMetricsHandler {
 	return &OpenMetricsHandler{
-		defaultTimeout: 10 * time.Second,
-		explorer:       explorer,
-		explorerName:   explorerName,
+		source: source,
 	}
 }
 
-// ServeHTTP implements the http.Handler interface. It collects all metrics from the configured
-// collector and return them, formatted in the http response.
+// ServeHTTP implements the http.Handler interface. It returns the metrics of the last snapshot
+// taken by the source collectors, formatted in the http response.
 func (handler *OpenMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	start := time.Now()
-	impacts := make(chan *Impact)
-	metrics := make(chan *Metric)
-	errs := make(chan error)
-	errCount := 0
 
 	traceAttr := slog.Attr{}
 	if traceID := r.Header.Get("X-Cloud-Trace-Context"); traceID != "" {
 		traceAttr = slog.String("logging.googleapis.com/trace", traceID)
 	}
 
-	baseLabels := map[string]string{
-		"explorer": handler.explorerName,
-	}
-
-	errg, errgctx := errgroup.WithContext(r.Context())
-	errgctx, cancel := context.WithTimeout(errgctx, handler.defaultTimeout)
-	defer cancel()
-
-	errg.Go(func() error {
-		defer close(impacts)
-		defer close(errs)
-
-		ctx := WrapCtx(errgctx)
-		handler.explorer.CollectImpacts(ctx, impacts, errs)
-
-		metrics <- &Metric{
-			Name:   "collect_duration_ms",
-			Labels: baseLabels,
-			Value:  float64(time.Since(start).Milliseconds()),
-		}
-
-		metrics <- &Metric{
-			Name:   "error_count",
-			Labels: baseLabels,
-			Value:  float64(errCount),
-		}
-
-		metrics <- &Metric{
-			Name:   "api_calls",
-			Labels: baseLabels,
-			Value:  float64(ctx.Calls()),
-		}
-
-		return nil
-	})
-
-	errg.Go(func() error {
-		defer close(metrics)
-		for impact := range impacts {
-			impact.Labels = MergeLabels(impact.Labels, baseLabels)
-			metrics <- NewEnergyMetric(impact.Energy).SetLabels(impact.Labels)
-			metrics <- NewEmissionsMetric(impact.EnergyEmissions).SetLabels(impact.Labels)
-			metrics <- NewEmbodiedEmissionsMetric(impact.EmbodiedEmissions).SetLabels(impact.Labels)
-		}
-
-		return nil
-	})
-
-	errg.Go(func() error {
-		for err := range errs {
-			if err == nil {
-				continue
-			}
-
-			errCount++
-
-			experr := new(ExplorerErr)
-			if errors.As(err, &experr) {
-				slog.Warn("metrics collection failed", "err", experr, "op", experr.Operation)
-				continue
-			}
-			slog.Warn("metrics collection failed", "err", err.Error())
-		}
-
-		return nil
-	})
-
-	errg.Go(func() error {
-		return writeMetrics(errgctx, w, metrics)
-	})
-
-	err := errg.Wait()
-	if err != nil {
-		slog.Error("failed to collect metrics", "err", err.Error(), traceAttr)
-		http.Error(w, err.Error(), 500)
+	if handler.source.Snapshot() == nil {
+		http.Error(w, "no collection completed yet", http.StatusServiceUnavailable)
 		return
 	}
 
-	slog.Info("metrics have been successfully collected", traceAttr, "duration_ms", time.Since(start).Milliseconds())
-}
-
-// writeMetrics write all metrics sent over the channel and write them on the writer.
-// Metrics labels are sorted lexicographically before being written.
-func writeMetrics(ctx context.Context, w io.Writer, metrics chan *Metric) error {
-	for {
-		select {
-		case <-ctx.Done():
-			return nil
-		case metric, ok := <-metrics:
-			if !ok {
-				return nil
-			}
-
-			if metric == nil {
-				slog.Warn("discarding nil metric")
-				continue
-			}
-			if err := writeMetric(w, metric); err != nil {
-				return fmt.Errorf("failed to write metric on writer: %w", err)
-			}
-		}
-	}
-}
+	format := NegotiateFormat(r.Header.Get("Accept"))
+	w.Header().Set("Content-Type", string(format))
 
-func writeMetric(w io.Writer, metric *Metric) error {
-	metric = metric.SanitizeLabels()
-
-	// sort labels in lexicographical order
-	labels := make([]string, 0, len(metric.Labels))
-	for labelName, labelValue := range metric.Labels {
-		labels = append(labels, fmt.Sprintf(`%s="%s"`, labelName, labelValue))
-	}
-	slices.SortFunc(labels, strings.Compare)
-
-	_, err := fmt.Fprintf(w, "%s{%s} %0.10f\n", metric.Name, strings.Join(labels, ","), metric.Value)
-	if err != nil {
-		return fmt.Errorf("writing metric %s failed: %w", metric.Name, err)
+	if err := NewEncoder(w, format).Encode(handler.source.Metrics()); err != nil {
+		slog.Error("failed to write metrics", "err", err.Error(), traceAttr)
+		return
 	}
 
-	return nil
+	slog.Debug("metrics have been successfully served", traceAttr, "duration_ms", time.Since(start).Milliseconds())
 }
 
 // Metric olds the name and value of a measurement in addition to its labels.
@@ -204,17 +92,83 @@ type Metric struct {
 	Name   string
 	Labels map[string]string
 	Value  float64
+	// Created is the time a counter started to accumulate its value. Zero for gauges.
+	Created time.Time
 }
 
 type Impact struct {
 	// Labels for impact
 	Labels map[string]string
+	// Tags are the raw cloud tags (or labels) of the resource. They are turned into labels
+	// by the LabelPolicy.
+	Tags map[string]string
 	// Energy in watts
 	Energy Energy
+	// OverheadEnergy is the share of Energy drawn by the datacenter cooling and power
+	// distribution rather than by the resource itself, in watts
+	OverheadEnergy Energy
 	// EnergyEmissions are emissions related to energy in kgCO2eq/day
 	EnergyEmissions EmissionsOverTime
+	// MarketEmissions are the market-based emissions related to energy, where the
+	// carbon-free energy purchased by the provider emits nothing. Unset if the explorer does
+	// not know its carbon-free energy.
+	MarketEmissions EmissionsOverTime
 	// EmbodiedEmissions are emissions related to the manufacturing
 	EmbodiedEmissions EmissionsOverTime
+	// OnSiteWater is the water consumed by the datacenter cooling for the resource
+	OnSiteWater Water
+	// OffSiteWater is the water consumed to generate the electricity of the resource
+	OffSiteWater Water
+	// Components breaks down energy and emissions by resource component
+	Components map[Component]*ComponentImpact
+	// Inputs are the model inputs that produced the impact
+	Inputs ImpactInputs
+}
+
+// ImpactInputs holds the model inputs used to estimate an impact. Fields that don't apply
+// to the resource kind are left empty.
+type ImpactInputs struct {
+	InstanceType string           `json:"instance_type,omitempty"`
+	VCPU         float64          `json:"vcpu,omitempty"`
+	MemoryGB     float64          `json:"memory_gb,omitempty"`
+	Processor    *ProcessorInputs `json:"processor,omitempty"`
+	GPU          *GPUInputs       `json:"gpu,omitempty"`
+	// CPUAverage is the average cpu usage in percent
+	CPUAverage float64 `json:"cpu_average_percent,omitempty"`
+	// PUE is the power usage effectiveness applied to the resource energy
+	PUE float64 `json:"pue,omitempty"`
+	// CarbonIntensity is the grid carbon intensity used in gCO2eq/kWh
+	CarbonIntensity float64 `json:"carbon_intensity_gco2eq_kwh,omitempty"`
+	// CarbonIntensitySource names where the grid carbon intensity comes from
+	CarbonIntensitySource string `json:"carbon_intensity_source,omitempty"`
+	// CarbonIntensityTimestamp is when the grid carbon intensity was measured. It is zero
+	// for yearly averages.
+	CarbonIntensityTimestamp time.Time `json:"carbon_intensity_timestamp,omitzero"`
+	// CarbonFreeEnergy is the share of carbon-free energy (0 to 1) used for market-based
+	// emissions
+	CarbonFreeEnergy float64 `json:"carbon_free_energy,omitempty"`
+	// WUE is the datacenter water usage effectiveness used in litres/kWh
+	WUE float64 `json:"wue_l_kwh,omitempty"`
+	// GridWaterIntensity is the water consumed by the grid to generate a kWh, in litres
+	GridWaterIntensity float64 `json:"grid_water_intensity_l_kwh,omitempty"`
+}
+
+// ProcessorInputs describes the processor matched in the processors database
+type ProcessorInputs struct {
+	Name    string  `json:"name"`
+	Family  string  `json:"family"`
+	TDP     float64 `json:"tdp"`
+	Cores   float64 `json:"cores"`
+	Threads float64 `json:"threads"`
+}
+
+// GPUInputs describes the accelerators matched in the gpus database
+type GPUInputs struct {
+	Name  string  `json:"name"`
+	TDP   float64 `json:"tdp"`
+	Count float64 `json:"count"`
+	// Usage is the average gpu usage assumed in percent
+	Usage float64 `json:"usage_percent"`
 }
 
 // Clone return a deep copy of a metric.
@@ -222,9 +176,10 @@ func (m Metric) Clone() Metric {
 	copiedLabel := make(map[string]string, len(m.Labels))
 	maps.Copy(copiedLabel, m.Labels)
 	return Metric{
-		Name:   m.Name,
-		Value:  m.Value,
-		Labels: copiedLabel,
+		Name:    m.Name,
+		Value:   m.Value,
+		Labels:  copiedLabel,
+		Created: m.Created,
 	}
 }
 
@@ -248,19 +203,34 @@ func (m *Metric) SetValue(v float64) *Metric {
 	return m
 }
 
+// SanitizeLabels replaces all characters not allowed in label names by underscores.
 func (m *Metric) SanitizeLabels() *Metric {
 	newLabels := make(map[string]string)
-	invalidChars := []string{".", "/", "-", ":", ";"}
 	for label, value := range m.Labels {
-		for _, char := range invalidChars {
-			label = strings.ReplaceAll(label, char, "_")
-		}
-		newLabels[label] = value
+		newLabels[sanitizeLabelName(label)] = value
 	}
 	m.Labels = newLabels
 	return m
 }
 
+// sanitizeLabelName returns a label name matching [a-zA-Z_][a-zA-Z0-9_]*
+func sanitizeLabelName(label string) string {
+	sanitized := []rune(label)
+	for i, char := range sanitized {
+		isLetter := (char >= 'a' && char <= 'z') || (char >= 'A' && char <= 'Z') || char == '_'
+		isDigit := char >= '0' && char <= '9'
+		if !isLetter && !isDigit {
+			sanitized[i] = '_'
+		}
+	}
+
+	if len(sanitized) > 0 && sanitized[0] >= '0' && sanitized[0] <= '9' {
+		return "_" + string(sanitized)
+	}
+
+	return string(sanitized)
+}
+
 func NewEmbodiedEmissionsMetric(value EmissionsOverTime) *Metric {
 	return &Metric{
 		Name:  "estimated_embodied_emissions_kgCO2eq_day",
@@ -275,6 +245,20 @@ func NewEnergyMetric(value Energy) *Metric {
 	}
 }
 
+func NewOverheadEnergyMetric(value Energy) *Metric {
+	return &Metric{
+		Name:  "estimated_overhead_watts",
+		Value: float64(value),
+	}
+}
+
+func NewWaterMetric(value Water) *Metric {
+	return &Metric{
+		Name:  "estimated_water_litres_day",
+		Value: float64(value),
+	}
+}
+
 func NewEmissionsMetric(value EmissionsOverTime) *Metric {
 	return &Metric{
 		Name:  "estimated_usage_emissions_kgCO2eq_day",
@@ -22,5 +22,10 @@ func TestSanitizeLabels(t *testing.T) {
 		"aws_ec2launchtemplate_version": "1.0",
 		"karpenter_sh_nodeclaim":        "",
 	}, m.SanitizeLabels().Labels)
+}
 
+func TestSanitizeLabelName(t *testing.T) {
+	assert.Equal(t, "app_kubernetes_io_name", sanitizeLabelName("app.kubernetes.io/name"))
+	assert.Equal(t, "cost_center_", sanitizeLabelName("cost center?"))
+	assert.Equal(t, "_1st_team", sanitizeLabelName("1st-team"))
 }
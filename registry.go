@@ -0,0 +1,153 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ExplorerSettings are the settings shared by the explorers of all providers
+type ExplorerSettings struct {
+	// Regions restricts the exploration to these regions. All regions are explored if empty.
+	Regions []string
+	// Services restricts the exploration to these supported services. All supported
+	// services are explored if empty.
+	Services    []string
+	Credentials ExplorerCredentials
+	// PUE overrides the power usage effectiveness of the provider datacenters if not zero
+	PUE float64
+	// LocationPUE overrides the power usage effectiveness by location prefix, before PUE
+	LocationPUE map[string]float64
+	// MonthlyPUE overrides the power usage effectiveness of each month, January first, by
+	// location prefix. Months without value use LocationPUE or PUE.
+	MonthlyPUE map[string][]float64
+	// Intensity overrides the grid carbon intensity (gCO2eq/kWh) by location prefix
+	Intensity map[string]float64
+	// IntensityProviders are the time-varying grid carbon intensity providers, tried in order
+	// after the overrides and before the static intensity map of the provider
+	IntensityProviders []IntensityProvider
+	// CarbonFreeEnergy sets the share of carbon-free energy (0 to 1) matched by the provider
+	// energy purchases by location prefix, for market-based emissions
+	CarbonFreeEnergy map[string]float64
+	// CacheTTL overrides how long discovery and monitoring data are cached if not zero
+	CacheTTL time.Duration
+	// Transport sends the requests of the provider api clients if not nil, e.g. to record
+	// their exchanges
+	Transport http.RoundTripper
+	// Offline is true if Transport serves recorded exchanges. Credentials are not loaded and
+	// placeholders are used instead.
+	Offline bool
+}
+
+// ExplorerCredentials references the credentials of an explorer. Secrets are never set
+// directly, only the files, profiles or environment variables holding them.
+type ExplorerCredentials struct {
+	Profile      string
+	File         string
+	AccessKeyEnv string
+	SecretKeyEnv string
+}
+
+// FieldError is an error of an explorer configuration field. Path is relative to the
+// explorer configuration, e.g. [aws organization_role] or [credentials profile].
+type FieldError struct {
+	Path []string
+	Msg  string
+}
+
+func (err *FieldError) Error() string {
+	return strings.Join(err.Path, ".") + ": " + err.Msg
+}
+
+// ProviderConfig is the configuration specific to a provider. It is decoded from the
+// section named after the provider in the explorer configuration and its fields are set
+// with yaml tags.
+type ProviderConfig interface {
+	// Validate checks the provider configuration and the shared settings it supports
+	Validate(settings *ExplorerSettings) []*FieldError
+}
+
+// ExplorerProvider creates the explorers of a cloud provider
+type ExplorerProvider struct {
+	// Name identifies the provider in the configuration, e.g. aws
+	Name string
+	// SupportedServices lists the services whose impacts are estimated
+	SupportedServices []string
+	// Locations lists the regions of the provider datacenters, used to validate the
+	// locations of emission factor files. Locations are not validated if empty.
+	Locations []string
+	// NewConfig returns the provider configuration holding its default values
+	NewConfig func() ProviderConfig
+	// New creates an explorer from its settings and provider configuration. The explorer
+	// is initialized by the caller.
+	New func(ctx context.Context, settings *ExplorerSettings, config ProviderConfig) (Explorer, error)
+}
+
+// KnowsLocation returns true if the location is one of the provider locations or one of
+// their zones (eu-west-3a, europe-west1-b, fr-par-1). All locations are known if the provider
+// does not list them.
+func (provider ExplorerProvider) KnowsLocation(location string) bool {
+	if len(provider.Locations) == 0 {
+		return true
+	}
+	location = strings.ToLower(location)
+	for _, known := range provider.Locations {
+		zone, found := strings.CutPrefix(location, strings.ToLower(known))
+		if found && (zone == "" || zoneSuffix.MatchString(zone)) {
+			return true
+		}
+	}
+	return false
+}
+
+// zoneSuffix matches the zone suffix of a region: a letter for aws, a dash followed by a
+// letter or digit for gcp and scaleway
+var zoneSuffix = regexp.MustCompile(`^([a-z]|-[a-z0-9])$`)
+
+var (
+	providersMu = new(sync.RWMutex)
+	providers   = make(map[string]ExplorerProvider)
+)
+
+// RegisterExplorerProvider makes an explorer provider available by its name. It is meant to
+// be called from the init function of the provider package and panics if the provider is
+// registered twice.
+func RegisterExplorerProvider(provider ExplorerProvider) {
+	providersMu.Lock()
+	defer providersMu.Unlock()
+
+	if provider.Name == "" || provider.NewConfig == nil || provider.New == nil {
+		panic("cloudcarbonexporter: explorer provider must have a name, a config and a factory")
+	}
+	if _, found := providers[provider.Name]; found {
+		panic(fmt.Sprintf("cloudcarbonexporter: explorer provider %s is already registered", provider.Name))
+	}
+	providers[provider.Name] = provider
+}
+
+// LookupExplorerProvider returns the registered provider named name
+func LookupExplorerProvider(name string) (ExplorerProvider, bool) {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	provider, found := providers[name]
+	return provider, found
+}
+
+// ExplorerProviders returns the registered providers sorted by name
+func ExplorerProviders() []ExplorerProvider {
+	providersMu.RLock()
+	defer providersMu.RUnlock()
+
+	registered := make([]ExplorerProvider, 0, len(providers))
+	for _, name := range slices.Sorted(maps.Keys(providers)) {
+		registered = append(registered, providers[name])
+	}
+	return registered
+}
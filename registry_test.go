@@ -0,0 +1,59 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeProviderConfig struct {
+	Account string `yaml:"account"`
+}
+
+func (config *fakeProviderConfig) Validate(settings *ExplorerSettings) []*FieldError {
+	if config.Account == "" {
+		return []*FieldError{{Path: []string{"fake", "account"}, Msg: "must be set"}}
+	}
+	return nil
+}
+
+func TestRegisterExplorerProvider(t *testing.T) {
+	provider := ExplorerProvider{
+		Name:              "fake",
+		SupportedServices: []string{"fake/resource"},
+		NewConfig:         func() ProviderConfig { return new(fakeProviderConfig) },
+		New: func(ctx context.Context, settings *ExplorerSettings, config ProviderConfig) (Explorer, error) {
+			return newFakeExplorer(), nil
+		},
+	}
+	RegisterExplorerProvider(provider)
+	assert.Panics(t, func() { RegisterExplorerProvider(provider) })
+	assert.Panics(t, func() { RegisterExplorerProvider(ExplorerProvider{Name: "incomplete"}) })
+
+	registered, found := LookupExplorerProvider("fake")
+	assert.True(t, found)
+	assert.Equal(t, []string{"fake/resource"}, registered.SupportedServices)
+	assert.Equal(t, "fake.account: must be set", registered.NewConfig().Validate(new(ExplorerSettings))[0].Error())
+
+	_, found = LookupExplorerProvider("unknown")
+	assert.False(t, found)
+
+	names := make([]string, 0)
+	for _, provider := range ExplorerProviders() {
+		names = append(names, provider.Name)
+	}
+	assert.Contains(t, names, "fake")
+}
+
+func TestExplorerProviderKnowsLocation(t *testing.T) {
+	provider := ExplorerProvider{Locations: []string{"eu-west-3", "europe-west1", "fr-par"}}
+
+	for _, location := range []string{"eu-west-3", "EU-WEST-3", "eu-west-3a", "europe-west1-b", "fr-par-2"} {
+		assert.True(t, provider.KnowsLocation(location), location)
+	}
+	for _, location := range []string{"eu-west-30", "europe-west12", "fr-paris", "mars-north1"} {
+		assert.False(t, provider.KnowsLocation(location), location)
+	}
+	assert.True(t, ExplorerProvider{}.KnowsLocation("mars-north1"), "locations are not validated")
+}
@@ -0,0 +1,146 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"crypto/subtle"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"time"
+)
+
+func init() {
+	RegisterMetricFamilies(
+		MetricFamily{
+			Name: "config_last_reload_successful",
+			Help: "Whether the last configuration reload succeeded (1) or not (0).",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "config_last_reload_success_timestamp_seconds",
+			Help: "Timestamp of the last successful configuration reload.",
+			Type: GaugeType,
+			Unit: "seconds",
+		},
+	)
+}
+
+// ReloadFunc loads the configuration again and applies it. It must leave the running
+// configuration untouched if it fails.
+type ReloadFunc func(ctx context.Context) error
+
+// Reloader runs configuration reloads one at a time and keeps the outcome of the last one.
+// The configuration loaded at startup counts as a successful reload.
+type Reloader struct {
+	reload      ReloadFunc
+	successful  bool
+	lastSuccess time.Time
+	mu          *sync.Mutex
+}
+
+// NewReloader returns a new Reloader applying the configuration with the reload func
+func NewReloader(reload ReloadFunc) *Reloader {
+	return &Reloader{
+		reload:      reload,
+		successful:  true,
+		lastSuccess: time.Now(),
+		mu:          new(sync.Mutex),
+	}
+}
+
+// Reload reloads the configuration and records the outcome
+func (reloader *Reloader) Reload(ctx context.Context) error {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	err := reloader.reload(ctx)
+	reloader.successful = err == nil
+	if err != nil {
+		slog.Error("failed to reload configuration, keeping the running one", "err", err.Error())
+		return err
+	}
+
+	reloader.lastSuccess = time.Now()
+	slog.Info("configuration has been successfully reloaded")
+	return nil
+}
+
+// WatchSignals reloads the configuration each time one of the signals is received, until
+// the context is done.
+func (reloader *Reloader) WatchSignals(ctx context.Context, signals ...os.Signal) {
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sig := <-received:
+			slog.Info("reloading configuration", "signal", sig.String())
+			_ = reloader.Reload(ctx)
+		}
+	}
+}
+
+// Metrics returns the outcome of the last reload
+func (reloader *Reloader) Metrics() []*Metric {
+	reloader.mu.Lock()
+	defer reloader.mu.Unlock()
+
+	successful := 0.0
+	if reloader.successful {
+		successful = 1.0
+	}
+
+	return []*Metric{
+		{Name: "config_last_reload_successful", Labels: map[string]string{}, Value: successful},
+		{Name: "config_last_reload_success_timestamp_seconds", Labels: map[string]string{}, Value: float64(reloader.lastSuccess.Unix())},
+	}
+}
+
+// ReloadHandler reloads the configuration on POST requests authenticated with a bearer
+// token. All requests are forbidden if the token is empty.
+type ReloadHandler struct {
+	reloader *Reloader
+	token    string
+}
+
+// NewReloadHandler creates a new ReloadHandler
+func NewReloadHandler(reloader *Reloader, token string) *ReloadHandler {
+	return &ReloadHandler{
+		reloader: reloader,
+		token:    token,
+	}
+}
+
+// ServeHTTP implements the http.Handler interface
+func (handler *ReloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if handler.token == "" {
+		writeJSON(w, http.StatusForbidden, map[string]string{"status": "error", "error": "reload endpoint is disabled"})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"status": "error", "error": "only POST requests are allowed"})
+		return
+	}
+
+	token, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !found || subtle.ConstantTimeCompare([]byte(token), []byte(handler.token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"status": "error", "error": "invalid reload token"})
+		return
+	}
+
+	if err := handler.reloader.Reload(r.Context()); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"status": "error", "error": err.Error()})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"status": "success"})
+}
@@ -0,0 +1,64 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReloader(t *testing.T) {
+	var reloadErr error
+	reloads := 0
+	reloader := NewReloader(func(ctx context.Context) error {
+		reloads++
+		return reloadErr
+	})
+
+	metrics := reloader.Metrics()
+	assert.Equal(t, 1.0, metrics[0].Value, "startup configuration is successful")
+	startup := metrics[1].Value
+
+	reloadErr = fmt.Errorf("invalid configuration")
+	assert.Error(t, reloader.Reload(t.Context()))
+	metrics = reloader.Metrics()
+	assert.Equal(t, 0.0, metrics[0].Value)
+	assert.Equal(t, startup, metrics[1].Value, "failed reloads don't move the success timestamp")
+
+	reloadErr = nil
+	assert.NoError(t, reloader.Reload(t.Context()))
+	assert.Equal(t, 1.0, reloader.Metrics()[0].Value)
+	assert.Equal(t, 2, reloads)
+}
+
+func TestReloadHandler(t *testing.T) {
+	reloads := 0
+	reloader := NewReloader(func(ctx context.Context) error {
+		reloads++
+		return nil
+	})
+
+	reload := func(handler http.Handler, method string, authorization string) int {
+		req := httptest.NewRequest(method, "/-/reload", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusForbidden, reload(NewReloadHandler(reloader, ""), http.MethodPost, "Bearer "))
+
+	handler := NewReloadHandler(reloader, "secret")
+	assert.Equal(t, http.StatusMethodNotAllowed, reload(handler, http.MethodGet, "Bearer secret"))
+	assert.Equal(t, http.StatusUnauthorized, reload(handler, http.MethodPost, ""))
+	assert.Equal(t, http.StatusUnauthorized, reload(handler, http.MethodPost, "Bearer wrong"))
+	assert.Equal(t, 0, reloads)
+
+	assert.Equal(t, http.StatusOK, reload(handler, http.MethodPost, "Bearer secret"))
+	assert.Equal(t, 1, reloads)
+}
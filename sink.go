@@ -0,0 +1,41 @@
+package cloudcarbonexporter
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// Sink pushes metrics to a remote system, as an alternative to scrapes.
+type Sink interface {
+	Push(ctx context.Context, metrics []*Metric) error
+}
+
+// RunSink pushes the source metrics to the sink at each interval until the context is done.
+// Nothing is pushed while the source has no metrics.
+func RunSink(ctx context.Context, sinkName string, sink Sink, source MetricsSource, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		metrics := source.Metrics()
+		if len(metrics) == 0 {
+			slog.Debug("no metrics to push yet", "sink", sinkName)
+			continue
+		}
+
+		start := time.Now()
+		if err := sink.Push(ctx, metrics); err != nil {
+			slog.Warn("failed to push metrics", "sink", sinkName, "err", err.Error())
+			continue
+		}
+
+		slog.Debug("metrics pushed", "sink", sinkName, "metrics", len(metrics), "duration_ms", time.Since(start).Milliseconds())
+	}
+}
@@ -0,0 +1,238 @@
+package cloudcarbonexporter
+
+import (
+	"cmp"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/superdango/cloud-carbon-exporter/internal/cache"
+)
+
+// UnknownOperation is the operation of errors not wrapped in an ExplorerErr
+const UnknownOperation = "unknown"
+
+func init() {
+	RegisterMetricFamilies(
+		MetricFamily{
+			Name: "api_operation_calls",
+			Help: "Number of cloud api calls made by operation during the last collection.",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "collect_operation_errors",
+			Help: "Number of errors by failing operation during the last collection.",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "collect_subexplorer_duration_ms",
+			Help: "Duration of the last collection of a sub explorer in a location.",
+			Type: GaugeType,
+			Unit: "ms",
+		},
+		MetricFamily{
+			Name: "collect_subexplorer_resources",
+			Help: "Number of resources emitted by a sub explorer in a location during the last collection.",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "collect_subexplorer_errors",
+			Help: "Number of errors returned by a sub explorer in a location during the last collection.",
+			Type: GaugeType,
+		},
+		MetricFamily{
+			Name: "cache_hits_total",
+			Help: "Number of explorer cache lookups served from memory since startup.",
+			Type: CounterType,
+		},
+		MetricFamily{
+			Name: "cache_misses_total",
+			Help: "Number of explorer cache lookups on missing or expired keys since startup.",
+			Type: CounterType,
+		},
+		MetricFamily{
+			Name: "cache_refreshes_total",
+			Help: "Number of explorer cache dynamic entries refreshes since startup.",
+			Type: CounterType,
+		},
+		MetricFamily{
+			Name: "cache_refresh_errors_total",
+			Help: "Number of explorer cache dynamic entries refreshes that failed since startup.",
+			Type: CounterType,
+		},
+	)
+}
+
+// CacheStats counts the lookups and refreshes of an explorer cache
+type CacheStats = cache.Stats
+
+// CacheStatsReporter is implemented by explorers keeping a cache of cloud api responses
+type CacheStatsReporter interface {
+	CacheStats() CacheStats
+}
+
+// SubExplorerStats reports the collection of a sub explorer in a location
+type SubExplorerStats struct {
+	Name      string
+	Location  string
+	Duration  time.Duration
+	Resources int
+	Errors    int
+}
+
+// CollectStats gathers the api calls, errors and sub explorers statistics of a collection.
+// It is safe for concurrent use.
+type CollectStats struct {
+	mu           *sync.Mutex
+	calls        map[string]int
+	errors       map[string]int
+	subExplorers map[[2]string]*SubExplorerStats
+}
+
+// NewCollectStats returns empty collection statistics
+func NewCollectStats() *CollectStats {
+	return &CollectStats{
+		mu:           new(sync.Mutex),
+		calls:        make(map[string]int),
+		errors:       make(map[string]int),
+		subExplorers: make(map[[2]string]*SubExplorerStats),
+	}
+}
+
+// IncrCalls counts an api call of the operation
+func (stats *CollectStats) IncrCalls(operation string) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.calls[operation]++
+}
+
+// IncrErrors counts an error by its operation. Errors not wrapped in an ExplorerErr are
+// counted with the unknown operation.
+func (stats *CollectStats) IncrErrors(err error) {
+	operation := UnknownOperation
+	experr := new(ExplorerErr)
+	if errors.As(err, &experr) && experr.Operation != "" {
+		operation = experr.Operation
+	}
+
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	stats.errors[operation]++
+}
+
+// Calls returns the number of api calls by operation
+func (stats *CollectStats) Calls() map[string]int {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	return maps.Clone(stats.calls)
+}
+
+// Errors returns the number of errors by operation
+func (stats *CollectStats) Errors() map[string]int {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+	return maps.Clone(stats.errors)
+}
+
+// SubExplorers returns the sub explorers statistics sorted by name and location
+func (stats *CollectStats) SubExplorers() []SubExplorerStats {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	subExplorers := make([]SubExplorerStats, 0, len(stats.subExplorers))
+	for _, subExplorer := range stats.subExplorers {
+		subExplorers = append(subExplorers, *subExplorer)
+	}
+	slices.SortFunc(subExplorers, func(a, b SubExplorerStats) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Location, b.Location))
+	})
+
+	return subExplorers
+}
+
+func (stats *CollectStats) observeSubExplorer(name, location string, duration time.Duration, resources int, err error) {
+	stats.mu.Lock()
+	defer stats.mu.Unlock()
+
+	key := [2]string{name, location}
+	subExplorer, found := stats.subExplorers[key]
+	if !found {
+		subExplorer = &SubExplorerStats{Name: name, Location: location}
+		stats.subExplorers[key] = subExplorer
+	}
+
+	subExplorer.Duration += duration
+	subExplorer.Resources += resources
+	if err != nil {
+		subExplorer.Errors++
+	}
+}
+
+// ObserveSubExplorer runs the collection of a sub explorer in a location and records its
+// duration, the number of impacts it emitted and whether it failed.
+func ObserveSubExplorer(ctx Context, name, location string, impacts chan *Impact, collect func(impacts chan *Impact) error) error {
+	start := time.Now()
+	observed := make(chan *Impact)
+	resources := 0
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for impact := range observed {
+			resources++
+			impacts <- impact
+		}
+	}()
+
+	err := collect(observed)
+	close(observed)
+	<-done
+
+	ctx.Stats().observeSubExplorer(name, location, time.Since(start), resources, err)
+
+	return err
+}
+
+// statsMetrics returns the metrics of the collection statistics
+func statsMetrics(baseLabels map[string]string, status *CollectionStatus) []*Metric {
+	metrics := make([]*Metric, 0)
+
+	for _, operation := range slices.Sorted(maps.Keys(status.OperationCalls)) {
+		metrics = append(metrics, &Metric{
+			Name:   "api_operation_calls",
+			Labels: MergeLabels(baseLabels, map[string]string{"operation": operation}),
+			Value:  float64(status.OperationCalls[operation]),
+		})
+	}
+
+	for _, operation := range slices.Sorted(maps.Keys(status.OperationErrors)) {
+		metrics = append(metrics, &Metric{
+			Name:   "collect_operation_errors",
+			Labels: MergeLabels(baseLabels, map[string]string{"operation": operation}),
+			Value:  float64(status.OperationErrors[operation]),
+		})
+	}
+
+	for _, subExplorer := range status.SubExplorers {
+		labels := MergeLabels(baseLabels, map[string]string{"subexplorer": subExplorer.Name, "location": subExplorer.Location})
+		metrics = append(metrics,
+			&Metric{Name: "collect_subexplorer_duration_ms", Labels: labels, Value: float64(subExplorer.Duration.Milliseconds())},
+			&Metric{Name: "collect_subexplorer_resources", Labels: labels, Value: float64(subExplorer.Resources)},
+			&Metric{Name: "collect_subexplorer_errors", Labels: labels, Value: float64(subExplorer.Errors)},
+		)
+	}
+
+	return metrics
+}
+
+// cacheMetrics returns the metrics of an explorer cache
+func cacheMetrics(baseLabels map[string]string, stats CacheStats) []*Metric {
+	return []*Metric{
+		{Name: "cache_hits_total", Labels: baseLabels, Value: float64(stats.Hits)},
+		{Name: "cache_misses_total", Labels: baseLabels, Value: float64(stats.Misses)},
+		{Name: "cache_refreshes_total", Labels: baseLabels, Value: float64(stats.Refreshes)},
+		{Name: "cache_refresh_errors_total", Labels: baseLabels, Value: float64(stats.RefreshErrors)},
+	}
+}
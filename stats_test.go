@@ -0,0 +1,67 @@
+package cloudcarbonexporter
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestObserveSubExplorer(t *testing.T) {
+	ctx := WrapCtx(t.Context())
+	impacts := make(chan *Impact)
+
+	received := 0
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for range impacts {
+			received++
+		}
+	}()
+
+	err := ObserveSubExplorer(ctx, "ec2/instance", "eu-west-3", impacts, func(impacts chan *Impact) error {
+		ctx.IncrCalls("service/ec2:DescribeInstances")
+		ctx.IncrCalls("cloudwatch:GetMetricData")
+		ctx.IncrCalls("cloudwatch:GetMetricData")
+		impacts <- &Impact{}
+		impacts <- &Impact{}
+		return nil
+	})
+	assert.NoError(t, err)
+
+	expectedErr := &ExplorerErr{Err: fmt.Errorf("throttled"), Operation: "cloudwatch:GetMetricData"}
+	err = ObserveSubExplorer(ctx, "ec2/instance", "us-east-1", impacts, func(impacts chan *Impact) error {
+		return expectedErr
+	})
+	assert.ErrorIs(t, err, expectedErr)
+
+	close(impacts)
+	<-done
+	assert.Equal(t, 2, received)
+
+	ctx.Stats().IncrErrors(err)
+	ctx.Stats().IncrErrors(fmt.Errorf("wrapped: %w", err))
+	ctx.Stats().IncrErrors(fmt.Errorf("not an explorer error"))
+
+	assert.Equal(t, 3, ctx.Calls())
+	assert.Equal(t, map[string]int{"service/ec2:DescribeInstances": 1, "cloudwatch:GetMetricData": 2}, ctx.Stats().Calls())
+	assert.Equal(t, map[string]int{"cloudwatch:GetMetricData": 2, UnknownOperation: 1}, ctx.Stats().Errors())
+
+	subExplorers := ctx.Stats().SubExplorers()
+	assert.Len(t, subExplorers, 2)
+	assert.Equal(t, "eu-west-3", subExplorers[0].Location)
+	assert.Equal(t, 2, subExplorers[0].Resources)
+	assert.Equal(t, 0, subExplorers[0].Errors)
+	assert.Equal(t, "us-east-1", subExplorers[1].Location)
+	assert.Equal(t, 1, subExplorers[1].Errors)
+
+	metrics := statsMetrics(map[string]string{"explorer": "aws"}, &CollectionStatus{
+		OperationCalls:  ctx.Stats().Calls(),
+		OperationErrors: ctx.Stats().Errors(),
+		SubExplorers:    subExplorers,
+	})
+	assert.Len(t, metrics, 2+2+2*3)
+	assert.Equal(t, "api_operation_calls", metrics[0].Name)
+	assert.Equal(t, "cloudwatch:GetMetricData", metrics[0].Labels["operation"])
+}
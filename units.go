@@ -13,6 +13,16 @@ type Energy float64
 // Emissions in gCO2eq
 type Emissions float64
 
+// Water consumption in litres per day
+type Water float64
+
+// EnergyWater returns the daily water consumption of an energy draw given a water factor
+// in litres/kWh.
+func EnergyWater(energy Energy, litresPerKWh float64) Water {
+	kWh := float64(energy/1000) * 24
+	return Water(kWh * litresPerKWh)
+}
+
 func (e Emissions) KgCO2eq() float64 {
 	return float64(e) / 1000
 }